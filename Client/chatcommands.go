@@ -0,0 +1,501 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	pb "github.com/taylorflatt/go-chat"
+	"github.com/taylorflatt/go-chat/chatclient"
+	"golang.org/x/net/context"
+)
+
+// ChatCommand is a command the user can type while chatting.
+type ChatCommand struct {
+	Name    string   // The name it is listed under in the help, e.g. "/react".
+	Aliases []string // Other names it can be typed as, e.g. the older "!edit".
+	Args    string   // The arguments it takes, e.g. "<id> <emoji>". Optional ones are in brackets.
+	Help    string   // What it does, for the help.
+	Leaves  bool     // Whether it ends the chat, so no more input is read for it.
+
+	// Run runs the command with the rest of the line as its arguments. It is only called
+	// once the arguments the command requires have been given.
+	Run func(st *ChatState, args string)
+}
+
+// ChatState is the state of the chat loop that chat commands work on.
+type ChatState struct {
+	s       *chatclient.Session
+	history map[uint64]*pb.ChatMessage // Messages seen in this group, by id.
+	thread  uint64                     // The thread new messages reply to, if any.
+	left    bool                       // Whether the user has left the group.
+}
+
+// chatCommands holds the chat commands in the order they are listed in the help.
+var chatCommands []*ChatCommand
+
+// completers complete the arguments of chat commands by the name used for them in Args.
+// Each is given the word typed so far and gives back the candidates for it.
+var completers = map[string]func(s *chatclient.Session, prefix string) []string{
+	"user": CompleteUser,
+	"path": CompletePath,
+}
+
+// RegisterChatCommand adds c to the chat commands.
+// It doesn't return anything.
+func RegisterChatCommand(c *ChatCommand) {
+
+	for _, n := range c.Names() {
+		if FindChatCommand(n) != nil {
+			panic("chat command " + n + " is registered twice")
+		}
+	}
+
+	chatCommands = append(chatCommands, c)
+}
+
+// FindChatCommand looks up the chat command called name, or one of its aliases.
+// It returns the command or nil if there isn't one.
+func FindChatCommand(name string) *ChatCommand {
+
+	for _, c := range chatCommands {
+		for _, n := range c.Names() {
+			if n == name {
+				return c
+			}
+		}
+	}
+
+	return nil
+}
+
+// Names lists the names the command can be typed as.
+// It returns the name followed by the aliases.
+func (c *ChatCommand) Names() []string {
+
+	return append([]string{c.Name}, c.Aliases...)
+}
+
+// Params splits the command's argument spec into its arguments.
+// It returns the arguments, e.g. ["<id>", "<emoji>"].
+func (c *ChatCommand) Params() []string {
+
+	return strings.Fields(c.Args)
+}
+
+// Usage formats how the command is typed.
+// It returns the usage, e.g. "/react <id> <emoji>".
+func (c *ChatCommand) Usage() string {
+
+	return strings.TrimSpace(c.Name + " " + c.Args)
+}
+
+// Execute runs the command if args has all the arguments it requires, and otherwise shows
+// how it is used. The last argument takes the rest of the line.
+// It doesn't return anything.
+func (c *ChatCommand) Execute(st *ChatState, args string) {
+
+	required := 0
+	for _, p := range c.Params() {
+		if strings.HasPrefix(p, "<") {
+			required++
+		}
+	}
+
+	if len(strings.Fields(args)) < required {
+		color.New(theme.Error).Println("Usage: " + c.Usage())
+		return
+	}
+
+	c.Run(st, args)
+}
+
+// CompleteChatLine completes the command or argument being typed at the end of line.
+// It returns the completed line and, if it couldn't be completed further, the candidates.
+func CompleteChatLine(s *chatclient.Session, line string) (string, []string) {
+
+	i := strings.IndexAny(line, " \t")
+	if i < 0 {
+		if !strings.HasPrefix(line, "/") && !strings.HasPrefix(line, "!") {
+			return line, nil
+		}
+
+		var names []string
+		for _, c := range chatCommands {
+			names = append(names, c.Names()...)
+		}
+		return Complete("", line, names)
+	}
+
+	c := FindChatCommand(line[:i])
+	if c == nil {
+		return line, nil
+	}
+
+	words := strings.Fields(line[i:])
+	prefix := ""
+	if len(words) > 0 && !strings.HasSuffix(line, " ") {
+		prefix = words[len(words)-1]
+		words = words[:len(words)-1]
+	}
+
+	params := c.Params()
+	if len(words) >= len(params) {
+		return line, nil
+	}
+
+	complete, ok := completers[strings.Trim(params[len(words)], "<>[]")]
+	if !ok {
+		return line, nil
+	}
+
+	return Complete(strings.TrimSuffix(line, prefix), prefix, complete(s, prefix))
+}
+
+// Complete completes the word prefix, which follows base, from candidates. A single match is
+// filled in, and several are filled in as far as they agree.
+// It returns the completed line and, if it couldn't be completed further, the matches.
+func Complete(base string, prefix string, candidates []string) (string, []string) {
+
+	var matches []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, prefix) {
+			matches = append(matches, c)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return base + prefix, nil
+	case 1:
+		if strings.HasSuffix(matches[0], string(filepath.Separator)) {
+			return base + matches[0], nil
+		}
+		return base + matches[0] + " ", nil
+	}
+
+	common := matches[0]
+	for _, m := range matches[1:] {
+		for !strings.HasPrefix(m, common) {
+			common = common[:len(common)-1]
+		}
+	}
+
+	if common != prefix {
+		return base + common, nil
+	}
+
+	sort.Strings(matches)
+	return base + prefix, matches
+}
+
+// CompleteUser completes the names of the users logged in to the server.
+// It returns the names.
+func CompleteUser(s *chatclient.Session, prefix string) []string {
+
+	l, err := s.Client().GetClientList(context.Background(), &pb.Empty{})
+	if err != nil {
+		return nil
+	}
+
+	return l.Clients
+}
+
+// CompletePath completes the paths of local files, marking directories with a trailing slash.
+// It returns the paths.
+func CompletePath(s *chatclient.Session, prefix string) []string {
+
+	paths, _ := filepath.Glob(prefix + "*")
+	for i, p := range paths {
+		if fi, err := os.Stat(p); err == nil && fi.IsDir() {
+			paths[i] = p + string(filepath.Separator)
+		}
+	}
+
+	return paths
+}
+
+// DisplayChatHelp lists the chat commands.
+// It doesn't return anything.
+func DisplayChatHelp(st *ChatState, args string) {
+
+	AddSpacing(1)
+	fmt.Fprintln(out, "The following commands are available to you (tab completes them): ")
+	for _, c := range chatCommands {
+		color.New(theme.Highlight).Print("   " + c.Usage())
+		fmt.Fprint(out, ": "+c.Help)
+		if len(c.Aliases) > 0 {
+			color.New(theme.Dim).Print(" (also " + strings.Join(c.Aliases, ", ") + ")")
+		}
+		AddSpacing(1)
+	}
+	AddSpacing(1)
+
+	// Anything else starting with a slash is run by the server, which has its own list.
+	res, err := st.s.Client().RunCommand(context.Background(), &pb.ChatMessage{Sender: st.s.User(), Receiver: st.s.Group(), Message: "/help"})
+	if err == nil {
+		fmt.Fprintln(out, res.Message)
+		AddSpacing(1)
+	}
+}
+
+// DisplayWho lists the users logged in to the server, marking those in the current group.
+// It doesn't return anything.
+func DisplayWho(st *ChatState, args string) {
+
+	c := st.s.Client()
+
+	l, err := c.GetClientList(context.Background(), &pb.Empty{})
+	if err != nil {
+		color.New(theme.Error).Println("Couldn't list the users. " + chatclient.ErrorMessage(err))
+		return
+	}
+
+	in := make(map[string]bool)
+	if m, err := c.GetGroupClientList(context.Background(), &pb.GroupInfo{GroupName: st.s.Group()}); err == nil {
+		for _, n := range m.Clients {
+			in[n] = true
+		}
+	}
+
+	names := MemberNames(l)
+	for i, n := range l.Clients {
+		if in[n] {
+			names[i] += " (in " + st.s.Group() + ")"
+		}
+	}
+
+	sort.Strings(names)
+	fmt.Fprintln(out, "Online:")
+	for _, n := range names {
+		fmt.Fprintln(out, "  "+n)
+	}
+	AddSpacing(1)
+}
+
+// HandleTopic handles the /topic command, which shows the group's topic or, given one, sets it.
+// It doesn't return anything.
+func HandleTopic(st *ChatState, args string) {
+
+	c := st.s.Client()
+	g := st.s.Group()
+
+	if args == "" {
+		t, err := c.GetTopic(context.Background(), &pb.GroupInfo{GroupName: g})
+		if err != nil {
+			color.New(theme.Error).Println("Couldn't get the topic. " + chatclient.ErrorMessage(err))
+		} else if t.Topic == "" {
+			fmt.Fprintln(out, g+" doesn't have a topic yet.")
+		} else {
+			fmt.Fprintln(out, "The topic of "+g+" is: "+t.Topic)
+		}
+		return
+	}
+
+	if _, err := c.SetTopic(context.Background(), &pb.Topic{Client: st.s.User(), GroupName: g, Topic: args}); err != nil {
+		color.New(theme.Error).Println("Couldn't set the topic. " + chatclient.ErrorMessage(err))
+	}
+}
+
+// HandleHooks handles the /hooks command, which lets the group's moderator list, create and
+// delete the group's incoming webhooks.
+// It doesn't return anything.
+func HandleHooks(st *ChatState, args string) {
+
+	c := st.s.Client()
+	u := st.s.User()
+	g := st.s.Group()
+
+	switch action, rest := SplitCommand(args); action {
+	case "":
+		l, err := c.ListIncomingHooks(context.Background(), &pb.GroupInfo{Client: u, GroupName: g})
+		if err != nil {
+			color.New(theme.Error).Println("Couldn't list the webhooks. " + chatclient.ErrorMessage(err))
+		} else if len(l.Hooks) == 0 {
+			fmt.Fprintln(out, g+" doesn't have any webhooks. Type /hooks create <name> to add one.")
+		} else {
+			fmt.Fprintln(out, "Webhooks of "+g+":")
+			for _, h := range l.Hooks {
+				fmt.Fprintln(out, "  "+h.Name+": /hooks/"+h.Token)
+			}
+		}
+	case "create":
+		h, err := c.CreateIncomingHook(context.Background(), &pb.IncomingHook{Client: u, GroupName: g, Name: rest})
+		if err != nil {
+			color.New(theme.Error).Println("Couldn't create the webhook. " + chatclient.ErrorMessage(err))
+			return
+		}
+		color.New(theme.Success).Println("Created a webhook that posts as " + h.Name + ".")
+		fmt.Fprintln(out, "POST messages to /hooks/"+h.Token+" on the server's HTTP address, e.g.")
+		fmt.Fprintln(out, "  curl -d 'Deploy finished' http://localhost:12022/hooks/"+h.Token)
+	case "delete":
+		if _, err := c.DeleteIncomingHook(context.Background(), &pb.IncomingHook{Client: u, GroupName: g, Token: rest}); err != nil {
+			color.New(theme.Error).Println("Couldn't delete the webhook. " + chatclient.ErrorMessage(err))
+			return
+		}
+		fmt.Fprintln(out, "Deleted the webhook.")
+	default:
+		color.New(theme.Error).Println("Usage: /hooks, /hooks create <name> or /hooks delete <token>")
+	}
+	AddSpacing(1)
+}
+
+func init() {
+
+	RegisterChatCommand(&ChatCommand{
+		Name:    "/members",
+		Aliases: []string{"!members"},
+		Help:    "Lists the current members in the group.",
+		Run: func(st *ChatState, args string) {
+			DisplayCurrentMembers(st.s.Client(), st.s.Group())
+		},
+	})
+	RegisterChatCommand(&ChatCommand{
+		Name: "/who",
+		Help: "Lists everyone logged in to the server.",
+		Run:  DisplayWho,
+	})
+	RegisterChatCommand(&ChatCommand{
+		Name:    "/seen",
+		Aliases: []string{"!seen"},
+		Help:    "Shows who has read the latest message.",
+		Run: func(st *ChatState, args string) {
+			DisplaySeenBy(st.s.Client(), st.s.Group())
+		},
+	})
+	RegisterChatCommand(&ChatCommand{
+		Name:    "/edit",
+		Aliases: []string{"!edit"},
+		Args:    "<id> <message>",
+		Help:    "Replaces the text of a message.",
+		Run: func(st *ChatState, args string) {
+			EditMessage(st.s.Client(), st.s.User(), st.s.Group(), args, st.history)
+		},
+	})
+	RegisterChatCommand(&ChatCommand{
+		Name:    "/delete",
+		Aliases: []string{"!delete"},
+		Args:    "<id>",
+		Help:    "Deletes a message.",
+		Run: func(st *ChatState, args string) {
+			DeleteMessage(st.s.Client(), st.s.User(), st.s.Group(), args, st.history)
+		},
+	})
+	RegisterChatCommand(&ChatCommand{
+		Name: "/react",
+		Args: "<id> <emoji>",
+		Help: "Adds or removes your reaction to a message.",
+		Run: func(st *ChatState, args string) {
+			ReactToMessage(st.s.Client(), st.s.User(), st.s.Group(), args, st.history)
+		},
+	})
+	RegisterChatCommand(&ChatCommand{
+		Name: "/thread",
+		Args: "[id]",
+		Help: "Opens the thread a message belongs to, or goes back to the main chat.",
+		Run: func(st *ChatState, args string) {
+			st.thread = OpenThread(st.s, args, st.thread, st.history)
+		},
+	})
+	RegisterChatCommand(&ChatCommand{
+		Name: "/mentions",
+		Help: "Lists the latest messages that mentioned you.",
+		Run: func(st *ChatState, args string) {
+			DisplayMentions(st.s.Client(), st.s.User())
+		},
+	})
+	RegisterChatCommand(&ChatCommand{
+		Name: "/topic",
+		Args: "[topic]",
+		Help: "Shows the group's topic, or sets it.",
+		Run:  HandleTopic,
+	})
+	RegisterChatCommand(&ChatCommand{
+		Name:    "/msg",
+		Aliases: []string{"/tell"},
+		Args:    "<user> <message>",
+		Help:    "Sends a private message to a user.",
+		Run: func(st *ChatState, args string) {
+			to, text := SplitCommand(args)
+			if err := st.s.SendDirect(to, text); err != nil {
+				color.New(theme.Error).Println("Your message wasn't sent. " + chatclient.ErrorMessage(err))
+			}
+		},
+	})
+	RegisterChatCommand(&ChatCommand{
+		Name: "/me",
+		Args: "<action>",
+		Help: "Describes what you are doing, e.g. /me waves.",
+		Run: func(st *ChatState, args string) {
+			if err := st.s.Act(args); err != nil {
+				color.New(theme.Error).Println("Your message wasn't sent. " + chatclient.ErrorMessage(err))
+			}
+		},
+	})
+	RegisterChatCommand(&ChatCommand{
+		Name: "/nick",
+		Args: "<name>",
+		Help: "Changes your username.",
+		Run: func(st *ChatState, args string) {
+			if err := st.s.Rename(args); err != nil {
+				color.New(theme.Error).Println("Couldn't change your name. " + chatclient.ErrorMessage(err))
+			}
+		},
+	})
+	RegisterChatCommand(&ChatCommand{
+		Name: "/hooks",
+		Args: "[create|delete] [name|token]",
+		Help: "Lists the group's incoming webhooks, or creates or deletes one. Only its moderator can.",
+		Run:  HandleHooks,
+	})
+	RegisterChatCommand(&ChatCommand{
+		Name: "/send",
+		Args: "<path>",
+		Help: "Shares a file with the group.",
+		Run: func(st *ChatState, args string) {
+			if err := SendFile(st.s.Client(), st.s.User(), st.s.Group(), args); err != nil {
+				color.New(theme.Error).Println("Couldn't send the file. " + chatclient.ErrorMessage(err))
+			}
+		},
+	})
+	RegisterChatCommand(&ChatCommand{
+		Name: "/get",
+		Args: "<id> <path>",
+		Help: "Downloads a shared file.",
+		Run: func(st *ChatState, args string) {
+			id, dest := SplitCommand(args)
+			if err := GetFile(st.s.Client(), st.s.User(), id, dest); err != nil {
+				color.New(theme.Error).Println("Couldn't get the file. " + chatclient.ErrorMessage(err))
+			}
+		},
+	})
+	RegisterChatCommand(&ChatCommand{
+		Name:    "/leave",
+		Aliases: []string{"!leave"},
+		Help:    "Leaves the group and goes back to the menu.",
+		Leaves:  true,
+		Run: func(st *ChatState, args string) {
+			st.s.Leave()
+			st.left = true
+		},
+	})
+	RegisterChatCommand(&ChatCommand{
+		Name:    "/exit",
+		Aliases: []string{"!exit", "/quit"},
+		Help:    "Leaves the chat server.",
+		Leaves:  true,
+		Run: func(st *ChatState, args string) {
+			st.s.Leave()
+			ExitClient(st.s)
+		},
+	})
+	RegisterChatCommand(&ChatCommand{
+		Name:    "/help",
+		Aliases: []string{"!help"},
+		Help:    "Shows this list.",
+		Run:     DisplayChatHelp,
+	})
+}
@@ -2,18 +2,23 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
 
 	"github.com/fatih/color"
 	pb "github.com/taylorflatt/go-chat"
+	"github.com/taylorflatt/go-chat/chatclient"
 	"golang.org/x/net/context"
-	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type Watcher struct {
@@ -24,7 +29,6 @@ type Watcher struct {
 type Monitor struct {
 	chatting  bool
 	ch        chan os.Signal
-	stream    pb.Chat_RouteChatClient
 	WaitGroup *sync.WaitGroup
 }
 
@@ -32,7 +36,6 @@ func CreateMonitor() *Monitor {
 	m := &Monitor{
 		chatting:  false,
 		ch:        make(chan os.Signal),
-		stream:    nil,
 		WaitGroup: &sync.WaitGroup{},
 	}
 
@@ -64,10 +67,10 @@ func (s *Watcher) Stop() {
 }
 
 // ControlExit handles any interrupts during program execution.
-// Note: The routine control is dictated by the existence of a stream. If one is present, the user is in a group and needs
+// Note: The routine control is dictated by whether the user is chatting. If they are, the user is in a group and needs
 // to be removed. Otherwise, the user is still in the menu system.
 // It doesn't return anything.
-func (m *Monitor) ControlExit(c pb.ChatClient, u string, g string) {
+func (m *Monitor) ControlExit(s *chatclient.Session) {
 
 	log.Print("[ControlExit]: Entered.")
 
@@ -80,13 +83,13 @@ func (m *Monitor) ControlExit(c pb.ChatClient, u string, g string) {
 			log.Print("[ControlExit]: I need to quit the application!")
 			if m.chatting {
 				log.Print("[ControlExit]: I am chatting.")
-				m.stream.Send(&pb.ChatMessage{Sender: u, Receiver: g, Message: u + " left chat!\n"})
-				ExitClient(c, u, g)
+				s.Leave()
+				ExitClient(s)
 				return
 			}
 
 			log.Print("[ControlExit]: I am NOT chatting.")
-			ExitClient(c, u, g)
+			ExitClient(s)
 			os.Exit(1)
 			return
 		}
@@ -95,195 +98,541 @@ func (m *Monitor) ControlExit(c pb.ChatClient, u string, g string) {
 
 // ExitClient handles removing the client from the server and exiting the program.
 // It doesn't return anything.
-func ExitClient(c pb.ChatClient, u string, g string) {
+func ExitClient(s *chatclient.Session) {
 
-	c.UnRegister(context.Background(), &pb.ClientInfo{Sender: u})
+	if console != nil {
+		console.Close()
+	}
+	s.Close()
 	os.Exit(1)
 }
 
 // ListenToClient listens to the client for input and adds that input to the sQueue with
-// the username of the sender, group name, and the message.
+// the username of the sender, group name, and the message. It stops after a command that
+// ends the chat, and treats the end of the input as the user exiting.
 // It doesn't return anything.
-func ListenToClient(sQueue *Watcher, reader *bufio.Reader, uName string, gName string) {
+func ListenToClient(sQueue *Watcher, con *Console, uName string, gName string) {
 
 	log.Println("[ListenToClient]: Starting.")
 	defer sQueue.WaitGroup.Done()
 
 	for {
-		msg, _ := reader.ReadString('\n')
-		if strings.TrimSpace(msg) == "!leave" {
-			log.Println("[ListenToClient]: Stopping.")
-			sQueue.ch <- pb.ChatMessage{Sender: uName, Message: msg, Receiver: gName}
-			return
+		msg, err := con.ReadLine()
+		if err != nil {
+			msg = "/exit"
 		}
+
 		log.Println("[ListenToClient]: Adding message to send queue.")
 		sQueue.ch <- pb.ChatMessage{Sender: uName, Message: msg, Receiver: gName}
+
+		name, _ := SplitCommand(msg)
+		if cmd := FindChatCommand(name); cmd != nil && cmd.Leaves {
+			log.Println("[ListenToClient]: Stopping.")
+			return
+		}
 	}
 }
 
-// ReceiveMessages listens on the client's (NOT the client's group) stream and adds any incoming
-// message to the client's inbox.
+// DisplayCurrentMembers displays the members who are currently in the group chat.
 // It doesn't return anything.
-func ReceiveMessages(inbox *Watcher, stream pb.Chat_RouteChatClient, u string) {
+func DisplayCurrentMembers(c pb.ChatClient, g string) {
+
+	m, _ := c.GetGroupClientList(context.Background(), &pb.GroupInfo{GroupName: g})
+	if len(m.Clients) > 0 {
+		fmt.Fprint(out, "Current Members: "+strings.Join(MemberNames(m), ", "))
+		AddSpacing(2)
+	}
+}
 
-	log.Println("[ReceiveMessages]: Starting.")
-	defer inbox.WaitGroup.Done()
+// MemberNames lists the clients in l for display, marking the bots among them.
+// It returns the names.
+func MemberNames(l *pb.ClientList) []string {
 
-	for {
-		log.Println("[ReceiveMessages]: Listening for incoming messages.")
-		msg, _ := stream.Recv()
-		log.Println("[ReceiveMessages]: I see " + msg.Message)
-		if msg.Message == u+" left chat!\n" {
-			log.Println("[ReceiveMessages]: Found special signal!")
-			return
+	names := make([]string, len(l.Clients))
+	for i, c := range l.Clients {
+		names[i] = c
+		if i < len(l.Bots) && l.Bots[i] {
+			names[i] += " (bot)"
 		}
+	}
 
-		inbox.ch <- *msg
+	return names
+}
+
+// DisplaySeenBy displays which members of the group have read the latest message.
+// It doesn't return anything.
+func DisplaySeenBy(c pb.ChatClient, g string) {
+
+	s, err := c.GetSeenBy(context.Background(), &pb.GroupInfo{GroupName: g})
+	if err != nil || s.Id == 0 {
+		fmt.Fprintln(out, "There aren't any messages in "+g+" yet.")
+	} else if len(s.Clients) == 0 {
+		fmt.Fprintln(out, "No one has seen the latest message yet.")
+	} else {
+		fmt.Fprintln(out, "Seen by: "+strings.Join(s.Clients, ", "))
 	}
+	AddSpacing(1)
 }
 
-// DisplayCurrentMembers displays the members who are currently in the group chat.
+// SplitCommand splits a line of input into its first word and the rest of the line.
+// It returns the command and its arguments.
+func SplitCommand(msg string) (string, string) {
+
+	msg = strings.TrimSpace(msg)
+	if i := strings.IndexAny(msg, " \t"); i >= 0 {
+		return msg[:i], strings.TrimSpace(msg[i+1:])
+	}
+
+	return msg, ""
+}
+
+// DisplayMessage displays a chat message prefixed with the id that commands use to refer to it.
 // It doesn't return anything.
-func DisplayCurrentMembers(c pb.ChatClient, g string) {
+func DisplayMessage(m pb.ChatMessage) {
 
-	m, _ := c.GetGroupClientList(context.Background(), &pb.GroupInfo{GroupName: g})
-	if len(m.Clients) > 0 {
-		fmt.Print("Current Members: ")
-		for i := 0; i < len(m.Clients); i++ {
-			if i == len(m.Clients)-1 {
-				fmt.Print(m.Clients[i])
-			} else {
-				fmt.Print(m.Clients[i] + ", ")
+	if m.Kind == pb.Kind_ACTION && !m.Deleted {
+		fmt.Fprintf(out, "[%d] * %s %s", m.Id, m.Sender, strings.TrimRight(m.Message, "\n"))
+	} else if m.File != nil && !m.Deleted {
+		fmt.Fprintf(out, "[%d] %s> shared %s (%s). Type /get %s <dest> to download it.", m.Id, m.Sender, m.File.Name, FormatSize(m.File.Size), m.File.Id)
+	} else {
+		fmt.Fprintf(out, "[%d] %s> %s", m.Id, m.Sender, strings.TrimRight(m.Message, "\n"))
+	}
+	if m.Deleted {
+		color.New(theme.Dim).Print("(deleted)")
+	} else if m.Edited {
+		color.New(theme.Dim).Print(" (edited)")
+	}
+	if m.Replies == 1 {
+		color.New(theme.Dim).Print(" (1 reply)")
+	} else if m.Replies > 1 {
+		color.New(theme.Dim).Printf(" (%d replies)", m.Replies)
+	}
+	AddSpacing(1)
+
+	if len(m.Reactions) > 0 {
+		color.New(theme.Dim).Println("    " + ReactionSummary(m.Reactions))
+	}
+}
+
+// ReactionSummary formats a message's reactions compactly, e.g. "👍 2  🎉 1".
+// It returns the formatted reactions.
+func ReactionSummary(rs []*pb.Reaction) string {
+
+	var parts []string
+	for _, r := range rs {
+		parts = append(parts, r.Emoji+" "+strconv.Itoa(len(r.Clients)))
+	}
+
+	return strings.Join(parts, "  ")
+}
+
+// DisplayEvent displays an incoming message or an event about an earlier message. Messages are
+// recorded in history so later events can re-render them. The user's own messages are echoed
+// back by the server only to tell us their id, and replies outside the open thread are only
+// announced so they don't clutter the main chat. Mentions of the user ring the terminal bell;
+// the server sends them just ahead of the message itself so it can be highlighted. So do
+// announcements from the server's operators.
+// It doesn't return anything.
+func DisplayEvent(m pb.ChatMessage, u string, g string, thread uint64, history map[uint64]*pb.ChatMessage) {
+
+	switch m.Kind {
+	case pb.Kind_EDIT:
+		if h, ok := history[m.Id]; ok {
+			h.Message = m.Message
+			h.Edited = true
+			DisplayMessage(*h)
+		} else {
+			color.New(theme.Dim).Printf("Message %d was edited by %s: %s", m.Id, m.Sender, m.Message)
+		}
+	case pb.Kind_DELETE:
+		if h, ok := history[m.Id]; ok {
+			h.Message = ""
+			h.Deleted = true
+		}
+		color.New(theme.Dim).Printf("Message %d was deleted by %s.\n", m.Id, m.Sender)
+	case pb.Kind_REACT, pb.Kind_UNREACT:
+		if h, ok := history[m.Id]; ok {
+			h.Reactions = m.Reactions
+			DisplayMessage(*h)
+		} else if len(m.Reactions) > 0 {
+			color.New(theme.Dim).Printf("Message %d: %s\n", m.Id, ReactionSummary(m.Reactions))
+		} else {
+			color.New(theme.Dim).Printf("Message %d no longer has any reactions.\n", m.Id)
+		}
+	case pb.Kind_DIRECT:
+		if m.Sender == u {
+			color.New(theme.Dim).Printf("(private to %s) %s\n", m.Receiver, strings.TrimRight(m.Message, "\n"))
+		} else {
+			fmt.Fprint(out, "\a")
+			color.New(theme.Highlight).Printf("(private) %s> %s\n", m.Sender, strings.TrimRight(m.Message, "\n"))
+		}
+	case pb.Kind_COMMAND_RESULT:
+		fmt.Fprintln(out, strings.TrimRight(m.Message, "\n"))
+		AddSpacing(1)
+	case pb.Kind_RATE_LIMITED, pb.Kind_REJECTED:
+		color.New(theme.Error).Println(strings.TrimRight(m.Message, "\n"))
+	case pb.Kind_ANNOUNCEMENT:
+		fmt.Fprint(out, "\a")
+		color.New(theme.Highlight, color.Bold).Printf("(announcement) %s\n", strings.TrimRight(m.Message, "\n"))
+	case pb.Kind_TOPIC:
+		color.New(theme.Dim).Printf("%s set the topic to: %s\n", m.Sender, strings.TrimRight(m.Message, "\n"))
+	case pb.Kind_NICK:
+		color.New(theme.Dim).Printf("%s is now known as %s.\n", m.Sender, m.Message)
+	case pb.Kind_MENTION:
+		fmt.Fprint(out, "\a")
+		if m.Receiver == g {
+			history[m.Id] = &m
+		} else {
+			color.New(theme.Highlight, color.Bold).Printf("%s mentioned you in %s: %s\n", m.Sender, m.Receiver, strings.TrimRight(m.Message, "\n"))
+		}
+	default:
+		h, mentioned := history[m.Id]
+		mentioned = mentioned && h.Kind == pb.Kind_MENTION
+
+		history[m.Id] = &m
+		if p, ok := history[m.ParentId]; ok && m.ParentId != 0 {
+			p.Replies++
+		}
+
+		if m.Sender == u && m.Kind != pb.Kind_ACTION {
+			if m.Message != "joined chat!\n" {
+				color.New(theme.Dim).Printf("(#%d)\n", m.Id)
 			}
+		} else if m.ParentId != 0 && m.ParentId != thread {
+			color.New(theme.Dim).Printf("%s replied to message %d. Type /thread %d to open the thread.\n", m.Sender, m.ParentId, m.ParentId)
+		} else if mentioned {
+			color.New(theme.Highlight, color.Bold).Set()
+			DisplayMessage(m)
+			color.Unset()
+		} else if m.Message != "!leave" {
+			DisplayMessage(m)
 		}
-		AddSpacing(2)
 	}
 }
 
+// ParseMessageId parses the id at the start of a command's arguments.
+// It returns the id, the remaining arguments and an error.
+func ParseMessageId(args string) (uint64, string, error) {
+
+	idStr, rest := SplitCommand(args)
+	id, err := strconv.ParseUint(strings.TrimPrefix(idStr, "#"), 10, 64)
+
+	return id, rest, err
+}
+
+// EditMessage handles the /edit command by asking the server to replace the text of a message.
+// It doesn't return anything.
+func EditMessage(c pb.ChatClient, u string, g string, args string, history map[uint64]*pb.ChatMessage) {
+
+	id, text, err := ParseMessageId(args)
+	if err != nil || text == "" {
+		color.New(theme.Error).Println("Usage: /edit <id> <message>")
+		return
+	}
+
+	_, err = c.EditMessage(context.Background(), &pb.MessageEdit{Client: u, GroupName: g, Id: id, Message: text + "\n"})
+	if status.Code(err) == codes.PermissionDenied {
+		color.New(theme.Error).Println("You can only edit your own messages unless you moderate the group.")
+		return
+	} else if err != nil {
+		color.New(theme.Error).Println(chatclient.ErrorMessage(err))
+		return
+	}
+
+	if h, ok := history[id]; ok {
+		h.Message = text + "\n"
+		h.Edited = true
+	}
+}
+
+// DeleteMessage handles the /delete command by asking the server to delete a message.
+// It doesn't return anything.
+func DeleteMessage(c pb.ChatClient, u string, g string, args string, history map[uint64]*pb.ChatMessage) {
+
+	id, _, err := ParseMessageId(args)
+	if err != nil {
+		color.New(theme.Error).Println("Usage: /delete <id>")
+		return
+	}
+
+	_, err = c.DeleteMessage(context.Background(), &pb.MessageEdit{Client: u, GroupName: g, Id: id})
+	if status.Code(err) == codes.PermissionDenied {
+		color.New(theme.Error).Println("You can only delete your own messages unless you moderate the group.")
+		return
+	} else if err != nil {
+		color.New(theme.Error).Println(chatclient.ErrorMessage(err))
+		return
+	}
+
+	if h, ok := history[id]; ok {
+		h.Message = ""
+		h.Deleted = true
+	}
+	color.New(theme.Dim).Printf("Message %d was deleted.\n", id)
+}
+
+// ReactToMessage handles the /react command by toggling the user's reaction on a message.
+// It doesn't return anything.
+func ReactToMessage(c pb.ChatClient, u string, g string, args string, history map[uint64]*pb.ChatMessage) {
+
+	id, e, err := ParseMessageId(args)
+	if err != nil || e == "" {
+		color.New(theme.Error).Println("Usage: /react <id> <emoji>")
+		return
+	}
+
+	ev, err := c.React(context.Background(), &pb.ReactionInfo{Client: u, GroupName: g, Id: id, Emoji: e})
+	if status.Code(err) == codes.NotFound {
+		color.New(theme.Error).Println("Couldn't react to message " + strconv.FormatUint(id, 10) + ". Please check that it exists.")
+		return
+	} else if err != nil {
+		color.New(theme.Error).Println(chatclient.ErrorMessage(err))
+		return
+	}
+
+	DisplayEvent(*ev, u, g, 0, history)
+}
+
+// DisplayMentions handles the /mentions command by listing the latest messages that mentioned
+// the user in any group.
+// It doesn't return anything.
+func DisplayMentions(c pb.ChatClient, u string) {
+
+	l, err := c.GetMentions(context.Background(), &pb.ClientInfo{Sender: u})
+	if err != nil || len(l.Messages) == 0 {
+		fmt.Fprintln(out, "No one has mentioned you yet.")
+		AddSpacing(1)
+		return
+	}
+
+	fmt.Fprintln(out, "Your latest mentions:")
+	for _, m := range l.Messages {
+		fmt.Fprintf(out, "  %s [%d] %s> %s\n", m.Receiver, m.Id, m.Sender, strings.TrimRight(m.Message, "\n"))
+	}
+	AddSpacing(1)
+}
+
+// OpenThread handles the /thread command. Given a message id it displays the thread the message
+// belongs to so that new messages can be sent as replies in it. Without an id it goes back to
+// the main chat.
+// It returns the id of the message that started the open thread, or 0 for the main chat.
+func OpenThread(s *chatclient.Session, args string, thread uint64, history map[uint64]*pb.ChatMessage) uint64 {
+
+	if args == "" {
+		color.New(theme.Dim).Println("You are back in the main chat.")
+		return 0
+	}
+
+	id, _, err := ParseMessageId(args)
+	if err != nil {
+		color.New(theme.Error).Println("Usage: /thread <id> or /thread to go back to the main chat")
+		return thread
+	}
+
+	t, err := s.Client().GetThread(context.Background(), &pb.MessageRef{Client: s.User(), GroupName: s.Group(), Id: id})
+	if err == nil && len(t.Messages) == 0 || status.Code(err) == codes.NotFound {
+		color.New(theme.Error).Println("Message " + strconv.FormatUint(id, 10) + " doesn't exist.")
+		return thread
+	} else if err != nil {
+		color.New(theme.Error).Println(chatclient.ErrorMessage(err))
+		return thread
+	}
+
+	root := t.Messages[0]
+
+	AddSpacing(1)
+	fmt.Fprintln(out, "Thread started by "+root.Sender)
+	Frame()
+	for _, m := range t.Messages {
+		d := s.Decrypt(*m)
+		history[d.Id] = &d
+		DisplayMessage(d)
+	}
+	Frame()
+	color.New(theme.Dim).Println("Your messages are now replies in this thread. Type /thread to go back to the main chat.")
+
+	return root.Id
+}
+
 func main() {
 
+	c, err := LoadConfig(ConfigPath(os.Args[1:]))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "go-chat: "+err.Error())
+		os.Exit(exitUsage)
+	}
+
+	// Scripting commands don't use the prompts and only print their results.
+	if len(os.Args) > 1 {
+		if run, ok := commands[os.Args[1]]; ok {
+			log.SetOutput(io.Discard)
+			os.Exit(run(c, os.Args[2:]))
+		}
+	}
+
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: go-chat [flags]")
+		fmt.Fprintln(flag.CommandLine.Output(), "       go-chat send|tail|groups|members [flags] (run one with -h for its flags)")
+		flag.PrintDefaults()
+	}
+	c.Flags(flag.CommandLine)
+	flag.Parse()
+
+	if c.Theme != "" {
+		if err := SetTheme(c.Theme); err != nil {
+			fmt.Fprintln(os.Stderr, "go-chat: "+err.Error())
+			os.Exit(exitUsage)
+		}
+	}
+
+	if c.Log != "" {
+		f, err := os.OpenFile(c.Log, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "go-chat: "+err.Error())
+			os.Exit(exitError)
+		}
+		defer f.Close()
+		log.SetOutput(f)
+	} else if c.TUI {
+		// Log lines would be drawn over the interface.
+		log.SetOutput(io.Discard)
+	}
+
 	r := bufio.NewReader(os.Stdin)
 
-	var uName string // Client username
-	var gName string // Client's chat group
+	if c.Server == "" || c.User == "" {
+		StartMessage()
+	}
 
-	a := SetServer(r)
+	a, err := ServerAddress(c.Server)
+	if err != nil && c.Server != "" {
+		color.New(theme.Error).Println("The server " + c.Server + " in your settings isn't valid: " + err.Error())
+	}
+	if err != nil {
+		a = SetServer(r)
+	}
 
 	// Set up a connection to the server.
-	conn, err := grpc.Dial(a, grpc.WithInsecure())
+	s, err := chatclient.Connect(a)
 
 	if err != nil {
 		log.Fatalf("Could not connect: %v", err)
 	} else {
-		fmt.Printf("\nYou have successfully connected to %s! To disconnect, hit ctrl+c or type !exit.\n\n", a)
+		fmt.Fprintf(out, "\nYou have successfully connected to %s! To disconnect, hit ctrl+c or type /exit.\n\n", a)
 	}
 
 	// Close the connection after main returns.
-	defer conn.Close()
+	defer s.Close()
 
-	// Create the client
-	c := pb.NewChatClient(conn)
-	ctx := context.Background()
-	stream, serr := c.RouteChat(ctx)
-	if serr != nil {
-		log.Fatal(serr)
+	SetName(s, r, c.User)
+	joined := AutoJoin(s, c.Join)
+
+	if c.TUI {
+		if err := RunTUI(s); err != nil {
+			fmt.Fprintln(out, err)
+			os.Exit(1)
+		}
+		return
 	}
 
-	uName = SetName(c, r)
 	showMenu := true // Control whether the user sees the menu or exits.
 	m := CreateMonitor()
-	go m.ControlExit(c, uName, gName)
+	go m.ControlExit(s)
 
 	for showMenu {
-		gName, err = TopMenu(c, r, uName)
+		if !joined {
+			if err := TopMenu(s, r); err != nil {
+				fmt.Fprint(out, err)
+				os.Exit(1)
+			}
+		}
+		joined = false
 
-		if err != nil {
-			fmt.Print(err)
-			os.Exit(1)
+		showMenu = Chat(s, m, r)
+	}
+}
+
+// AutoJoin joins the first of the groups gs that exists.
+// It returns true if a group was joined.
+func AutoJoin(s *chatclient.Session, gs []string) bool {
+
+	for _, g := range gs {
+		if err := s.Join(g); err == nil {
+			return true
 		}
+		log.Println("[AutoJoin]: Couldn't join " + g + ".")
+	}
 
-		log.Print("Showing context")
-		log.Print(ctx)
-		showMenu = Chat(conn, stream, c, m, r, uName, gName)
+	if len(gs) > 0 {
+		color.New(theme.Warning).Println("None of the groups in your settings (" + strings.Join(gs, ", ") + ") exist yet.")
 	}
+
+	return false
 }
 
-func Chat(conn *grpc.ClientConn, stream pb.Chat_RouteChatClient, c pb.ChatClient, m *Monitor, r *bufio.Reader, u string, g string) bool {
+// Chat runs the chat loop for the group the session has joined. It sends whatever the user
+// types to the group, runs their commands and displays the messages and events that arrive.
+// It returns true if the user left the group and false if they exited the client.
+func Chat(s *chatclient.Session, m *Monitor, r *bufio.Reader) bool {
 
-	//t, cancel := context.WithCancel(ctx)
-	//stream, serr := c.RouteChat(t)
-	//defer cancel()
+	c := s.Client()
+	g := s.Group()
 
 	DisplayCurrentMembers(c, g)
 
-	//if serr != nil {
-	//	fmt.Print(serr)
-	//} else {
 	sQueue := CreateWatcher() // Creates the sQueue with a channel and waitgroup.
-	inbox := CreateWatcher()  // Similar to sQueue
+	st := &ChatState{s: s, history: make(map[uint64]*pb.ChatMessage)}
 
-	go ListenToClient(sQueue, r, u, g)
-	go ReceiveMessages(inbox, stream, u)
+	con := OpenConsole(r, func(line string) (string, []string) {
+		return CompleteChatLine(s, line)
+	})
+	defer con.Close()
 
-	// TODO: Find out why the first message is always dropped so an empty message needn't be sent.
-	stream.Send(&pb.ChatMessage{Sender: u, Receiver: g, Message: ""})
-	stream.Send(&pb.ChatMessage{Sender: u, Receiver: g, Message: "joined chat!\n"})
+	go ListenToClient(sQueue, con, s.User(), g)
 	m.chatting = true
-	m.stream = stream
 
 	AddSpacing(1)
-	fmt.Println("You are now chatting in " + g + ".")
+	fmt.Fprintln(out, "You are now chatting in "+g+". Type /help for the commands.")
+	if s.Encrypted() {
+		color.New(theme.Success).Println("Messages in " + g + " are end-to-end encrypted.")
+	}
 	Frame()
 
 	for {
 		select {
 		case toSend := <-sQueue.ch:
-			switch msg := strings.TrimSpace(toSend.Message); msg {
-			case "!members":
-				log.Println("[Main]: I'm in !members.")
-				DisplayCurrentMembers(c, g)
-			case "!leave":
-				log.Println("[Main]: I'm in !leave.")
-				c.LeaveRoom(context.Background(), &pb.GroupInfo{Client: u, GroupName: g})
+			name, args := SplitCommand(toSend.Message)
+			if cmd := FindChatCommand(name); cmd != nil {
+				log.Println("[Chat]: Running " + cmd.Name + ".")
+				cmd.Execute(st, args)
+			} else if strings.HasPrefix(name, "/") {
+				log.Println("[Chat]: Sending " + name + " to the server.")
+				if err := s.Command(toSend.Message); err != nil {
+					color.New(theme.Error).Println("Couldn't run " + name + ". " + chatclient.ErrorMessage(err))
+				}
+			} else {
+				log.Println("[Chat]: Sending the message.")
+				if err := s.Reply(st.thread, toSend.Message); err != nil {
+					color.New(theme.Error).Println("Your message wasn't sent. " + chatclient.ErrorMessage(err))
+				}
+			}
+
+			if st.left {
 				sQueue.Stop()
-				inbox.Stop()
-				//stream.CloseSend()
-				log.Println("HEY LOOK")
-				//cancel()
-				log.Println(context.Canceled)
+				m.chatting = false
 				return true
-			case "!exit":
-				log.Println("[Main]: I'm in !exit.")
-				stream.Send(&pb.ChatMessage{Sender: u, Receiver: g, Message: u + " left chat!\n"})
-				ExitClient(c, u, g)
-				//stream.CloseSend()
-				//cancel()
-				conn.Close()
-				return false
-			case "!help":
-				log.Println("[Main]: I'm in !help.")
-				AddSpacing(1)
-				fmt.Println("The following commands are available to you: ")
-				color.New(color.FgHiYellow).Print("   !members")
-				fmt.Print(": Lists the current members in the group.")
-
-				AddSpacing(1)
-				color.New(color.FgHiYellow).Print("   !exit")
-				fmt.Println(": Leaves the chat server.")
-				AddSpacing(1)
-
-			default:
-				log.Println("[Main]: Sending the message.")
-				stream.Send(&toSend)
 			}
-		case received := <-inbox.ch:
-			log.Println("[Main]: Receiving the message.")
-			if received.Message != "!leave" {
-				fmt.Printf("%s> %s", received.Sender, received.Message)
+		case received, ok := <-s.Events:
+			if !ok {
+				color.New(theme.Error).Println("Lost the connection to the server.")
+				ExitClient(s)
+			}
+			log.Println("[Chat]: Receiving the message.")
+			u := s.User()
+			DisplayEvent(received, u, g, st.thread, st.history)
+			if received.Id > 0 && received.Receiver == g {
+				c.MarkRead(context.Background(), &pb.ReadMarker{Client: u, GroupName: g, Id: received.Id})
 			}
 		}
 	}
-	//}
-
-	//return false
 }
@@ -0,0 +1,393 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	pb "github.com/taylorflatt/go-chat"
+	"github.com/taylorflatt/go-chat/chatclient"
+	"golang.org/x/net/context"
+)
+
+// Exit codes of the scripting commands.
+const (
+	exitOK    = 0 // The command succeeded.
+	exitError = 1 // The command failed, e.g. the server couldn't be reached.
+	exitUsage = 2 // The command line was invalid.
+)
+
+// The server the client talks to unless told otherwise.
+const (
+	defaultPort   = "12021"
+	defaultServer = "localhost:" + defaultPort
+)
+
+// commands holds the scripting commands by name. Each one is given the settings from the
+// config file and the arguments after its name, and returns the exit code.
+var commands = map[string]func(c *Config, args []string) int{
+	"send":    SendCommand,
+	"tail":    TailCommand,
+	"groups":  GroupsCommand,
+	"members": MembersCommand,
+}
+
+// TailEvent is the JSON form of a message or event written by tail --json.
+type TailEvent struct {
+	Id       uint64 `json:"id,omitempty"`
+	Group    string `json:"group"`
+	Sender   string `json:"sender"`
+	Kind     string `json:"kind"`
+	Message  string `json:"message,omitempty"`
+	ParentId uint64 `json:"parent_id,omitempty"`
+	File     string `json:"file,omitempty"`
+	Time     string `json:"time"`
+}
+
+// Fail prints an error for the command cmd to stderr.
+// It returns the exit code code.
+func Fail(cmd string, code int, err error) int {
+
+	fmt.Fprintln(os.Stderr, "go-chat "+cmd+": "+err.Error())
+	return code
+}
+
+// NewFlagSet creates the flags for a scripting command along with the --server and --config
+// flags every command has.
+// It returns the flag set and the server flag.
+func NewFlagSet(cmd string, usage string, c *Config) (*flag.FlagSet, *string) {
+
+	fs := flag.NewFlagSet(cmd, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: go-chat "+cmd+" "+usage)
+		fs.PrintDefaults()
+	}
+
+	server := defaultServer
+	if c.Server != "" {
+		server = c.Server
+	}
+
+	fs.String("config", DefaultConfigPath(), "The config `file` to read.")
+	return fs, fs.String("server", server, "The `host:port` of the chat server.")
+}
+
+// Connect connects to server, adding the default port if it doesn't have one.
+// It returns the session and an error.
+func Connect(server string) (*chatclient.Session, error) {
+
+	a, err := ServerAddress(server)
+	if err != nil {
+		return nil, err
+	}
+
+	return chatclient.Connect(a)
+}
+
+// JoinAs connects to server as user u and joins group g, creating it first if create is set.
+// It returns the session and an error.
+func JoinAs(server string, u string, g string, create bool) (*chatclient.Session, error) {
+
+	s, err := Connect(server)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Login(u); err != nil {
+		s.Close()
+		return nil, errors.New("couldn't log in as " + u + ": " + err.Error())
+	}
+
+	if create {
+		s.Create(g, false)
+	}
+
+	if err := s.Join(g); err != nil {
+		s.Close()
+		return nil, errors.New("couldn't join " + g + ": " + err.Error())
+	}
+
+	return s, nil
+}
+
+// SendCommand runs `go-chat send`, which posts a message to a group and waits until the
+// server has stored it. Without a message on the command line it is read from stdin.
+// It returns the exit code.
+func SendCommand(c *Config, args []string) int {
+
+	fs, server := NewFlagSet("send", "--user <name> --group <group> [message]", c)
+	u := fs.String("user", c.User, "The `name` to send as. It must not be in use.")
+	g := fs.String("group", "", "The `group` to send to.")
+	create := fs.Bool("create", false, "Create the group if it doesn't exist. Groups are removed when their last member leaves.")
+	timeout := fs.Duration("timeout", 10*time.Second, "How long to wait for the server to accept the message.")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if *u == "" || *g == "" {
+		fs.Usage()
+		return exitUsage
+	}
+
+	text := strings.Join(fs.Args(), " ")
+	if text == "" {
+		b, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return Fail("send", exitError, err)
+		}
+		text = string(b)
+	}
+
+	text = strings.TrimRight(text, "\n")
+	if strings.TrimSpace(text) == "" {
+		return Fail("send", exitUsage, errors.New("there is no message to send"))
+	}
+
+	s, err := JoinAs(*server, *u, *g, *create)
+	if err != nil {
+		return Fail("send", exitError, err)
+	}
+	defer s.Close()
+	defer s.Leave()
+
+	if err := Deliver(s, text, *timeout); err != nil {
+		return Fail("send", exitError, err)
+	}
+
+	return exitOK
+}
+
+// Deliver sends text to the session's group and waits for the server to echo it back, which
+// it does once the message has been stored. In encrypted groups it first waits for the group
+// key to arrive.
+// It returns an error.
+func Deliver(s *chatclient.Session, text string, timeout time.Duration) error {
+
+	deadline := time.After(timeout)
+
+	for err := s.Send(text); err != nil; err = s.Send(text) {
+		if !s.Encrypted() {
+			return err
+		}
+
+		select {
+		case <-deadline:
+			return errors.New("timed out waiting for the group key: " + err.Error())
+		case _, ok := <-s.Events:
+			if !ok {
+				return errors.New("lost the connection to the server")
+			}
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	for {
+		select {
+		case <-deadline:
+			return errors.New("timed out waiting for the server to accept the message")
+		case m, ok := <-s.Events:
+			if !ok {
+				return errors.New("lost the connection to the server")
+			}
+			if m.Sender == s.User() && m.Kind == pb.Kind_MESSAGE && m.Message == text+"\n" {
+				return nil
+			}
+		}
+	}
+}
+
+// TailCommand runs `go-chat tail`, which joins a group and writes everything that happens in
+// it to stdout until it is interrupted.
+// It returns the exit code.
+func TailCommand(c *Config, args []string) int {
+
+	fs, server := NewFlagSet("tail", "--group <group> [--json]", c)
+	u := fs.String("user", "tail-"+strconv.Itoa(os.Getpid()), "The `name` to join as. It must not be in use.")
+	g := fs.String("group", "", "The `group` to follow.")
+	create := fs.Bool("create", false, "Create the group if it doesn't exist.")
+	asJSON := fs.Bool("json", false, "Write one JSON object per line instead of text.")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if *g == "" || fs.NArg() > 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	s, err := JoinAs(*server, *u, *g, *create)
+	if err != nil {
+		return Fail("tail", exitError, err)
+	}
+	defer s.Close()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+
+	enc := json.NewEncoder(os.Stdout)
+	for {
+		select {
+		case <-stop:
+			s.Leave()
+			return exitOK
+		case m, ok := <-s.Events:
+			if !ok {
+				return Fail("tail", exitError, errors.New("lost the connection to the server"))
+			}
+			if m.Receiver != *g || m.Kind == pb.Kind_MENTION || (m.Sender == *u && m.Message == "joined chat!\n") {
+				continue
+			}
+
+			if *asJSON {
+				enc.Encode(NewTailEvent(m))
+			} else {
+				fmt.Println(FormatTailEvent(m))
+			}
+		}
+	}
+}
+
+// NewTailEvent converts a message into the JSON form written by tail.
+// It returns the event.
+func NewTailEvent(m pb.ChatMessage) TailEvent {
+
+	e := TailEvent{
+		Id:       m.Id,
+		Group:    m.Receiver,
+		Sender:   m.Sender,
+		Kind:     strings.ToLower(m.Kind.String()),
+		Message:  strings.TrimRight(m.Message, "\n"),
+		ParentId: m.ParentId,
+		Time:     time.Now().UTC().Format(time.RFC3339),
+	}
+	if m.File != nil {
+		e.File = m.File.Id
+	}
+	if m.Kind == pb.Kind_REACT || m.Kind == pb.Kind_UNREACT {
+		e.Message = ReactionSummary(m.Reactions)
+	}
+
+	return e
+}
+
+// FormatTailEvent formats a message or event as a line of text for tail.
+// It returns the formatted line.
+func FormatTailEvent(m pb.ChatMessage) string {
+
+	text := strings.TrimRight(m.Message, "\n")
+
+	switch m.Kind {
+	case pb.Kind_EDIT:
+		return fmt.Sprintf("[%d] %s edited: %s", m.Id, m.Sender, text)
+	case pb.Kind_DELETE:
+		return fmt.Sprintf("[%d] %s deleted the message", m.Id, m.Sender)
+	case pb.Kind_REACT, pb.Kind_UNREACT:
+		return fmt.Sprintf("[%d] reactions: %s", m.Id, ReactionSummary(m.Reactions))
+	case pb.Kind_ACTION:
+		return fmt.Sprintf("[%d] * %s %s", m.Id, m.Sender, text)
+	case pb.Kind_TOPIC:
+		return fmt.Sprintf("%s set the topic to: %s", m.Sender, text)
+	case pb.Kind_NICK:
+		return fmt.Sprintf("%s is now known as %s", m.Sender, text)
+	case pb.Kind_ATTACHMENT:
+		if m.File != nil {
+			return fmt.Sprintf("[%d] %s> shared %s (%s), id %s", m.Id, m.Sender, m.File.Name, FormatSize(m.File.Size), m.File.Id)
+		}
+	}
+
+	if m.ParentId != 0 {
+		return fmt.Sprintf("[%d] %s> (reply to %d) %s", m.Id, m.Sender, m.ParentId, text)
+	}
+
+	return fmt.Sprintf("[%d] %s> %s", m.Id, m.Sender, text)
+}
+
+// GroupsCommand runs `go-chat groups`, which lists the groups on the server.
+// It returns the exit code.
+func GroupsCommand(c *Config, args []string) int {
+
+	fs, server := NewFlagSet("groups", "[--json]", c)
+	asJSON := fs.Bool("json", false, "Write one JSON object per line instead of text.")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	s, err := Connect(*server)
+	if err != nil {
+		return Fail("groups", exitError, err)
+	}
+	defer s.Close()
+
+	l, err := s.Client().GetGroupList(context.Background(), &pb.ClientInfo{})
+	if err != nil {
+		return Fail("groups", exitError, err)
+	}
+
+	encrypted := make(map[string]bool)
+	for i, g := range l.Groups {
+		encrypted[g] = i < len(l.Encrypted) && l.Encrypted[i]
+	}
+	sort.Strings(l.Groups)
+
+	enc := json.NewEncoder(os.Stdout)
+	for _, g := range l.Groups {
+		if *asJSON {
+			enc.Encode(struct {
+				Name      string `json:"name"`
+				Encrypted bool   `json:"encrypted"`
+			}{g, encrypted[g]})
+		} else {
+			fmt.Println(g)
+		}
+	}
+
+	return exitOK
+}
+
+// MembersCommand runs `go-chat members <group>`, which lists the members of a group.
+// It returns the exit code.
+func MembersCommand(c *Config, args []string) int {
+
+	fs, server := NewFlagSet("members", "<group>", c)
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	s, err := Connect(*server)
+	if err != nil {
+		return Fail("members", exitError, err)
+	}
+	defer s.Close()
+
+	g := fs.Arg(0)
+	l, err := s.Client().GetGroupClientList(context.Background(), &pb.GroupInfo{GroupName: g})
+	if err != nil {
+		return Fail("members", exitError, errors.New("couldn't list the members of "+g+": "+err.Error()))
+	}
+
+	sort.Strings(l.Clients)
+	for _, c := range l.Clients {
+		fmt.Println(c)
+	}
+
+	return exitOK
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Config holds the client's settings. They are read from the config file and can be
+// overridden with flags. Anything still missing is asked for at the prompts.
+type Config struct {
+	Server string   // The host:port of the chat server.
+	User   string   // The username to log in with.
+	Join   []string // Groups to join at start. The first one that exists is joined.
+	Theme  string   // The color theme.
+	Log    string   // The file to write logs to instead of stderr.
+	TUI    bool     // Whether to use the full-screen interface.
+}
+
+// DefaultConfigPath finds the config file, ~/.config/go-chat/config unless XDG_CONFIG_HOME
+// says otherwise.
+// It returns the path of the config file.
+func DefaultConfigPath() string {
+
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+
+	return filepath.Join(dir, "go-chat", "config")
+}
+
+// ConfigPath looks through the command line for a -config flag, which has to be known before
+// the other flags are set up since their defaults come from the config file.
+// It returns the path of the config file.
+func ConfigPath(args []string) string {
+
+	for i, a := range args {
+		a = strings.TrimLeft(a, "-")
+		if a == "config" && i+1 < len(args) {
+			return args[i+1]
+		} else if strings.HasPrefix(a, "config=") {
+			return strings.TrimPrefix(a, "config=")
+		}
+	}
+
+	return DefaultConfigPath()
+}
+
+// LoadConfig reads the config file at path. It has one "key = value" setting per line, and
+// blank lines and lines starting with # are ignored. A missing file gives an empty config.
+// It returns the config and an error.
+func LoadConfig(path string) (*Config, error) {
+
+	c := &Config{}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) || path == "" {
+		return c, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			return nil, errors.New(path + ":" + strconv.Itoa(n) + ": expected key = value")
+		}
+
+		if err := c.Set(strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])); err != nil {
+			return nil, errors.New(path + ":" + strconv.Itoa(n) + ": " + err.Error())
+		}
+	}
+
+	return c, s.Err()
+}
+
+// Set sets the setting key to value.
+// It returns an error.
+func (c *Config) Set(key string, value string) error {
+
+	switch key {
+	case "server":
+		c.Server = value
+	case "user":
+		c.User = value
+	case "join":
+		c.Join = SplitList(value)
+	case "theme":
+		c.Theme = value
+	case "log":
+		c.Log = value
+	case "tui":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.New("tui must be true or false")
+		}
+		c.TUI = b
+	default:
+		return errors.New("unknown setting \"" + key + "\"")
+	}
+
+	return nil
+}
+
+// SplitList splits a comma separated list, dropping empty entries.
+// It returns the entries.
+func SplitList(s string) []string {
+
+	var l []string
+	for _, e := range strings.Split(s, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			l = append(l, e)
+		}
+	}
+
+	return l
+}
+
+// joinFlag lets -join be given a comma separated list of groups.
+type joinFlag struct{ c *Config }
+
+func (j joinFlag) String() string {
+
+	if j.c == nil {
+		return ""
+	}
+	return strings.Join(j.c.Join, ",")
+}
+
+func (j joinFlag) Set(s string) error {
+
+	j.c.Join = SplitList(s)
+	return nil
+}
+
+// Flags defines the flags of the interactive client on fs, with the config as defaults.
+// It doesn't return anything.
+func (c *Config) Flags(fs *flag.FlagSet) {
+
+	fs.String("config", DefaultConfigPath(), "The config `file` to read.")
+	fs.StringVar(&c.Server, "server", c.Server, "The `host:port` of the chat server.")
+	fs.StringVar(&c.User, "user", c.User, "The `name` to log in with.")
+	fs.Var(joinFlag{c}, "join", "Comma separated `groups` to join at start. The first one that exists is joined.")
+	fs.StringVar(&c.Theme, "theme", c.Theme, "The color `theme`: "+strings.Join(ThemeNames(), ", ")+".")
+	fs.StringVar(&c.Log, "log", c.Log, "Write logs to `file` instead of stderr.")
+	fs.BoolVar(&c.TUI, "tui", c.TUI, "Use the full-screen interface instead of the menus.")
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+	"golang.org/x/term"
+)
+
+// out is where the client writes what it displays. While the user is chatting on a terminal
+// it is the console, so that incoming messages are drawn above the line being typed.
+var out io.Writer = os.Stdout
+
+// console is the console open while the user is chatting, if any.
+var console *Console
+
+// Console reads what the user types while chatting. On a terminal it edits the line itself,
+// completing commands with tab; otherwise lines are read as they come.
+type Console struct {
+	r     *bufio.Reader
+	t     *term.Terminal
+	state *term.State // The terminal's settings before it was put in raw mode.
+	color io.Writer   // Where colored output went before the console was opened.
+	log   io.Writer   // Where logs went before the console was opened.
+
+	mu      sync.Mutex // Guards pending.
+	pending []byte     // Output that doesn't end a line yet.
+}
+
+// OpenConsole opens the console for a chat, reading from r unless stdin is a terminal.
+// complete is called with the line typed so far when tab is pressed, and gives back the
+// completed line and the candidates to show if there is more than one.
+// It returns the console.
+func OpenConsole(r *bufio.Reader, complete func(line string) (string, []string)) *Console {
+
+	c := &Console{r: r}
+	console = c
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) || r.Buffered() > 0 {
+		return c
+	}
+
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		log.Println("[OpenConsole]: Couldn't put the terminal in raw mode: " + err.Error())
+		return c
+	}
+
+	c.state = state
+	c.t = term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}, color.New(theme.Prompt).Sprint("> "))
+	c.t.AutoCompleteCallback = func(line string, pos int, key rune) (string, int, bool) {
+
+		if key != '\t' {
+			return "", 0, false
+		}
+
+		completed, candidates := complete(line[:pos])
+		if len(candidates) > 0 {
+			c.Write([]byte(strings.Join(candidates, "  ") + "\n"))
+		}
+
+		return completed + line[pos:], len(completed), true
+	}
+
+	if w, h, err := term.GetSize(fd); err == nil && w > 0 {
+		c.t.SetSize(w, h)
+	}
+
+	c.color = color.Output
+	color.Output = c
+	out = c
+	if log.Writer() == os.Stderr {
+		c.log = os.Stderr
+		log.SetOutput(c)
+	}
+
+	return c
+}
+
+// ReadLine reads the next line the user enters.
+// It returns the line, without its newline, and an error, which is io.EOF once there is no
+// more input or the user hit ctrl+c or ctrl+d.
+func (c *Console) ReadLine() (string, error) {
+
+	if c.t != nil {
+		return c.t.ReadLine()
+	}
+
+	line, err := c.r.ReadString('\n')
+	if err != nil && line != "" {
+		err = nil
+	}
+
+	return strings.TrimRight(line, "\r\n"), err
+}
+
+// Write displays p above the line being typed. Output is held back until it ends a line so
+// that the line being typed is only redrawn once per line.
+// It returns the number of bytes written and an error.
+func (c *Console) Write(p []byte) (int, error) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.pending = append(c.pending, p...)
+	i := bytes.LastIndexByte(c.pending, '\n')
+	if i < 0 {
+		return len(p), nil
+	}
+
+	if _, err := c.t.Write(c.pending[:i+1]); err != nil {
+		return 0, err
+	}
+	c.pending = append(c.pending[:0], c.pending[i+1:]...)
+
+	return len(p), nil
+}
+
+// Close puts the terminal back the way it was and sends output to stdout again.
+// It doesn't return anything.
+func (c *Console) Close() {
+
+	if console == c {
+		console = nil
+	}
+
+	if c.t == nil {
+		return
+	}
+
+	c.mu.Lock()
+	if len(c.pending) > 0 {
+		c.t.Write(append(c.pending, '\n'))
+		c.pending = nil
+	}
+	c.mu.Unlock()
+
+	term.Restore(int(os.Stdin.Fd()), c.state)
+	out = os.Stdout
+	color.Output = c.color
+	if c.log != nil {
+		log.SetOutput(c.log)
+	}
+}
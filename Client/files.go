@@ -0,0 +1,202 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/fatih/color"
+	pb "github.com/taylorflatt/go-chat"
+	"golang.org/x/net/context"
+)
+
+// The size of the chunks files are uploaded in.
+const (
+	chunkSize = 32 << 10
+)
+
+// FormatSize formats a number of bytes for display, e.g. "12.3 KB".
+// It returns the formatted size.
+func FormatSize(n uint64) string {
+
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+
+	return fmt.Sprintf("%d B", n)
+}
+
+// ShowProgress displays how much of a transfer is done on a single, constantly updated line.
+// It doesn't return anything.
+func ShowProgress(verb string, name string, done uint64, total uint64) {
+
+	pct := uint64(100)
+	if total > 0 {
+		pct = done * 100 / total
+	}
+
+	fmt.Fprintf(out, "\r%s %s: %3d%% (%s of %s)", verb, name, pct, FormatSize(done), FormatSize(total))
+}
+
+// FileChecksum reads a whole file to work out its SHA-256 checksum.
+// It returns the hex encoded checksum and an error.
+func FileChecksum(path string) (string, error) {
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// SendFile handles the /send command by uploading a file and sharing it with the group.
+// It returns an error.
+func SendFile(c pb.ChatClient, u string, g string, path string) error {
+
+	if path == "" {
+		return fmt.Errorf("usage: /send <path>")
+	}
+
+	st, err := os.Stat(path)
+	if err != nil {
+		return err
+	} else if st.IsDir() {
+		return fmt.Errorf("%s is a directory", path)
+	}
+
+	sum, err := FileChecksum(path)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	stream, err := c.UploadFile(context.Background())
+	if err != nil {
+		return err
+	}
+
+	name := filepath.Base(path)
+	total := uint64(st.Size())
+	info := &pb.FileInfo{Name: name, Size: total, Sha256: sum, Client: u, GroupName: g}
+	if err := stream.Send(&pb.FileChunk{Info: info}); err != nil {
+		return err
+	}
+
+	var done uint64
+	buf := make([]byte, chunkSize)
+	for {
+		n, rerr := f.Read(buf)
+		if n > 0 {
+			if err := stream.Send(&pb.FileChunk{Data: buf[:n]}); err != nil {
+				break // The real error comes back from CloseAndRecv.
+			}
+			done += uint64(n)
+			ShowProgress("Sending", name, done, total)
+		}
+
+		if rerr == io.EOF {
+			break
+		} else if rerr != nil {
+			stream.CloseSend()
+			return rerr
+		}
+	}
+
+	res, err := stream.CloseAndRecv()
+	AddSpacing(1)
+	if err != nil {
+		return err
+	}
+
+	color.New(theme.Success).Printf("Shared %s as file %s.\n", res.Name, res.Id)
+	return nil
+}
+
+// GetFile handles the /get command by downloading a shared file to dest. If dest is a directory
+// the file keeps the name it was shared with. The download is thrown away if its checksum
+// doesn't match the one the server recorded.
+// It returns an error.
+func GetFile(c pb.ChatClient, u string, id string, dest string) error {
+
+	if id == "" || dest == "" {
+		return fmt.Errorf("usage: /get <id> <dest>")
+	}
+
+	stream, err := c.DownloadFile(context.Background(), &pb.FileRequest{Client: u, Id: id})
+	if err != nil {
+		return err
+	}
+
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	} else if first.Info == nil {
+		return fmt.Errorf("the server didn't describe file %s", id)
+	}
+	info := first.Info
+
+	if st, err := os.Stat(dest); err == nil && st.IsDir() {
+		dest = filepath.Join(dest, info.Name)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dest), ".go-chat-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	h := sha256.New()
+	w := io.MultiWriter(tmp, h)
+
+	var done uint64
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			AddSpacing(1)
+			return err
+		}
+
+		if _, err := w.Write(chunk.Data); err != nil {
+			AddSpacing(1)
+			return err
+		}
+		done += uint64(len(chunk.Data))
+		ShowProgress("Downloading", info.Name, done, info.Size)
+	}
+	AddSpacing(1)
+
+	if sum := hex.EncodeToString(h.Sum(nil)); sum != info.Sha256 {
+		return fmt.Errorf("the checksum of %s doesn't match, the download was discarded", info.Name)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), dest); err != nil {
+		return err
+	}
+
+	color.New(theme.Success).Printf("Saved %s to %s.\n", info.Name, dest)
+	return nil
+}
@@ -10,27 +10,27 @@ package main
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"math/rand"
+	"net"
 	"os"
 	"strconv"
 	"strings"
 
 	"github.com/fatih/color"
 	pb "github.com/taylorflatt/go-chat"
+	"github.com/taylorflatt/go-chat/chatclient"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
-// Stores the main color for all the entry dialogs.
-const (
-	promptColor = color.FgHiMagenta
-)
-
-// RandColor picks a random from a stored list of colors.
+// RandColor picks a random color from the theme's welcome colors.
 // It returns a single color attribute.
 func RandColor() color.Attribute {
 
-	c := []color.Attribute{color.FgHiCyan, color.FgHiGreen, color.FgHiRed, color.FgHiWhite, color.FgHiYellow, color.FgHiMagenta}
+	c := theme.Welcome
 	return c[(len(c)*rand.Intn(20)+rand.Intn(10)+2)%len(c)]
 }
 
@@ -39,7 +39,7 @@ func RandColor() color.Attribute {
 func AddSpacing(n int) {
 
 	for i := 0; i < n; i++ {
-		fmt.Println()
+		fmt.Fprintln(out)
 	}
 }
 
@@ -48,11 +48,11 @@ func AddSpacing(n int) {
 func StartMessage() {
 
 	AddSpacing(1)
-	fmt.Println("Welcome to Go-Chat!")
+	fmt.Fprintln(out, "Welcome to Go-Chat!")
 	Frame()
-	fmt.Println("In order to begin chatting, you must first chose a server and username. It cannot be one")
-	fmt.Println("that is already in user on the server. Remember, your username only lasts for as long as")
-	fmt.Println("you are logged into the server!")
+	fmt.Fprintln(out, "In order to begin chatting, you must first chose a server and username. It cannot be one")
+	fmt.Fprintln(out, "that is already in user on the server. Remember, your username only lasts for as long as")
+	fmt.Fprintln(out, "you are logged into the server!")
 	AddSpacing(1)
 }
 
@@ -61,14 +61,14 @@ func StartMessage() {
 func WelcomeMessage(c pb.ChatClient, u string) {
 
 	AddSpacing(1)
-	u = "Welcome " + u + "!"
-	for _, l := range u {
+	w := "Welcome " + u + "!"
+	for _, l := range w {
 		color.New(RandColor()).Print(string(l))
 	}
 	n, _ := c.GetClientList(context.Background(), &pb.Empty{})
-	g, _ := c.GetGroupList(context.Background(), &pb.Empty{})
+	g, _ := c.GetGroupList(context.Background(), &pb.ClientInfo{Sender: u})
 
-	fmt.Print(" There are currently " + strconv.Itoa(len(n.Clients)) + " member(s) logged in and " + strconv.Itoa(len(g.Groups)) + " group(s).")
+	fmt.Fprint(out, " There are currently "+strconv.Itoa(len(n.Clients))+" member(s) logged in and "+strconv.Itoa(len(g.Groups))+" group(s).")
 	AddSpacing(1)
 }
 
@@ -76,29 +76,29 @@ func WelcomeMessage(c pb.ChatClient, u string) {
 // It doesn't return anything.
 func TopMenuText() {
 
-	fmt.Println("Main Menu")
+	fmt.Fprintln(out, "Main Menu")
 	AddSpacing(1)
-	fmt.Println("1) Create a Group")
-	fmt.Println("2) View Group Options")
-	fmt.Println("3) Exit Chat")
+	fmt.Fprintln(out, "1) Create a Group")
+	fmt.Fprintln(out, "2) View Group Options")
+	fmt.Fprintln(out, "3) Exit Chat")
 	AddSpacing(1)
-	color.New(promptColor).Print("Main> ")
+	color.New(theme.Prompt).Print("Main> ")
 }
 
 // GroupMenuText displays the option text for the group menu.
 // It doesn't return anything.
 func GroupMenuText() {
 
-	fmt.Println("View Groups Menu")
+	fmt.Fprintln(out, "View Groups Menu")
 	AddSpacing(1)
-	fmt.Println("Below is a list of menu options for groups.")
+	fmt.Fprintln(out, "Below is a list of menu options for groups.")
 	AddSpacing(1)
-	fmt.Println("1) View a Group's Members")
-	fmt.Println("2) Refresh List of Groups")
-	fmt.Println("3) Join a Group")
-	fmt.Println("4) Go back")
+	fmt.Fprintln(out, "1) View a Group's Members")
+	fmt.Fprintln(out, "2) Refresh List of Groups")
+	fmt.Fprintln(out, "3) Join a Group")
+	fmt.Fprintln(out, "4) Go back")
 	AddSpacing(1)
-	color.New(promptColor).Print("Groups> ")
+	color.New(theme.Prompt).Print("Groups> ")
 }
 
 // ViewGroupMemMenuText displays option text to view a group.
@@ -106,54 +106,96 @@ func GroupMenuText() {
 func ViewGroupMemMenuText() {
 
 	AddSpacing(1)
-	fmt.Println("Enter the group name that you would like to view! Enter !back to go back to the menu.")
+	fmt.Fprintln(out, "Enter the group name that you would like to view! Enter !back to go back to the menu.")
 	AddSpacing(1)
 }
 
 // Frame gives some nice formatting structure to the output.
 func Frame() {
 
-	fmt.Println("------------------------------------------")
+	fmt.Fprintln(out, "------------------------------------------")
+}
+
+// ServerAddress checks a server address given as ip:port, adding the default port if
+// there isn't one.
+// It returns the address and an error.
+func ServerAddress(a string) (string, error) {
+
+	a = strings.TrimSpace(a)
+	if a == "" {
+		return "", errors.New("no server was given")
+	}
+
+	if _, _, err := net.SplitHostPort(a); err != nil {
+		a = net.JoinHostPort(a, defaultPort)
+		if _, _, err := net.SplitHostPort(a); err != nil {
+			return "", err
+		}
+	}
+
+	return a, nil
 }
 
 // SetServer handles the input for the chat server address.
 // It returns a string which contains the ip:port of the chat server.
 func SetServer(r *bufio.Reader) string {
 
-	StartMessage()
+	for {
+		fmt.Fprint(out, "Please specify the server IP: ")
+		t, err := r.ReadString('\n')
+		if err != nil && strings.TrimSpace(t) == "" {
+			fmt.Fprintln(out)
+			os.Exit(exitError)
+		}
 
-	fmt.Print("Please specify the server IP: ")
-	t, _ := r.ReadString('\n')
-	t = strings.TrimSpace(t)
-	s := strings.Split(t, ":")
-	ip := s[0]
-	p := s[1]
-	address := ip + ":" + p
+		a, err := ServerAddress(t)
+		if err == nil {
+			return a
+		}
 
-	return address
+		AddSpacing(1)
+		color.New(theme.Error).Println("Please enter the server as ip:port, e.g. localhost:" + defaultPort + ".")
+	}
 }
 
-// SetName sets the username for the user.
+// SetName sets the username for the user and logs the session in with it. The username
+// u from the settings is tried first, if there is one.
 // It returns a string containing the username of the client.
-func SetName(c pb.ChatClient, r *bufio.Reader) string {
+func SetName(s *chatclient.Session, r *bufio.Reader, u string) string {
+
+	if u != "" {
+		err := s.Login(u)
+		if err == nil {
+			WelcomeMessage(s.Client(), u)
+			return u
+		} else if status.Code(err) == codes.AlreadyExists {
+			color.New(theme.Error).Println("The username " + u + " from your settings is already taken. Please choose a new one!")
+		} else {
+			color.New(theme.Error).Println("The username " + u + " from your settings can't be used. " + chatclient.ErrorMessage(err))
+		}
+	}
+
 	for {
-		fmt.Printf("Enter your username: ")
+		fmt.Fprintf(out, "Enter your username: ")
 		n, err := r.ReadString('\n')
 		if err != nil {
-			fmt.Print(err)
+			fmt.Fprint(out, err)
 		} else {
 			uName := strings.TrimSpace(n)
 			if len(uName) < 3 {
 				AddSpacing(1)
-				color.New(color.FgHiRed).Println("Your username must be at least 3 characters long.")
+				color.New(theme.Error).Println("Your username must be at least 3 characters long.")
 			} else {
-				_, err = c.Register(context.Background(), &pb.ClientInfo{Sender: uName})
+				err = s.Login(uName)
 
-				if err != nil {
+				if status.Code(err) == codes.AlreadyExists {
+					AddSpacing(1)
+					color.New(theme.Error).Println("That username already exists. Please choose a new one! ")
+				} else if err != nil {
 					AddSpacing(1)
-					color.New(color.FgHiRed).Println("That username already exists. Please choose a new one! ")
+					color.New(theme.Error).Println(chatclient.ErrorMessage(err))
 				} else {
-					WelcomeMessage(c, uName)
+					WelcomeMessage(s.Client(), uName)
 					return uName
 				}
 			}
@@ -163,27 +205,35 @@ func SetName(c pb.ChatClient, r *bufio.Reader) string {
 
 // CreateGroup handles the create group menu option.
 // It returns a string which contains the keyword !back allowing it to escape the input as well as an error.
-func CreateGroup(c pb.ChatClient, r *bufio.Reader, uName string) (string, error) {
+func CreateGroup(s *chatclient.Session, r *bufio.Reader) (string, error) {
 
 	for {
 		AddSpacing(1)
-		fmt.Println("Enter the name of the group or type !back to go back to the main menu.")
-		color.New(promptColor).Print("Join> ")
+		fmt.Fprintln(out, "Enter the name of the group or type !back to go back to the main menu.")
+		color.New(theme.Prompt).Print("Join> ")
 		g, err := r.ReadString('\n')
 		g = strings.TrimSpace(g)
 
 		if err != nil {
 			return "", err
 		} else if g != "!back" {
-			_, nerr := c.CreateGroup(context.Background(), &pb.GroupInfo{Client: uName, GroupName: g})
+			fmt.Fprint(out, "Encrypt messages in "+g+" end-to-end? (y/N) ")
+			e, _ := r.ReadString('\n')
+			encrypted := strings.ToLower(strings.TrimSpace(e)) == "y"
 
-			if nerr != nil {
+			nerr := s.Create(g, encrypted)
+
+			if status.Code(nerr) == codes.AlreadyExists {
+				AddSpacing(1)
+				color.New(theme.Error).Println("The group name \"" + g + "\" has already been chosen. Please select a new one.")
+			} else if nerr != nil {
 				AddSpacing(1)
-				color.New(color.FgRed).Println("The group name \"" + g + "\" has already been chosen. Please select a new one.")
+				color.New(theme.Error).Println(chatclient.ErrorMessage(nerr))
+			} else if err := s.Join(g); err != nil {
+				return "", err
 			} else {
-				c.JoinGroup(context.Background(), &pb.GroupInfo{Client: uName, GroupName: g})
 				AddSpacing(1)
-				color.New(color.FgGreen).Println("Created and joined group named " + g)
+				color.New(theme.Success).Println("Created and joined group named " + g)
 				return g, nil
 			}
 		} else {
@@ -194,11 +244,11 @@ func CreateGroup(c pb.ChatClient, r *bufio.Reader, uName string) (string, error)
 
 // JoinGroup handles the join group menu option.
 // It returns a string which contains the keyword !back allowing it to escape the input.
-func JoinGroup(c pb.ChatClient, r *bufio.Reader, u string) string {
+func JoinGroup(s *chatclient.Session, r *bufio.Reader) string {
 
 	for {
-		fmt.Println("Enter the name of the group as it appears in the group list or enter !back to go back to the Group menu.")
-		color.New(promptColor).Print("Group Name> ")
+		fmt.Fprintln(out, "Enter the name of the group as it appears in the group list or enter !back to go back to the Group menu.")
+		color.New(theme.Prompt).Print("Group Name> ")
 		g, _ := r.ReadString('\n')
 		g = strings.TrimSpace(g)
 
@@ -206,34 +256,46 @@ func JoinGroup(c pb.ChatClient, r *bufio.Reader, u string) string {
 			return g
 		}
 
-		_, err := c.JoinGroup(context.Background(), &pb.GroupInfo{Client: u, GroupName: g})
+		err := s.Join(g)
 
-		if err != nil {
+		if status.Code(err) == codes.NotFound {
+			AddSpacing(1)
+			color.New(theme.Error).Println("The group name \"" + g + "\" doesn't exist. Please check again.")
+			AddSpacing(1)
+		} else if err != nil {
 			AddSpacing(1)
-			color.New(color.FgRed).Println("The group name \"" + g + "\" doesn't exist. Please check again.")
+			color.New(theme.Error).Println(chatclient.ErrorMessage(err))
 			AddSpacing(1)
 		} else {
-			color.New(color.FgGreen).Println("Joined " + g)
+			color.New(theme.Success).Println("Joined " + g)
 			return g
 		}
 	}
 }
 
-// ListGroups handles listing all of the groups stored on the server.
+// ListGroups handles listing all of the groups stored on the server along with how many
+// messages in each the user hasn't read.
 // It doesn't return anything.
-func ListGroups(c pb.ChatClient, r *bufio.Reader) {
+func ListGroups(c pb.ChatClient, r *bufio.Reader, u string) {
 
-	t, _ := c.GetGroupList(context.Background(), &pb.Empty{})
+	t, _ := c.GetGroupList(context.Background(), &pb.ClientInfo{Sender: u})
 	l := t.Groups
 
 	if len(l) == 0 {
 		AddSpacing(1)
-		color.New(color.FgYellow).Println("There are no groups created yet!")
+		color.New(theme.Warning).Println("There are no groups created yet!")
 	} else {
 		AddSpacing(1)
-		fmt.Println("Current groups able to join:")
+		fmt.Fprintln(out, "Current groups able to join:")
 		for i, g := range l {
-			fmt.Println("  " + strconv.Itoa(i+1) + ") " + g)
+			line := "  " + strconv.Itoa(i+1) + ") " + g
+			if i < len(t.Encrypted) && t.Encrypted[i] {
+				line += " [encrypted]"
+			}
+			if i < len(t.Unread) && t.Unread[i] > 0 {
+				line += " (" + strconv.FormatUint(t.Unread[i], 10) + " unread)"
+			}
+			fmt.Fprintln(out, line)
 		}
 	}
 
@@ -244,13 +306,13 @@ func ListGroups(c pb.ChatClient, r *bufio.Reader) {
 func ListGroupMembers(c pb.ChatClient, r *bufio.Reader, u string) error {
 
 	for {
-		color.New(promptColor).Print("View> ")
-		t, _ := c.GetGroupList(context.Background(), &pb.Empty{})
+		color.New(theme.Prompt).Print("View> ")
+		t, _ := c.GetGroupList(context.Background(), &pb.ClientInfo{Sender: u})
 		n := len(t.Groups)
 
 		if n == 0 {
 			AddSpacing(2)
-			color.New(color.FgYellow).Println("There are currently no groups created!")
+			color.New(theme.Warning).Println("There are currently no groups created!")
 			return nil
 		}
 
@@ -263,12 +325,14 @@ func ListGroupMembers(c pb.ChatClient, r *bufio.Reader, u string) error {
 			return nil
 		} else {
 			ls, err := c.GetGroupClientList(context.Background(), &pb.GroupInfo{Client: u, GroupName: g})
-			if err != nil {
-				color.New(color.FgRed).Println("Please double check that the group name you entered actually exists.")
+			if status.Code(err) == codes.NotFound {
+				color.New(theme.Error).Println("Please double check that the group name you entered actually exists.")
+			} else if err != nil {
+				color.New(theme.Error).Println(chatclient.ErrorMessage(err))
 			} else {
-				fmt.Println("Members of " + g)
-				for i, c := range ls.Clients {
-					fmt.Println("  " + strconv.Itoa(i+1) + ") " + c)
+				fmt.Fprintln(out, "Members of "+g)
+				for i, c := range MemberNames(ls) {
+					fmt.Fprintln(out, "  "+strconv.Itoa(i+1)+") "+c)
 				}
 
 				return nil
@@ -277,9 +341,9 @@ func ListGroupMembers(c pb.ChatClient, r *bufio.Reader, u string) error {
 	}
 }
 
-// TopMenu handles displaying the menu to the client.
-// It returns the group name for the user and an error.
-func TopMenu(c pb.ChatClient, r *bufio.Reader, u string) (string, error) {
+// TopMenu handles displaying the menu to the client until the session has joined a group.
+// It returns an error.
+func TopMenu(s *chatclient.Session, r *bufio.Reader) error {
 	//func TopMenu(c pb.ChatClient, u string) (string, error) {
 	log.Println("In TopMenu")
 
@@ -293,35 +357,38 @@ func TopMenu(c pb.ChatClient, r *bufio.Reader, u string) (string, error) {
 
 		switch input := i; input {
 		case "1": // Create group
-			g, err := CreateGroup(c, r, u)
+			g, err := CreateGroup(s, r)
 
 			if err != nil {
-				return g, err
+				return err
 			} else if g != "!back" {
-				return g, nil
+				return nil
 			}
 		case "2": // View Group Menu
-			g, err := DisplayGroupMenu(c, r, u)
+			g, err := DisplayGroupMenu(s, r)
 
 			if err != nil {
-				return g, err
+				return err
 			} else if g != "!back" {
-				return g, nil
+				return nil
 			}
 		case "3": // Exit Client
-			c.UnRegister(context.Background(), &pb.ClientInfo{Sender: u})
+			s.Close()
 			os.Exit(0)
 		default: // Error
-			color.New(color.FgRed).Println("Please enter a valid selection between 1 and 3.")
+			color.New(theme.Error).Println("Please enter a valid selection between 1 and 3.")
 		}
 	}
 }
 
 // DisplayGroupMenu displays the menu for the group options.
 // It returns either an empty string or the keyword !back to navigate to TopMenu.
-func DisplayGroupMenu(c pb.ChatClient, r *bufio.Reader, u string) (string, error) {
+func DisplayGroupMenu(s *chatclient.Session, r *bufio.Reader) (string, error) {
+
+	c := s.Client()
+	u := s.User()
 
-	ListGroups(c, r)
+	ListGroups(c, r, u)
 
 	for {
 		Frame()
@@ -337,17 +404,17 @@ func DisplayGroupMenu(c pb.ChatClient, r *bufio.Reader, u string) (string, error
 				return "", err
 			}
 		case "2": // Refresh Group List
-			ListGroups(c, r)
+			ListGroups(c, r, u)
 			break
 		case "3": // Join Group
-			g := JoinGroup(c, r, u)
+			g := JoinGroup(s, r)
 			if g != "!back" {
 				return g, nil
 			}
 		case "4": // Go Back
 			return "!back", nil
 		default: // Error
-			color.New(color.FgRed).Println("Please enter a valid selection between 1 and 4.")
+			color.New(theme.Error).Println("Please enter a valid selection between 1 and 4.")
 		}
 	}
 }
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fatih/color"
+	"github.com/muesli/termenv"
+)
+
+// Theme holds the colors the client uses.
+type Theme struct {
+	Prompt    color.Attribute   // Prompts in the menus.
+	Error     color.Attribute   // Errors.
+	Success   color.Attribute   // Confirmations, such as joining a group.
+	Warning   color.Attribute   // Notices, such as there being no groups yet.
+	Dim       color.Attribute   // Less important details, such as message ids and edits.
+	Highlight color.Attribute   // Commands in the help and messages that mention the user.
+	Welcome   []color.Attribute // The colors the welcome message is picked from.
+	NoColor   bool              // Whether to turn colors off altogether.
+}
+
+// themes holds the available themes by name.
+var themes = map[string]Theme{
+	"default": {
+		Prompt:    color.FgHiMagenta,
+		Error:     color.FgRed,
+		Success:   color.FgGreen,
+		Warning:   color.FgYellow,
+		Dim:       color.FgHiBlack,
+		Highlight: color.FgHiYellow,
+		Welcome:   []color.Attribute{color.FgHiCyan, color.FgHiGreen, color.FgHiRed, color.FgHiWhite, color.FgHiYellow, color.FgHiMagenta},
+	},
+	"light": {
+		Prompt:    color.FgMagenta,
+		Error:     color.FgRed,
+		Success:   color.FgGreen,
+		Warning:   color.FgMagenta,
+		Dim:       color.FgHiBlack,
+		Highlight: color.FgBlue,
+		Welcome:   []color.Attribute{color.FgBlue, color.FgGreen, color.FgRed, color.FgMagenta, color.FgCyan, color.FgBlack},
+	},
+	"none": {
+		Welcome: []color.Attribute{color.Reset},
+		NoColor: true,
+	},
+}
+
+// theme is the theme in use.
+var theme = themes["default"]
+
+// ThemeNames lists the names of the available themes.
+// It returns the names in alphabetical order.
+func ThemeNames() []string {
+
+	var names []string
+	for n := range themes {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// SetTheme switches to the theme called name, for both the menus and the full-screen interface.
+// It returns an error.
+func SetTheme(name string) error {
+
+	t, ok := themes[name]
+	if !ok {
+		return errors.New("there is no theme called \"" + name + "\" (try " + strings.Join(ThemeNames(), ", ") + ")")
+	}
+
+	theme = t
+	if t.NoColor {
+		color.NoColor = true
+		lipgloss.SetColorProfile(termenv.Ascii)
+	}
+	SetTUIStyles(t)
+
+	return nil
+}
+
+// TermColor converts one of the theme's colors for use in the full-screen interface.
+// It returns the color.
+func TermColor(a color.Attribute) lipgloss.TerminalColor {
+
+	switch {
+	case a >= color.FgHiBlack && a <= color.FgHiWhite:
+		return lipgloss.Color(strconv.Itoa(int(a-color.FgHiBlack) + 8))
+	case a >= color.FgBlack && a <= color.FgWhite:
+		return lipgloss.Color(strconv.Itoa(int(a - color.FgBlack)))
+	}
+
+	return lipgloss.NoColor{}
+}
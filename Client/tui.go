@@ -0,0 +1,598 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	pb "github.com/taylorflatt/go-chat"
+	"github.com/taylorflatt/go-chat/chatclient"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Sizes and timings for the full-screen interface.
+const (
+	sidebarWidth    = 24              // Width of the group sidebar, without its border.
+	membersWidth    = 20              // Width of the member list, without its border.
+	refreshInterval = 2 * time.Second // How often the group and member lists are refreshed.
+)
+
+// Styles used by the full-screen interface. They are set from the theme by SetTUIStyles.
+var (
+	paneStyle     lipgloss.Style
+	focusStyle    lipgloss.Style
+	titleStyle    lipgloss.Style
+	dimStyle      lipgloss.Style
+	currentStyle  lipgloss.Style
+	selectedStyle lipgloss.Style
+	mentionStyle  lipgloss.Style
+	errorStyle    lipgloss.Style
+)
+
+func init() {
+
+	SetTUIStyles(theme)
+}
+
+// SetTUIStyles sets the styles of the full-screen interface from the theme t.
+// It doesn't return anything.
+func SetTUIStyles(t Theme) {
+
+	paneStyle = lipgloss.NewStyle().Border(lipgloss.NormalBorder()).BorderForeground(TermColor(t.Dim))
+	focusStyle = paneStyle.BorderForeground(TermColor(t.Prompt))
+	titleStyle = lipgloss.NewStyle().Bold(true).Foreground(TermColor(t.Prompt))
+	dimStyle = lipgloss.NewStyle().Foreground(TermColor(t.Dim))
+	currentStyle = lipgloss.NewStyle().Bold(true).Foreground(TermColor(t.Success))
+	selectedStyle = lipgloss.NewStyle().Reverse(true)
+	mentionStyle = lipgloss.NewStyle().Bold(true).Foreground(TermColor(t.Highlight))
+	errorStyle = lipgloss.NewStyle().Foreground(TermColor(t.Error))
+}
+
+// eventMsg carries a message or event from the session's Events channel.
+type eventMsg pb.ChatMessage
+
+// closedMsg reports that the connection to the server was lost.
+type closedMsg struct{}
+
+// tickMsg asks for the group and member lists to be refreshed.
+type tickMsg time.Time
+
+// listsMsg carries freshly fetched group and member lists.
+type listsMsg struct {
+	groups  *pb.GroupList
+	group   string // The group the members were fetched for.
+	members []string
+}
+
+// TUI is the state of the full-screen interface: a sidebar of groups, the messages of the
+// group being chatted in, its members and an input line.
+type TUI struct {
+	s *chatclient.Session
+
+	groups   *pb.GroupList // Groups on the server with unread counts.
+	selected int           // The group highlighted in the sidebar.
+	sidebar  bool          // Whether the sidebar has focus rather than the input line.
+	members  []string      // Members of the group being chatted in.
+
+	history   map[uint64]*pb.ChatMessage // Messages in the group being chatted in, by id.
+	order     []uint64                   // Ids of the messages in the order they arrived.
+	mentioned map[uint64]bool            // Messages that mention the user.
+
+	messages viewport.Model
+	input    textinput.Model
+	status   string
+	width    int
+	height   int
+}
+
+// NewTUI creates the full-screen interface for a session that has logged in.
+// It returns the interface.
+func NewTUI(s *chatclient.Session) *TUI {
+
+	in := textinput.New()
+	in.Prompt = "> "
+	in.Placeholder = "Type a message, or /join <group>, /create <group>, /leave, /quit"
+	in.Focus()
+
+	t := &TUI{
+		s:        s,
+		groups:   &pb.GroupList{},
+		messages: viewport.New(0, 0),
+		input:    in,
+	}
+	t.Reset()
+
+	return t
+}
+
+// RunTUI runs the full-screen interface until the user quits.
+// It returns an error.
+func RunTUI(s *chatclient.Session) error {
+
+	_, err := tea.NewProgram(NewTUI(s), tea.WithAltScreen()).Run()
+	return err
+}
+
+// Reset clears the messages shown, for example when moving to another group.
+// It doesn't return anything.
+func (t *TUI) Reset() {
+
+	t.history = make(map[uint64]*pb.ChatMessage)
+	t.order = nil
+	t.mentioned = make(map[uint64]bool)
+	t.members = nil
+	t.Render()
+}
+
+// Init starts listening for events and refreshing the group list.
+// It returns the commands to run.
+func (t *TUI) Init() tea.Cmd {
+
+	return tea.Batch(textinput.Blink, t.WaitForEvent(), t.FetchLists(), Tick())
+}
+
+// Tick schedules the next refresh of the group and member lists.
+// It returns the command to run.
+func Tick() tea.Cmd {
+
+	return tea.Tick(refreshInterval, func(tm time.Time) tea.Msg { return tickMsg(tm) })
+}
+
+// WaitForEvent waits for the next event on the session.
+// It returns the command to run.
+func (t *TUI) WaitForEvent() tea.Cmd {
+
+	return func() tea.Msg {
+		m, ok := <-t.s.Events
+		if !ok {
+			return closedMsg{}
+		}
+		return eventMsg(m)
+	}
+}
+
+// FetchLists fetches the group list and the members of the group being chatted in.
+// It returns the command to run.
+func (t *TUI) FetchLists() tea.Cmd {
+
+	c := t.s.Client()
+	u := t.s.User()
+	g := t.s.Group()
+
+	return func() tea.Msg {
+		l := listsMsg{groups: &pb.GroupList{}, group: g}
+		if gs, err := c.GetGroupList(context.Background(), &pb.ClientInfo{Sender: u}); err == nil {
+			l.groups = gs
+		}
+		if g != "" {
+			if ms, err := c.GetGroupClientList(context.Background(), &pb.GroupInfo{Client: u, GroupName: g}); err == nil {
+				l.members = MemberNames(ms)
+			}
+		}
+		return l
+	}
+}
+
+// Update handles key presses, resizes, events from the server and refreshed lists.
+// It returns the updated interface and the commands to run.
+func (t *TUI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		t.width, t.height = msg.Width, msg.Height
+		t.Render()
+	case tea.KeyMsg:
+		return t.HandleKey(msg)
+	case eventMsg:
+		cmd := t.HandleEvent(pb.ChatMessage(msg))
+		return t, tea.Batch(cmd, t.WaitForEvent())
+	case closedMsg:
+		t.status = errorStyle.Render("Lost the connection to the server.")
+		return t, tea.Quit
+	case tickMsg:
+		return t, tea.Batch(t.FetchLists(), Tick())
+	case listsMsg:
+		t.groups = msg.groups
+		if msg.group == t.s.Group() {
+			t.members = msg.members
+		}
+		if t.selected >= len(t.groups.Groups) {
+			t.selected = 0
+		}
+	}
+
+	return t, nil
+}
+
+// HandleKey handles a key press. Tab moves focus between the sidebar and the input line;
+// in the sidebar the arrow keys pick a group and enter joins it.
+// It returns the updated interface and the commands to run.
+func (t *TUI) HandleKey(k tea.KeyMsg) (tea.Model, tea.Cmd) {
+
+	switch k.String() {
+	case "ctrl+c":
+		return t, t.Quit()
+	case "tab":
+		t.sidebar = !t.sidebar
+		if t.sidebar {
+			t.input.Blur()
+			return t, nil
+		}
+		return t, t.input.Focus()
+	case "pgup", "pgdown":
+		var cmd tea.Cmd
+		t.messages, cmd = t.messages.Update(k)
+		return t, cmd
+	}
+
+	if t.sidebar {
+		switch k.String() {
+		case "up", "k":
+			if t.selected > 0 {
+				t.selected--
+			}
+		case "down", "j":
+			if t.selected < len(t.groups.Groups)-1 {
+				t.selected++
+			}
+		case "enter":
+			if t.selected < len(t.groups.Groups) {
+				return t, t.Join(t.groups.Groups[t.selected])
+			}
+		}
+		return t, nil
+	}
+
+	if k.String() == "enter" {
+		line := strings.TrimSpace(t.input.Value())
+		t.input.Reset()
+		return t, t.HandleInput(line)
+	}
+
+	var cmd tea.Cmd
+	t.input, cmd = t.input.Update(k)
+	return t, cmd
+}
+
+// HandleInput runs a command typed into the input line or sends it to the group.
+// It returns the command to run.
+func (t *TUI) HandleInput(line string) tea.Cmd {
+
+	if line == "" {
+		return nil
+	}
+
+	t.status = ""
+	switch cmd, args := SplitCommand(line); cmd {
+	case "/join":
+		return t.Join(args)
+	case "/create":
+		g, opt := SplitCommand(args)
+		if g == "" {
+			t.status = errorStyle.Render("Usage: /create <group> [encrypted]")
+			return nil
+		}
+		if err := t.s.Create(g, opt == "encrypted"); status.Code(err) == codes.AlreadyExists {
+			t.status = errorStyle.Render("The group name \"" + g + "\" has already been chosen.")
+			return nil
+		} else if err != nil {
+			t.status = errorStyle.Render(chatclient.ErrorMessage(err))
+			return nil
+		}
+		return t.Join(g)
+	case "/leave", "!leave":
+		if t.s.Group() != "" {
+			t.s.Leave()
+			t.Reset()
+		}
+		return t.FetchLists()
+	case "/quit", "!exit":
+		return t.Quit()
+	case "/me":
+		if err := t.s.Act(args); err != nil {
+			t.status = errorStyle.Render("Your message wasn't sent. " + chatclient.ErrorMessage(err))
+		}
+		return nil
+	}
+
+	if strings.HasPrefix(line, "/") {
+		if err := t.s.Command(line); err != nil {
+			t.status = errorStyle.Render("Couldn't run the command. " + chatclient.ErrorMessage(err))
+		}
+		return nil
+	}
+
+	if t.s.Group() == "" {
+		t.status = errorStyle.Render("Join a group before sending messages.")
+		return nil
+	}
+
+	if err := t.s.Send(line); err != nil {
+		t.status = errorStyle.Render("Your message wasn't sent. " + chatclient.ErrorMessage(err))
+	}
+
+	return nil
+}
+
+// Join moves the session to the group g.
+// It returns the command to run.
+func (t *TUI) Join(g string) tea.Cmd {
+
+	if g == "" || g == t.s.Group() {
+		return nil
+	}
+
+	if err := t.s.Join(g); status.Code(err) == codes.NotFound {
+		t.status = errorStyle.Render("The group name \"" + g + "\" doesn't exist.")
+		return nil
+	} else if err != nil {
+		t.status = errorStyle.Render(chatclient.ErrorMessage(err))
+		return nil
+	}
+
+	t.Reset()
+	t.sidebar = false
+	t.status = ""
+
+	return tea.Batch(t.input.Focus(), t.FetchLists())
+}
+
+// Quit leaves the group the session is in, closes the session and stops the interface.
+// It returns the command to run.
+func (t *TUI) Quit() tea.Cmd {
+
+	if t.s.Group() != "" {
+		t.s.Leave()
+	}
+	t.s.Close()
+
+	return tea.Quit
+}
+
+// HandleEvent records a message or event from the server. Joins and leaves refresh the member
+// list, and mentions from other groups, private messages, announcements, dropped messages, topic
+// and name changes and the responses to server commands are shown on the status line.
+// It returns the command to run.
+func (t *TUI) HandleEvent(m pb.ChatMessage) tea.Cmd {
+
+	g := t.s.Group()
+
+	if m.Kind == pb.Kind_DIRECT {
+		if m.Sender != t.s.User() {
+			t.status = mentionStyle.Render("(private) " + m.Sender + "> " + strings.TrimRight(m.Message, "\n"))
+		}
+		return nil
+	}
+
+	if m.Kind == pb.Kind_ANNOUNCEMENT {
+		t.status = mentionStyle.Render("(announcement) " + strings.TrimRight(m.Message, "\n"))
+		return nil
+	}
+
+	if m.Kind == pb.Kind_RATE_LIMITED || m.Kind == pb.Kind_REJECTED {
+		t.status = errorStyle.Render(strings.TrimRight(m.Message, "\n"))
+		return nil
+	}
+
+	if m.Kind == pb.Kind_COMMAND_RESULT {
+		t.status = strings.ReplaceAll(strings.TrimRight(m.Message, "\n"), "\n", "  ")
+		return nil
+	}
+
+	if m.Kind == pb.Kind_MENTION {
+		if m.Receiver == g {
+			t.mentioned[m.Id] = true
+		} else {
+			t.status = mentionStyle.Render(m.Sender + " mentioned you in " + m.Receiver + ": " + strings.TrimRight(m.Message, "\n"))
+		}
+		return nil
+	}
+
+	if m.Receiver != g {
+		return nil
+	}
+
+	var cmd tea.Cmd
+	switch m.Kind {
+	case pb.Kind_EDIT:
+		if h, ok := t.history[m.Id]; ok {
+			h.Message = m.Message
+			h.Edited = true
+		}
+	case pb.Kind_DELETE:
+		if h, ok := t.history[m.Id]; ok {
+			h.Message = ""
+			h.Deleted = true
+		}
+	case pb.Kind_REACT, pb.Kind_UNREACT:
+		if h, ok := t.history[m.Id]; ok {
+			h.Reactions = m.Reactions
+		}
+	case pb.Kind_TOPIC:
+		t.status = dimStyle.Render(m.Sender + " set the topic to: " + strings.TrimRight(m.Message, "\n"))
+	case pb.Kind_NICK:
+		t.status = dimStyle.Render(m.Sender + " is now known as " + m.Message)
+		cmd = t.FetchLists()
+	default:
+		if m.Id == 0 {
+			return nil
+		}
+
+		if _, ok := t.history[m.Id]; !ok {
+			t.order = append(t.order, m.Id)
+		}
+		if p, ok := t.history[m.ParentId]; ok && m.ParentId != 0 {
+			p.Replies++
+		}
+		t.history[m.Id] = &m
+		if IsMembershipNotice(m) {
+			cmd = t.FetchLists()
+		}
+		t.s.Client().MarkRead(context.Background(), &pb.ReadMarker{Client: t.s.User(), GroupName: g, Id: m.Id})
+	}
+
+	t.Render()
+	return cmd
+}
+
+// IsMembershipNotice reports whether m announces someone joining or leaving the group.
+func IsMembershipNotice(m pb.ChatMessage) bool {
+
+	return m.File == nil && (m.Message == "joined chat!\n" || m.Message == m.Sender+" left chat!\n")
+}
+
+// FormatMessage formats a message the way DisplayMessage prints it in the line-based client.
+// It returns the formatted message.
+func FormatMessage(m *pb.ChatMessage, mentioned bool) string {
+
+	var b strings.Builder
+
+	if IsMembershipNotice(*m) && m.Message == "joined chat!\n" {
+		return dimStyle.Render("* " + m.Sender + " joined the chat")
+	} else if IsMembershipNotice(*m) {
+		return dimStyle.Render("* " + m.Sender + " left the chat")
+	}
+
+	if m.ParentId != 0 {
+		b.WriteString(dimStyle.Render("↪ #" + strconv.FormatUint(m.ParentId, 10) + " "))
+	}
+
+	text := strings.TrimRight(m.Message, "\n")
+	if m.File != nil && !m.Deleted {
+		text = fmt.Sprintf("shared %s (%s), id %s", m.File.Name, FormatSize(m.File.Size), m.File.Id)
+	}
+
+	line := fmt.Sprintf("[%d] %s> %s", m.Id, m.Sender, text)
+	if m.Kind == pb.Kind_ACTION {
+		line = fmt.Sprintf("[%d] * %s %s", m.Id, m.Sender, text)
+	}
+	if mentioned {
+		line = mentionStyle.Render(line)
+	}
+	b.WriteString(line)
+
+	if m.Deleted {
+		b.WriteString(dimStyle.Render("(deleted)"))
+	} else if m.Edited {
+		b.WriteString(dimStyle.Render(" (edited)"))
+	}
+	if m.Replies == 1 {
+		b.WriteString(dimStyle.Render(" (1 reply)"))
+	} else if m.Replies > 1 {
+		b.WriteString(dimStyle.Render(fmt.Sprintf(" (%d replies)", m.Replies)))
+	}
+	if len(m.Reactions) > 0 {
+		b.WriteString("\n" + dimStyle.Render("    "+ReactionSummary(m.Reactions)))
+	}
+
+	return b.String()
+}
+
+// Render lays out the panes for the current window size and refills the message pane.
+// It doesn't return anything.
+func (t *TUI) Render() {
+
+	w := t.width - sidebarWidth - membersWidth - 6
+	h := t.height - 6
+	if w < 10 || h < 3 {
+		return
+	}
+
+	t.messages.Width = w
+	t.messages.Height = h - 1
+	t.input.Width = t.width - 6
+
+	wrap := lipgloss.NewStyle().Width(w)
+	var lines []string
+	for _, id := range t.order {
+		lines = append(lines, wrap.Render(FormatMessage(t.history[id], t.mentioned[id])))
+	}
+
+	t.messages.SetContent(strings.Join(lines, "\n"))
+	t.messages.GotoBottom()
+}
+
+// View draws the interface.
+// It returns the drawn interface.
+func (t *TUI) View() string {
+
+	if t.width == 0 {
+		return "Loading..."
+	}
+
+	h := t.height - 6
+	g := t.s.Group()
+
+	// Groups
+	var side []string
+	side = append(side, titleStyle.Render("Groups"))
+	for i, n := range t.groups.Groups {
+		line := n
+		if i < len(t.groups.Encrypted) && t.groups.Encrypted[i] {
+			line += " 🔒"
+		}
+		if i < len(t.groups.Unread) && t.groups.Unread[i] > 0 && n != g {
+			line += " (" + strconv.FormatUint(t.groups.Unread[i], 10) + ")"
+		}
+		if n == g {
+			line = currentStyle.Render(line)
+		}
+		if t.sidebar && i == t.selected {
+			line = selectedStyle.Render(line)
+		}
+		side = append(side, line)
+	}
+	if len(t.groups.Groups) == 0 {
+		side = append(side, dimStyle.Render("No groups yet."))
+	}
+
+	// Messages
+	title := "Not in a group. Use /join <group> or pick one with tab."
+	if g != "" {
+		title = g
+		if t.s.Encrypted() {
+			title += " (end-to-end encrypted)"
+		}
+	}
+
+	// Members
+	members := append([]string(nil), t.members...)
+	sort.Strings(members)
+	mem := []string{titleStyle.Render("Members")}
+	for _, n := range members {
+		if n == t.s.User() {
+			n = currentStyle.Render(n)
+		}
+		mem = append(mem, n)
+	}
+
+	sideStyle, inputStyle := paneStyle, focusStyle
+	if t.sidebar {
+		sideStyle, inputStyle = focusStyle, paneStyle
+	}
+
+	panes := lipgloss.JoinHorizontal(lipgloss.Top,
+		sideStyle.Width(sidebarWidth).Height(h).Render(strings.Join(side, "\n")),
+		paneStyle.Width(t.messages.Width).Height(h).Render(titleStyle.Render(title)+"\n"+t.messages.View()),
+		paneStyle.Width(membersWidth).Height(h).Render(strings.Join(mem, "\n")),
+	)
+
+	help := dimStyle.Render("tab: switch focus · enter: send/join · pgup/pgdn: scroll · /create <group> [encrypted]")
+	if t.status != "" {
+		help = t.status
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		panes,
+		inputStyle.Width(t.width-2).Render(t.input.View()),
+		help,
+	)
+}
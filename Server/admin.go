@@ -0,0 +1,206 @@
+package main
+
+import (
+	"log"
+	"net"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	pb "github.com/taylorflatt/go-chat"
+	context "golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type admin struct{}
+
+// ServeAdmin serves the Admin service on addr, apart from the Chat service so that it can be
+// kept to a private address.
+// It doesn't return anything.
+func ServeAdmin(addr string) {
+
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Fatalf("Failed to listen for the Admin service %v", err)
+	}
+
+	s := grpc.NewServer(grpc.UnaryInterceptor(SanitizeUnary))
+	pb.RegisterAdminServer(s, &admin{})
+
+	log.Print("[ServeAdmin]: Listening on " + addr)
+	if err := s.Serve(lis); err != nil {
+		log.Fatalf("Failed to serve the Admin service: %v", err)
+	}
+}
+
+// ListSessions lists every client connected to the server, ordered by name.
+// It returns the list of sessions and an error.
+func (a *admin) ListSessions(ctx context.Context, in *pb.Empty) (*pb.SessionList, error) {
+
+	lock.RLock()
+	defer lock.RUnlock()
+
+	var l []*pb.Session
+	for n, c := range clients {
+		l = append(l, &pb.Session{
+			Client:    n,
+			Bot:       c.bot,
+			Connected: c.connected.Unix(),
+			Address:   c.address,
+			Groups:    append([]string(nil), c.groups...),
+		})
+	}
+	sort.Slice(l, func(i, j int) bool { return l[i].Client < l[j].Client })
+
+	return &pb.SessionList{Sessions: l}, nil
+}
+
+// DisconnectUser logs a client out. The groups they were in see them leave, and they are told
+// why before their stream is closed.
+// It returns an empty object and an error.
+func (a *admin) DisconnectUser(ctx context.Context, in *pb.Disconnect) (*pb.Empty, error) {
+
+	u := in.Client
+	if !ClientExists(u) {
+		return nil, NotFound("client", u)
+	}
+
+	for _, g := range GroupsOf(u) {
+		Broadcast(g, pb.ChatMessage{Sender: u, Receiver: g, Message: u + " left chat!\n"})
+	}
+
+	text := "You were disconnected by an operator."
+	if r := strings.TrimSpace(in.Reason); r != "" {
+		text += " " + r
+	}
+
+	lock.Lock()
+	Announce(u, text)
+	lock.Unlock()
+
+	if err := DisconnectClient(u); err != nil {
+		return nil, err
+	}
+
+	log.Print("[DisconnectUser]: Disconnected " + u)
+
+	return &pb.Empty{}, nil
+}
+
+// DeleteGroup removes a group along with its history, topic and incoming webhooks. Its members
+// are taken out of it and told that it was deleted.
+// It returns an empty object and an error.
+func (a *admin) DeleteGroup(ctx context.Context, in *pb.GroupInfo) (*pb.Empty, error) {
+
+	g := in.GroupName
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	grp, ok := groups[g]
+	if !ok {
+		return nil, NotFound("group", g)
+	}
+
+	for _, m := range grp.clients {
+		c := clients[m]
+		Deliver(c, pb.ChatMessage{Sender: m, Receiver: g, Message: m + " left chat!\n"})
+		Announce(m, "The group "+g+" was deleted by an operator.")
+		EmitMembership("leave", m, g)
+
+		for i := range c.groups {
+			if c.groups[i] == g {
+				c.groups = append(c.groups[:i], c.groups[i+1:]...)
+				break
+			}
+		}
+	}
+
+	delete(groups, g)
+	DeleteGroupFilesLocked(g)
+	log.Print("[DeleteGroup]: Deleted group " + g)
+
+	return &pb.Empty{}, nil
+}
+
+// BroadcastAnnouncement sends a notice to every connected client.
+// It returns an empty object and an error.
+func (a *admin) BroadcastAnnouncement(ctx context.Context, in *pb.Announcement) (*pb.Empty, error) {
+
+	text := strings.TrimSpace(in.Message)
+	if text == "" {
+		return nil, InvalidArgument("message", "the announcement can't be empty")
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	for n := range clients {
+		Announce(n, text)
+	}
+
+	log.Print("[BroadcastAnnouncement]: Announced " + text)
+
+	return &pb.Empty{}, nil
+}
+
+// Announce sends client n a notice from the operators without waiting on a client that isn't
+// reading (see Deliver). The lock must be held.
+// It doesn't return anything.
+func Announce(n string, text string) {
+
+	if c, ok := clients[n]; ok {
+		Deliver(c, pb.ChatMessage{Receiver: n, Kind: pb.Kind_ANNOUNCEMENT, Message: text + "\n"})
+	}
+}
+
+// GetServerStats describes how busy the server is.
+// It returns the stats and an error.
+func (a *admin) GetServerStats(ctx context.Context, in *pb.Empty) (*pb.ServerStats, error) {
+
+	lock.RLock()
+	defer lock.RUnlock()
+
+	st := &pb.ServerStats{
+		UptimeSeconds: int64(time.Since(started).Seconds()),
+		Clients:       int32(len(clients)),
+		Groups:        int32(len(groups)),
+		Webhooks:      int32(len(webhooks)),
+		Goroutines:    int32(runtime.NumGoroutine()),
+	}
+	for _, c := range clients {
+		if c.bot {
+			st.Bots++
+		}
+	}
+	for _, g := range groups {
+		st.StoredMessages += uint64(len(g.history))
+	}
+
+	return st, nil
+}
+
+// ReloadConfig reads the outgoing webhooks again from the file the server was started with.
+// The other settings are flags, which only take effect on a restart.
+// It returns what was loaded and an error.
+func (a *admin) ReloadConfig(ctx context.Context, in *pb.Empty) (*pb.ConfigInfo, error) {
+
+	if webhooksFile == "" {
+		return nil, status.Error(codes.FailedPrecondition, "the server wasn't started with a -webhooks file to reload")
+	}
+
+	if err := LoadWebhooks(webhooksFile); err != nil {
+		return nil, err
+	}
+
+	lock.RLock()
+	n := len(webhooks)
+	lock.RUnlock()
+
+	log.Printf("[ReloadConfig]: Reloaded %d webhook(s) from %s", n, webhooksFile)
+
+	return &pb.ConfigInfo{WebhooksFile: webhooksFile, Webhooks: int32(n)}, nil
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pb "github.com/taylorflatt/go-chat"
+	"github.com/taylorflatt/go-chat/chatclient"
+	"google.golang.org/grpc"
+)
+
+func TestListSessions(t *testing.T) {
+
+	ts := startServer(t)
+	ts.member(t, "alice", "general", true)
+	ts.login(t, "bob")
+
+	l, err := ts.admin(t).ListSessions(context.Background(), &pb.Empty{})
+	if err != nil {
+		t.Fatalf("listing the sessions: %v", err)
+	}
+
+	if len(l.Sessions) != 2 {
+		t.Fatalf("got %d sessions, want 2", len(l.Sessions))
+	}
+	a, b := l.Sessions[0], l.Sessions[1]
+	if a.Client != "alice" || strings.Join(a.Groups, ",") != "general" || a.Connected == 0 {
+		t.Errorf("alice's session is %+v", a)
+	}
+	if b.Client != "bob" || len(b.Groups) != 0 {
+		t.Errorf("bob's session is %+v", b)
+	}
+}
+
+func TestDisconnectUser(t *testing.T) {
+
+	ts := startServer(t)
+	alice := ts.member(t, "alice", "general", true)
+	bob := ts.member(t, "bob", "general", false)
+
+	if _, err := ts.admin(t).DisconnectUser(context.Background(), &pb.Disconnect{Client: "bob", Reason: "Be nice."}); err != nil {
+		t.Fatalf("disconnecting bob: %v", err)
+	}
+
+	expect(t, alice, "bob leaving", func(m pb.ChatMessage) bool { return m.Message == "bob left chat!\n" })
+	expect(t, bob, "the reason", func(m pb.ChatMessage) bool {
+		return m.Kind == pb.Kind_ANNOUNCEMENT && strings.Contains(m.Message, "Be nice.")
+	})
+	eventually(t, "bob's stream to close", func() bool {
+		_, open := <-bob.Events
+		return !open
+	})
+
+	if got := members(t, alice, "general"); strings.Join(got, ",") != "alice" {
+		t.Errorf("general has %v after bob was disconnected, want [alice]", got)
+	}
+	if _, err := ts.admin(t).DisconnectUser(context.Background(), &pb.Disconnect{Client: "bob"}); err == nil {
+		t.Error("disconnected bob twice")
+	}
+}
+
+func TestDeleteGroup(t *testing.T) {
+
+	ts := startServer(t)
+	alice := ts.member(t, "alice", "general", true)
+	ts.member(t, "bob", "general", false)
+
+	if _, err := ts.admin(t).DeleteGroup(context.Background(), &pb.GroupInfo{GroupName: "general"}); err != nil {
+		t.Fatalf("deleting general: %v", err)
+	}
+
+	expect(t, alice, "the notice", func(m pb.ChatMessage) bool {
+		return m.Kind == pb.Kind_ANNOUNCEMENT && strings.Contains(m.Message, "general was deleted")
+	})
+	if got := groupNames(t, alice); len(got) != 0 {
+		t.Errorf("the groups are %v after deleting general, want none", got)
+	}
+
+	// The members are free to join other groups.
+	if err := alice.Create("random", false); err != nil {
+		t.Fatalf("creating random: %v", err)
+	}
+	if err := alice.Join("random"); err != nil {
+		t.Fatalf("joining random: %v", err)
+	}
+}
+
+func TestBroadcastAnnouncement(t *testing.T) {
+
+	ts := startServer(t)
+	alice := ts.member(t, "alice", "general", true)
+	bob := ts.login(t, "bob")
+
+	a := ts.admin(t)
+	if _, err := a.BroadcastAnnouncement(context.Background(), &pb.Announcement{Message: " "}); err == nil {
+		t.Error("sent an empty announcement")
+	}
+	if _, err := a.BroadcastAnnouncement(context.Background(), &pb.Announcement{Message: "Restarting soon"}); err != nil {
+		t.Fatalf("announcing: %v", err)
+	}
+
+	for _, s := range []*chatclient.Session{alice, bob} {
+		m := expect(t, s, "the announcement", func(m pb.ChatMessage) bool { return m.Kind == pb.Kind_ANNOUNCEMENT })
+		if m.Message != "Restarting soon\n" || m.Receiver != s.User() {
+			t.Errorf("%s got %+v", s.User(), m)
+		}
+	}
+}
+
+func TestAnnouncementsDontWaitOnSlowClients(t *testing.T) {
+
+	ts := startServer(t)
+	alice := ts.member(t, "alice", "general", true)
+	var deleted atomic.Bool
+	go func() {
+		for m := range alice.Events {
+			if m.Kind == pb.Kind_ANNOUNCEMENT && m.Message == "fake\n" {
+				t.Errorf("alice got mallory's announcement")
+			} else if m.Kind == pb.Kind_ANNOUNCEMENT && strings.Contains(m.Message, "general was deleted") {
+				deleted.Store(true)
+			}
+		}
+	}()
+
+	ts.raw(t, "slow", "general", grpc.WithInitialWindowSize(1<<16), grpc.WithInitialConnWindowSize(1<<16))
+
+	// Clients can't make announcements of their own.
+	mallory := ts.raw(t, "mallory", "general")
+	mallory.Send(&pb.ChatMessage{Sender: "mallory", Receiver: "general", Kind: pb.Kind_ANNOUNCEMENT, Message: "fake\n"})
+	expectRaw(t, mallory, "the announcement being turned away", func(m *pb.ChatMessage) bool { return m.Kind == pb.Kind_REJECTED })
+
+	// The slow client never reads, so its stream and then its channel fill up long before
+	// the operator is done.
+	a := ts.admin(t)
+	text := strings.Repeat("x", 2000)
+	for i := 0; i < 500; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		_, err := a.BroadcastAnnouncement(ctx, &pb.Announcement{Message: text})
+		cancel()
+		if err != nil {
+			t.Fatalf("announcing: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := a.DeleteGroup(ctx, &pb.GroupInfo{GroupName: "general"}); err != nil {
+		t.Fatalf("deleting general: %v", err)
+	}
+
+	eventually(t, "alice to be told general was deleted", deleted.Load)
+}
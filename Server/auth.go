@@ -0,0 +1,75 @@
+package main
+
+import (
+	"crypto/subtle"
+	"path"
+
+	"github.com/taylorflatt/go-chat/chatclient"
+	context "golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+// Most calls name the client making them, which is fine for reading, but calls that act with a
+// client's keys or rights have to come from that client's session. Register gives each session
+// a token, which it sends back with every call as metadata.
+
+// publicCalls are the Chat calls anyone can make, since they only look things up or log in.
+// Every other call acts for the client it names and is authenticated before it is handled.
+var publicCalls = map[string]bool{
+	"Register":           true,
+	"GetClientList":      true,
+	"GetGroupList":       true,
+	"GetGroupClientList": true,
+	"GetPublicKeys":      true,
+	"GetTopic":           true,
+}
+
+// AuthenticateUnary turns away calls to the Chat service that don't come from the session of
+// the client they name, in its client or sender field. Streams authenticate themselves, since
+// their requests aren't known until they are read.
+// It returns the response and an error.
+func AuthenticateUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+
+	service, method := path.Split(info.FullMethod)
+	if service != "/goChat.Chat/" || publicCalls[method] {
+		return handler(ctx, req)
+	}
+
+	n := ""
+	switch r := req.(type) {
+	case interface{ GetClient() string }:
+		n = r.GetClient()
+	case interface{ GetSender() string }:
+		n = r.GetSender()
+	}
+
+	if err := Authenticate(ctx, n); err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+// Authenticate checks that the call ctx was made by the session that registered client n.
+// It returns an error if it wasn't.
+func Authenticate(ctx context.Context, n string) error {
+
+	token := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get(chatclient.TokenHeader)) > 0 {
+		token = md.Get(chatclient.TokenHeader)[0]
+	}
+
+	lock.RLock()
+	c, ok := clients[n]
+	lock.RUnlock()
+
+	if !ok {
+		return NotFound("client", n)
+	} else if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(c.token)) != 1 {
+		return StatusError(codes.Unauthenticated, "the call didn't come from "+n+"'s session")
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/taylorflatt/go-chat"
+	"github.com/taylorflatt/go-chat/bot"
+	"google.golang.org/grpc"
+)
+
+func TestBot(t *testing.T) {
+
+	ts := startServer(t)
+	alice := ts.member(t, "alice", "general", true)
+
+	b := bot.New("bufnet", "helper", "general")
+	b.Options = []grpc.DialOption{grpc.WithContextDialer(ts.dial)}
+	b.Command("ping", "Checks that the bot is alive.", func(b *bot.Bot, m pb.ChatMessage, args string) {
+		b.Respond(m, "pong")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- b.Run(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("running the bot: %v", err)
+		}
+	})
+
+	joined := func(m pb.ChatMessage) bool { return m.Sender == "helper" && m.Message == "joined chat!\n" }
+	pong := func(m pb.ChatMessage) bool { return m.Sender == "helper" && m.Message == "pong\n" }
+
+	expect(t, alice, "the bot joining", joined)
+	alice.Send("!ping")
+	expect(t, alice, "the bot's reply", pong)
+
+	// An operator disconnecting the bot is like losing the connection, so it comes back.
+	if _, err := ts.admin(t).DisconnectUser(context.Background(), &pb.Disconnect{Client: "helper"}); err != nil {
+		t.Fatalf("disconnecting the bot: %v", err)
+	}
+	expect(t, alice, "the bot leaving", func(m pb.ChatMessage) bool { return m.Sender == "helper" && m.Message == "helper left chat!\n" })
+	expect(t, alice, "the bot joining again", joined)
+
+	alice.Send("!ping")
+	expect(t, alice, "the bot's reply after coming back", pong)
+}
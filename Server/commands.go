@@ -0,0 +1,276 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/taylorflatt/go-chat"
+	context "golang.org/x/net/context"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// CommandHandler runs a server command for client u, who ran it from group g (empty if they
+// aren't in one), with the rest of the command line as args.
+// It returns the response for the client and an error.
+type CommandHandler func(u string, g string, args string) (string, error)
+
+// Command is a slash command the server runs for clients.
+type Command struct {
+	Name    string // The name it is run by, without the slash, e.g. "kick".
+	Args    string // The arguments it takes, e.g. "<user>". Optional ones are in brackets.
+	Help    string // What it does, for /help.
+	Handler CommandHandler
+}
+
+var commandsLock = &sync.RWMutex{}
+var commands = make(map[string]*Command)
+
+// started is when the server started, for /uptime.
+var started = time.Now()
+
+// RegisterCommand adds c to the commands the server runs. Commands can be added by any file
+// in the server, usually from an init function.
+// It doesn't return anything.
+func RegisterCommand(c *Command) {
+
+	commandsLock.Lock()
+	defer commandsLock.Unlock()
+
+	if _, ok := commands[c.Name]; ok {
+		panic("command /" + c.Name + " is registered twice")
+	}
+
+	commands[c.Name] = c
+}
+
+// DispatchCommand runs the command line sent by client u from group g. The first word names
+// the command, with or without its slash. Commands trust u, so it has to come from an
+// authenticated call or stream, as in RunCommand and RouteChat.
+// It returns the command's response and an error.
+func DispatchCommand(u string, g string, line string) (string, error) {
+
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return "", InvalidArgument("message", "there is no command to run")
+	}
+
+	name := strings.TrimPrefix(fields[0], "/")
+	args := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), fields[0]))
+
+	commandsLock.RLock()
+	c, ok := commands[name]
+	commandsLock.RUnlock()
+
+	if !ok {
+		return "", StatusError(codes.NotFound, "there is no command called /"+name+" (try /help)", &errdetails.ResourceInfo{ResourceType: "command", ResourceName: name})
+	}
+
+	required := 0
+	for _, a := range strings.Fields(c.Args) {
+		if strings.HasPrefix(a, "<") {
+			required++
+		}
+	}
+	if len(fields)-1 < required {
+		return "", InvalidArgument("message", "usage: /"+strings.TrimSpace(c.Name+" "+c.Args))
+	}
+
+	log.Print("[DispatchCommand]: " + u + " ran /" + name + " in " + g)
+
+	return c.Handler(u, g, args)
+}
+
+// CommandResult runs the command in msg for client u.
+// It returns the response event, which holds the error instead if the command failed.
+func CommandResult(u string, msg pb.ChatMessage) *pb.ChatMessage {
+
+	text, err := DispatchCommand(u, msg.Receiver, msg.Message)
+	if err != nil {
+		text = status.Convert(err).Message()
+	}
+
+	return &pb.ChatMessage{Sender: u, Receiver: msg.Receiver, Kind: pb.Kind_COMMAND_RESULT, Message: text}
+}
+
+// RunCommand runs a slash command for a client without going through the chat stream. Commands
+// act with the client's rights, so the call has to come from the client's own session.
+// It returns the response event and an error.
+func (s *server) RunCommand(ctx context.Context, in *pb.ChatMessage) (*pb.ChatMessage, error) {
+
+	text, err := DispatchCommand(in.Sender, in.Receiver, in.Message)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.ChatMessage{Sender: in.Sender, Receiver: in.Receiver, Kind: pb.Kind_COMMAND_RESULT, Message: text}, nil
+}
+
+// HelpCommand lists the server's commands.
+// It returns the list and an error.
+func HelpCommand(u string, g string, args string) (string, error) {
+
+	commandsLock.RLock()
+	defer commandsLock.RUnlock()
+
+	var lines []string
+	for _, c := range commands {
+		lines = append(lines, "/"+strings.TrimSpace(c.Name+" "+c.Args)+": "+c.Help)
+	}
+	sort.Strings(lines)
+
+	return "The server runs these commands:\n" + strings.Join(lines, "\n"), nil
+}
+
+// WhoCommand lists the members of a group, by default the one the command was run in, or
+// everyone online if it wasn't run in one.
+// It returns the list and an error.
+func WhoCommand(u string, g string, args string) (string, error) {
+
+	if args != "" {
+		g = args
+	}
+
+	lock.RLock()
+	defer lock.RUnlock()
+
+	var names []string
+	if g == "" {
+		for n := range clients {
+			names = append(names, n)
+		}
+		return "Online: " + strings.Join(MarkBots(names), ", "), nil
+	}
+
+	grp, ok := groups[g]
+	if !ok {
+		return "", NotFound("group", g)
+	}
+
+	names = append(names, grp.clients...)
+
+	return "In " + g + ": " + strings.Join(MarkBots(names), ", "), nil
+}
+
+// MarkBots sorts the client names ns and marks the bots among them. The lock must be held.
+// It returns the names.
+func MarkBots(ns []string) []string {
+
+	sort.Strings(ns)
+	for i, b := range Bots(ns) {
+		if b {
+			ns[i] += " (bot)"
+		}
+	}
+
+	return ns
+}
+
+// TopicCommand shows the topic of the group the command was run in or, given one, sets it.
+// It returns the response and an error.
+func TopicCommand(u string, g string, args string) (string, error) {
+
+	if g == "" {
+		return "", status.Error(codes.FailedPrecondition, "join a group to see or set its topic")
+	}
+
+	if args != "" {
+		if err := ChangeTopic(u, g, args); err != nil {
+			return "", err
+		}
+		return "Set the topic of " + g + ".", nil
+	}
+
+	lock.RLock()
+	defer lock.RUnlock()
+
+	grp, ok := groups[g]
+	if !ok {
+		return "", NotFound("group", g)
+	} else if grp.topic == "" {
+		return g + " doesn't have a topic yet.", nil
+	}
+
+	return "The topic of " + g + " is: " + grp.topic, nil
+}
+
+// KickCommand removes a member from the group the command was run in. Only the group's
+// moderator can kick people, and the member is told why they were removed.
+// It returns the response and an error.
+func KickCommand(u string, g string, args string) (string, error) {
+
+	target := strings.Fields(args)[0]
+
+	// The checks and the removal are done in one step so the target can't leave, or the group
+	// change hands, in between.
+	lock.Lock()
+	grp, ok := groups[g]
+	if !ok {
+		lock.Unlock()
+		return "", status.Error(codes.FailedPrecondition, "run /kick in the group you want to kick someone from")
+	} else if grp.owner != u {
+		lock.Unlock()
+		return "", PermissionDenied(reasonNotModerator, "only the moderator of "+g+" can kick people")
+	} else if target == u {
+		lock.Unlock()
+		return "", InvalidArgument("message", "you can't kick yourself")
+	} else if !IsMemberLocked(target, g) {
+		lock.Unlock()
+		return "", StatusError(codes.NotFound, target+" isn't in "+g, &errdetails.ResourceInfo{ResourceType: "member", ResourceName: target, Owner: g})
+	}
+
+	BroadcastLocked(g, pb.ChatMessage{Sender: target, Receiver: g, Message: target + " left chat!\n"})
+	RemoveClientFromGroup(target, g)
+	if c, ok := clients[target]; ok {
+		Deliver(c, pb.ChatMessage{Sender: u, Receiver: target, Kind: pb.Kind_DIRECT, Message: "You were kicked from " + g + ".\n"})
+	}
+	lock.Unlock()
+
+	RequestRekey(g)
+
+	return "Kicked " + target + " from " + g + ".", nil
+}
+
+// StatsCommand describes how busy the server is.
+// It returns the description and an error.
+func StatsCommand(u string, g string, args string) (string, error) {
+
+	lock.RLock()
+	defer lock.RUnlock()
+
+	stored := 0
+	for _, grp := range groups {
+		stored += len(grp.history)
+	}
+
+	return fmt.Sprintf("%d user(s) online, %d group(s), %d message(s) stored. Up for %s.", len(clients), len(groups), stored, Uptime()), nil
+}
+
+// UptimeCommand tells how long the server has been running.
+// It returns the response and an error.
+func UptimeCommand(u string, g string, args string) (string, error) {
+
+	return "Up for " + Uptime() + " since " + started.Format(time.RFC1123) + ".", nil
+}
+
+// Uptime formats how long the server has been running.
+// It returns the uptime, e.g. "3h2m1s".
+func Uptime() string {
+
+	return time.Since(started).Round(time.Second).String()
+}
+
+func init() {
+
+	RegisterCommand(&Command{Name: "help", Help: "Lists the server's commands.", Handler: HelpCommand})
+	RegisterCommand(&Command{Name: "who", Args: "[group]", Help: "Lists the members of a group, or everyone online outside a group.", Handler: WhoCommand})
+	RegisterCommand(&Command{Name: "topic", Args: "[topic]", Help: "Shows the group's topic, or sets it.", Handler: TopicCommand})
+	RegisterCommand(&Command{Name: "kick", Args: "<user>", Help: "Removes someone from the group. Only its moderator can.", Handler: KickCommand})
+	RegisterCommand(&Command{Name: "stats", Help: "Shows how many people, groups and messages the server has.", Handler: StatsCommand})
+	RegisterCommand(&Command{Name: "uptime", Help: "Shows how long the server has been running.", Handler: UptimeCommand})
+}
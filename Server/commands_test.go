@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/taylorflatt/go-chat"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestKick(t *testing.T) {
+
+	ts := startServer(t)
+	alice := ts.member(t, "alice", "general", true)
+	bob := ts.member(t, "bob", "random", true)
+	if _, err := bob.Client().JoinGroup(context.Background(), &pb.GroupInfo{Client: "bob", GroupName: "general"}); err != nil {
+		t.Fatalf("bob joining general: %v", err)
+	}
+
+	alice.Command("/kick bob")
+	expect(t, alice, "the kick", func(m pb.ChatMessage) bool {
+		return m.Kind == pb.Kind_COMMAND_RESULT && m.Message == "Kicked bob from general."
+	})
+	expect(t, bob, "the notice", func(m pb.ChatMessage) bool {
+		return m.Kind == pb.Kind_DIRECT && m.Message == "You were kicked from general.\n"
+	})
+
+	// Bob is only taken out of the group he was kicked from.
+	if got := members(t, alice, "general"); len(got) != 1 || got[0] != "alice" {
+		t.Errorf("the members of general are %v, want [alice]", got)
+	}
+	if got := members(t, bob, "random"); len(got) != 1 || got[0] != "bob" {
+		t.Errorf("the members of random are %v, want [bob]", got)
+	}
+}
+
+func TestCommandsNeedOwnSession(t *testing.T) {
+
+	ts := startServer(t)
+	ts.member(t, "alice", "general", true)
+	bob := ts.member(t, "bob", "general", false)
+
+	other := ts.connect(t).Client()
+	_, err := other.RunCommand(context.Background(), &pb.ChatMessage{Sender: "alice", Receiver: "general", Message: "/kick bob"})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("running a command as someone else got %v, want %v", err, codes.Unauthenticated)
+	}
+
+	stream, err := other.RouteChat(context.Background())
+	if err != nil {
+		t.Fatalf("opening a stream: %v", err)
+	}
+	stream.Send(&pb.ChatMessage{Sender: "alice"})
+	if _, err := stream.Recv(); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("opening a stream as someone else got %v, want %v", err, codes.Unauthenticated)
+	}
+
+	if got := members(t, bob, "general"); len(got) != 2 {
+		t.Errorf("the members of general are %v, want alice and bob", got)
+	}
+}
+
+func TestTopicNeedsOwnSession(t *testing.T) {
+
+	ts := startServer(t)
+	alice := ts.member(t, "alice", "general", true)
+	bob := ts.member(t, "bob", "general", false)
+	ctx := context.Background()
+
+	_, err := bob.Client().SetTopic(ctx, &pb.Topic{Client: "alice", GroupName: "general", Topic: "forged"})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("setting the topic as someone else got %v, want %v", err, codes.Unauthenticated)
+	}
+	_, err = bob.Client().RunCommand(ctx, &pb.ChatMessage{Sender: "alice", Receiver: "general", Message: "/topic forged"})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("running /topic as someone else got %v, want %v", err, codes.Unauthenticated)
+	}
+
+	if _, err := alice.Client().RunCommand(ctx, &pb.ChatMessage{Sender: "alice", Receiver: "general", Message: "/topic plans"}); err != nil {
+		t.Fatalf("setting the topic: %v", err)
+	}
+	topic, err := bob.Client().GetTopic(ctx, &pb.GroupInfo{GroupName: "general"})
+	if err != nil || topic.Topic != "plans" {
+		t.Errorf("the topic is %v, %v, want plans", topic, err)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"log"
+
+	pb "github.com/taylorflatt/go-chat"
+)
+
+// SendDirect delivers a private message to the client it was sent to and echoes it back to
+// the sender so it shows up in their chat too. Private messages aren't stored.
+// It returns an error.
+func SendDirect(msg pb.ChatMessage) error {
+
+	lock.RLock()
+	defer lock.RUnlock()
+
+	to, ok := clients[msg.Receiver]
+	if !ok {
+		return NotFound("client", msg.Receiver)
+	}
+
+	log.Print("[SendDirect]: Client " + msg.Sender + " sent " + msg.Receiver + " a private message")
+
+	Deliver(to, msg)
+	if from, ok := clients[msg.Sender]; ok && from != to {
+		Deliver(from, msg)
+	}
+
+	return nil
+}
+
+// ClientName gets the current name of client c, which changes if they rename themselves.
+// It returns the name.
+func ClientName(c *Client) string {
+
+	lock.RLock()
+	defer lock.RUnlock()
+
+	return c.name
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	pb "github.com/taylorflatt/go-chat"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+)
+
+// The largest file that will be accepted and the size of the chunks files are sent back in.
+const (
+	maxFileSize = 10 << 20
+	chunkSize   = 32 << 10
+)
+
+// Where uploaded files are kept and how many bytes of them are kept at most.
+var (
+	fileDir        = "files"
+	maxFileStorage = uint64(1 << 30)
+)
+
+var files = make(map[string]*pb.FileInfo)
+var fileStorage uint64 // The size of every file in files.
+
+// NewFileID picks a random id for a file that no other file has. Ids are random rather than
+// counted so that a restarted server doesn't hand out the ids of files already on disk. The
+// lock must be held.
+// It returns the id and an error.
+func NewFileID() (string, error) {
+
+	for {
+		b := make([]byte, 8)
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+
+		id := hex.EncodeToString(b)
+		if _, err := os.Stat(filepath.Join(fileDir, id)); files[id] == nil && os.IsNotExist(err) {
+			return id, nil
+		}
+	}
+}
+
+// LoadFileStorage counts the files left in fileDir by an earlier run towards maxFileStorage,
+// since they take up the room even though they are no longer shared. Uploads that didn't
+// finish are removed.
+// It returns an error.
+func LoadFileStorage() error {
+
+	entries, err := os.ReadDir(fileDir)
+	if err != nil {
+		return err
+	}
+
+	var total uint64
+	for _, e := range entries {
+		if !e.Type().IsRegular() {
+			continue
+		} else if strings.HasPrefix(e.Name(), "upload-") {
+			os.Remove(filepath.Join(fileDir, e.Name()))
+			continue
+		}
+
+		info, err := e.Info()
+		if err != nil {
+			return err
+		}
+		total += uint64(info.Size())
+	}
+
+	lock.Lock()
+	fileStorage = total
+	lock.Unlock()
+
+	log.Printf("[LoadFileStorage]: %d bytes of files are already stored", total)
+
+	return nil
+}
+
+// DeleteGroupFilesLocked removes the files shared with group g once it is gone, so they don't
+// go on taking up room or become visible to a new group of the same name. The lock must be
+// held.
+// It doesn't return anything.
+func DeleteGroupFilesLocked(g string) {
+
+	for id, f := range files {
+		if f.GroupName != g {
+			continue
+		}
+
+		if err := os.Remove(filepath.Join(fileDir, id)); err != nil && !os.IsNotExist(err) {
+			log.Print("[DeleteGroupFilesLocked]: Couldn't remove file " + id + ": " + err.Error())
+		}
+		delete(files, id)
+		fileStorage -= f.Size
+	}
+}
+
+// StorageFull creates the error for a file of size bytes that there is no room left for.
+// It returns a ResourceExhausted error.
+func StorageFull(size uint64) error {
+
+	return StatusError(codes.ResourceExhausted, "the server doesn't have room for more files",
+		&errdetails.QuotaFailure{Violations: []*errdetails.QuotaFailure_Violation{{Subject: "files", Description: strconv.FormatUint(size, 10) + " bytes over the limit"}}})
+}
+
+// SaveUpload copies the contents of an upload into a temporary file in fileDir, stopping if the
+// file grows past maxFileSize. The first chunk has already been received.
+// It returns the temporary file's path, the file's size, its hex encoded SHA-256 checksum and
+// an error.
+func SaveUpload(stream pb.Chat_UploadFileServer, first *pb.FileChunk) (string, uint64, string, error) {
+
+	tmp, err := os.CreateTemp(fileDir, "upload-")
+	if err != nil {
+		return "", 0, "", err
+	}
+	defer tmp.Close()
+
+	h := sha256.New()
+	w := io.MultiWriter(tmp, h)
+
+	var size uint64
+	for chunk := first; ; {
+		size += uint64(len(chunk.Data))
+		if size > maxFileSize {
+			os.Remove(tmp.Name())
+			return "", 0, "", InvalidArgument("data", "files can't be larger than "+strconv.Itoa(maxFileSize>>20)+"MB")
+		}
+
+		if _, err := w.Write(chunk.Data); err != nil {
+			os.Remove(tmp.Name())
+			return "", 0, "", err
+		}
+
+		chunk, err = stream.Recv()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			os.Remove(tmp.Name())
+			return "", 0, "", err
+		}
+	}
+
+	return tmp.Name(), size, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// UploadFile receives a file from a client in chunks, stores it on disk and announces it to the
+// group it was shared with. The first chunk must describe the file. If it includes a checksum
+// the upload is rejected unless the received contents match it. Uploads count towards the
+// caller's call limit, and once maxFileStorage bytes are stored no more are accepted.
+// It returns an error.
+func (s *server) UploadFile(stream pb.Chat_UploadFileServer) error {
+
+	if err := AllowCall(stream.Context()); err != nil {
+		return err
+	}
+
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+
+	in := first.Info
+	if err := Sanitize(first); err != nil {
+		return err
+	}
+	if in == nil || filepath.Base(in.Name) == "." || filepath.Base(in.Name) == "/" {
+		return InvalidArgument("info", "the first chunk must name the file being sent")
+	} else if err := Authenticate(stream.Context(), in.Client); err != nil {
+		return err
+	} else if !IsMember(in.Client, in.GroupName) {
+		return PermissionDenied(reasonNotMember, "the client "+in.Client+" isn't in the group "+in.GroupName)
+	} else if IsEncrypted(in.GroupName) {
+		return FailedPrecondition("group", in.GroupName, "files can't be shared in encrypted groups")
+	} else if in.Size > maxFileSize {
+		return InvalidArgument("info.size", "files can't be larger than "+strconv.Itoa(maxFileSize>>20)+"MB")
+	}
+
+	lock.RLock()
+	over := fileStorage + in.Size
+	lock.RUnlock()
+	if over > maxFileStorage {
+		return StorageFull(over - maxFileStorage)
+	}
+
+	tmp, size, sum, err := SaveUpload(stream, first)
+	if err != nil {
+		return err
+	}
+
+	if in.Sha256 != "" && in.Sha256 != sum {
+		os.Remove(tmp)
+		return InvalidArgument("info.sha256", "the file's checksum doesn't match what was received")
+	}
+
+	// The room and the id are taken in one step, so uploads finishing together can't both
+	// take the last of the room or the same id.
+	lock.Lock()
+	if over := fileStorage + size; over > maxFileStorage {
+		lock.Unlock()
+		os.Remove(tmp)
+		return StorageFull(over - maxFileStorage)
+	}
+
+	id, err := NewFileID()
+	if err == nil {
+		err = os.Rename(tmp, filepath.Join(fileDir, id))
+	}
+	if err != nil {
+		lock.Unlock()
+		os.Remove(tmp)
+		return err
+	}
+
+	f := &pb.FileInfo{Id: id, Name: CleanText(filepath.Base(in.Name)), Size: size, Sha256: sum, Client: in.Client, GroupName: in.GroupName}
+	files[id] = f
+	fileStorage += size
+	lock.Unlock()
+
+	log.Printf("[UploadFile]: %s shared %s (%d bytes) with %s as file %s", f.Client, f.Name, f.Size, f.GroupName, f.Id)
+
+	Broadcast(f.GroupName, pb.ChatMessage{Sender: f.Client, Receiver: f.GroupName, Message: f.Name, Kind: pb.Kind_ATTACHMENT, File: f})
+	return stream.SendAndClose(f)
+}
+
+// DownloadFile sends a stored file to a member of the group it was shared with, from the
+// member's own session. The first chunk describes the file so the client can check the
+// checksum once it has everything.
+// It returns an error.
+func (s *server) DownloadFile(in *pb.FileRequest, stream pb.Chat_DownloadFileServer) error {
+
+	if err := Authenticate(stream.Context(), in.Client); err != nil {
+		return err
+	}
+
+	lock.RLock()
+	f, ok := files[in.Id]
+	lock.RUnlock()
+
+	if !ok {
+		return NotFound("file", in.Id)
+	} else if !IsMember(in.Client, f.GroupName) {
+		return PermissionDenied(reasonNotMember, "the client "+in.Client+" isn't in the group "+f.GroupName)
+	}
+
+	r, err := os.Open(filepath.Join(fileDir, f.Id))
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	log.Printf("[DownloadFile]: Sending file %s to %s", f.Id, in.Client)
+
+	if err := stream.Send(&pb.FileChunk{Info: f}); err != nil {
+		return err
+	}
+
+	buf := make([]byte, chunkSize)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if serr := stream.Send(&pb.FileChunk{Data: buf[:n]}); serr != nil {
+				return serr
+			}
+		}
+
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
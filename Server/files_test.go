@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	pb "github.com/taylorflatt/go-chat"
+	"github.com/taylorflatt/go-chat/chatclient"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// upload shares a file called name holding data with the group s is in.
+// It returns what the server stored and an error.
+func upload(t *testing.T, s *chatclient.Session, name string, data []byte) (*pb.FileInfo, error) {
+
+	t.Helper()
+
+	stream, err := s.Client().UploadFile(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	info := &pb.FileInfo{Name: name, Size: uint64(len(data)), Client: s.User(), GroupName: s.Group()}
+	if err := stream.Send(&pb.FileChunk{Info: info, Data: data}); err != nil {
+		return nil, err
+	}
+
+	return stream.CloseAndRecv()
+}
+
+// useFileDir keeps the files uploaded during the test in a directory of its own, allowing at
+// most max bytes of them. It is called before startServer so the server is stopped before
+// they are put back.
+// It doesn't return anything.
+func useFileDir(t *testing.T, max uint64) {
+
+	savedDir, savedMax := fileDir, maxFileStorage
+	fileDir, maxFileStorage = t.TempDir(), max
+	t.Cleanup(func() { fileDir, maxFileStorage = savedDir, savedMax })
+}
+
+func TestFileIDs(t *testing.T) {
+
+	useFileDir(t, 1<<20)
+	ts := startServer(t)
+	alice := ts.member(t, "alice", "general", true)
+
+	ids := make(map[string]bool)
+	for i := 0; i < 3; i++ {
+		f, err := upload(t, alice, "notes.txt", []byte("hello"))
+		if err != nil {
+			t.Fatalf("uploading: %v", err)
+		} else if len(f.Id) != 16 || ids[f.Id] {
+			t.Errorf("got the id %q, want a new random one", f.Id)
+		}
+		ids[f.Id] = true
+	}
+}
+
+func TestFileStorageLimit(t *testing.T) {
+
+	useFileDir(t, 100)
+	ts := startServer(t)
+	alice := ts.member(t, "alice", "general", true)
+
+	if _, err := upload(t, alice, "a.txt", make([]byte, 60)); err != nil {
+		t.Fatalf("uploading: %v", err)
+	}
+	_, err := upload(t, alice, "b.txt", make([]byte, 60))
+	checkStatus(t, "uploading past the limit", err, codes.ResourceExhausted, "The server doesn't have room for more files.")
+
+	lock.RLock()
+	stored := fileStorage
+	lock.RUnlock()
+	if stored != 60 {
+		t.Errorf("%d bytes are stored, want 60", stored)
+	}
+}
+
+func TestUploadRateLimit(t *testing.T) {
+
+	useFileDir(t, 1<<20)
+	ts := startServer(t)
+	alice := ts.member(t, "alice", "general", true)
+	limits.CallRate, limits.CallBurst = 0.5, 1
+
+	if _, err := upload(t, alice, "a.txt", []byte("a")); err != nil {
+		t.Fatalf("uploading: %v", err)
+	}
+	_, err := upload(t, alice, "b.txt", []byte("b"))
+	checkStatus(t, "uploading too often", err, codes.ResourceExhausted, "You're doing that too often. Try again in 2s.")
+	if _, ok := Detail(err).(*errdetails.RetryInfo); !ok {
+		t.Errorf("uploading too often has the details %v, want a retry delay", Detail(err))
+	}
+}
+
+func TestDownloadNeedsOwnSession(t *testing.T) {
+
+	useFileDir(t, 1<<20)
+	ts := startServer(t)
+	alice := ts.member(t, "alice", "general", true)
+	bob := ts.member(t, "bob", "general", false)
+
+	f, err := upload(t, alice, "notes.txt", []byte("hello"))
+	if err != nil {
+		t.Fatalf("uploading: %v", err)
+	}
+
+	_, err = download(bob, "alice", f.Id)
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("downloading as someone else got %v, want %v", err, codes.Unauthenticated)
+	}
+	if data, err := download(bob, "bob", f.Id); err != nil || string(data) != "hello" {
+		t.Errorf("downloading got %q, %v, want \"hello\"", data, err)
+	}
+}
+
+func TestGroupFilesDeleted(t *testing.T) {
+
+	useFileDir(t, 1<<20)
+	ts := startServer(t)
+	alice := ts.member(t, "alice", "general", true)
+	ts.member(t, "bob", "random", true)
+
+	f, err := upload(t, alice, "notes.txt", []byte("hello"))
+	if err != nil {
+		t.Fatalf("uploading: %v", err)
+	}
+	g, err := upload(t, ts.member(t, "carol", "random", false), "plans.txt", []byte("plans"))
+	if err != nil {
+		t.Fatalf("uploading: %v", err)
+	}
+
+	// The last member leaving deletes general, and an operator deletes random.
+	if err := alice.Leave(); err != nil {
+		t.Fatalf("leaving: %v", err)
+	}
+	if _, err := ts.admin(t).DeleteGroup(context.Background(), &pb.GroupInfo{GroupName: "random"}); err != nil {
+		t.Fatalf("deleting random: %v", err)
+	}
+
+	lock.RLock()
+	n, stored := len(files), fileStorage
+	lock.RUnlock()
+	if n != 0 || stored != 0 {
+		t.Errorf("%d files of %d bytes are kept, want none", n, stored)
+	}
+	for _, id := range []string{f.Id, g.Id} {
+		if _, err := os.Stat(filepath.Join(fileDir, id)); !os.IsNotExist(err) {
+			t.Errorf("file %s is still on disk: %v", id, err)
+		}
+	}
+
+	// A new group of the same name doesn't get the old group's files.
+	if err := alice.Create("general", false); err != nil {
+		t.Fatalf("creating general again: %v", err)
+	}
+	if err := alice.Join("general"); err != nil {
+		t.Fatalf("joining general again: %v", err)
+	}
+	_, err = download(alice, "alice", f.Id)
+	checkStatus(t, "downloading a deleted group's file", err, codes.NotFound, "The file \""+f.Id+"\" doesn't exist.")
+}
+
+func TestLoadFileStorage(t *testing.T) {
+
+	useFileDir(t, 1<<20)
+	startServer(t)
+
+	for name, size := range map[string]int{"0123456789abcdef": 10, "fedcba9876543210": 20, "upload-123": 40} {
+		if err := os.WriteFile(filepath.Join(fileDir, name), make([]byte, size), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := LoadFileStorage(); err != nil {
+		t.Fatalf("loading: %v", err)
+	}
+
+	lock.RLock()
+	stored := fileStorage
+	lock.RUnlock()
+	if stored != 30 {
+		t.Errorf("%d bytes are stored, want 30", stored)
+	}
+	if _, err := os.Stat(filepath.Join(fileDir, "upload-123")); !os.IsNotExist(err) {
+		t.Errorf("the unfinished upload is still on disk: %v", err)
+	}
+}
+
+// download fetches file id through s, as client n.
+// It returns the file's contents and an error.
+func download(s *chatclient.Session, n string, id string) ([]byte, error) {
+
+	stream, err := s.Client().DownloadFile(context.Background(), &pb.FileRequest{Client: n, Id: id})
+	if err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			return data, nil
+		} else if err != nil {
+			return nil, err
+		}
+		data = append(data, chunk.Data...)
+	}
+}
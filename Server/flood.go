@@ -0,0 +1,342 @@
+package main
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	pb "github.com/taylorflatt/go-chat"
+	context "golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// Limits is how fast clients may send messages and make calls. A rate of 0 turns a limit off.
+type Limits struct {
+	MessageRate  float64 // Messages, including commands, each client may send per second.
+	MessageBurst int
+	GroupRate    float64 // Messages each group may be sent per second by all its members together.
+	GroupBurst   int
+	CallRate     float64 // Calls to Register and CreateGroup each address may make per second.
+	CallBurst    int
+}
+
+// Check makes sure each limit that is turned on can let something through.
+// It returns an error naming the first limit that can't.
+func (l Limits) Check() error {
+
+	for _, c := range []struct {
+		name  string
+		rate  float64
+		burst int
+	}{
+		{"message", l.MessageRate, l.MessageBurst},
+		{"group", l.GroupRate, l.GroupBurst},
+		{"call", l.CallRate, l.CallBurst},
+	} {
+		if c.rate < 0 {
+			return errors.New("the " + c.name + " rate can't be negative")
+		} else if c.rate > 0 && c.burst < 1 {
+			return errors.New("the " + c.name + " burst must be at least 1 while the " + c.name + " rate is set")
+		}
+	}
+
+	return nil
+}
+
+// limits are the limits in force, set by the server's flags. Clients and groups get theirs when
+// they are created.
+var limits = Limits{
+	MessageRate:  5,
+	MessageBurst: 20,
+	GroupRate:    50,
+	GroupBurst:   100,
+	CallRate:     2,
+	CallBurst:    20,
+}
+
+// How long a client is muted for going over a message limit. The mute doubles each time they go
+// over it again, up to muteMax, and starts over once they haven't been muted for muteReset.
+var (
+	muteBase  = 10 * time.Second
+	muteMax   = 10 * time.Minute
+	muteReset = 10 * time.Minute
+)
+
+// forwardedFor is the metadata key the server's gateways put the address of the client they
+// stand in for under, since their calls all come from the server itself. They mark the call
+// with gatewayKey under gatewayHeader, and the address is only trusted on calls marked so.
+const (
+	forwardedFor  = "x-forwarded-for"
+	gatewayHeader = "x-chat-gateway"
+)
+
+// gatewayKey is the key that marks calls made by the server's own gateways. It is picked when
+// the server starts, so nobody else can know it.
+var gatewayKey string
+
+func init() {
+
+	k, err := NewToken()
+	if err != nil {
+		panic("couldn't pick the gateway key: " + err.Error())
+	}
+	gatewayKey = k
+}
+
+// maxCallLimiters is how many addresses are tracked before those that are back within their
+// limits are forgotten.
+const maxCallLimiters = 4096
+
+var callLimitersLock = &sync.Mutex{}
+var callLimiters = make(map[string]*Limiter)
+
+// Flood tracks how fast a client sends messages and mutes them when they send too fast.
+type Flood struct {
+	mu      sync.Mutex
+	limiter *Limiter  // Nil if messages aren't limited.
+	strikes int       // How many times the client has been muted since they last kept to the limits.
+	until   time.Time // When the latest mute ends.
+}
+
+// NewFlood creates the flood tracking for a new client under the current limits.
+// It returns the tracking.
+func NewFlood() *Flood {
+
+	f := &Flood{}
+	if limits.MessageRate > 0 {
+		f.limiter = NewLimiter(limits.MessageRate, limits.MessageBurst)
+	}
+
+	return f
+}
+
+// NewGroupLimiter creates the limiter for a new group under the current limits.
+// It returns the limiter, or nil if groups aren't limited.
+func NewGroupLimiter() *Limiter {
+
+	if limits.GroupRate <= 0 {
+		return nil
+	}
+
+	return NewLimiter(limits.GroupRate, limits.GroupBurst)
+}
+
+// Allow checks whether the client may send msg: they mustn't be muted, and both they and the
+// group it is for must be within their limits. A client that goes over their own limit is
+// muted. A busy group isn't the fault of whoever happens to send next, so going over its limit
+// only drops the message, without using up any of the client's own limit.
+// It returns why the message isn't allowed and whether it is.
+func (f *Flood) Allow(msg pb.ChatMessage) (string, bool) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := time.Now()
+	if now.Before(f.until) {
+		return "You're muted for another " + Seconds(f.until.Sub(now)) + " for sending too fast.", false
+	}
+
+	if f.limiter == nil || f.limiter.Ready() {
+		if l := GroupLimiter(msg); l != nil && !l.Allow() {
+			return msg.Receiver + " is getting messages too fast, try again in " + Seconds(l.Wait()) + ".", false
+		}
+		if f.limiter != nil {
+			f.limiter.Allow()
+		}
+		return "", true
+	}
+
+	if now.Sub(f.until) > muteReset {
+		f.strikes = 0
+	}
+	f.strikes++
+
+	d := muteBase
+	for i := 1; i < f.strikes && d < muteMax; i++ {
+		d *= 2
+	}
+	if d > muteMax {
+		d = muteMax
+	}
+	f.until = now.Add(d)
+
+	return "You're sending messages too fast, so you're muted for " + Seconds(d) + ".", false
+}
+
+// GroupLimiter finds the limiter of the group msg is sent to. Private messages and commands
+// aren't sent to a group.
+// It returns the limiter, or nil if there isn't one.
+func GroupLimiter(msg pb.ChatMessage) *Limiter {
+
+	if msg.Kind == pb.Kind_DIRECT || msg.Kind == pb.Kind_COMMAND {
+		return nil
+	}
+
+	lock.RLock()
+	defer lock.RUnlock()
+
+	if g, ok := groups[msg.Receiver]; ok {
+		return g.limiter
+	}
+
+	return nil
+}
+
+// Seconds formats d in whole seconds, rounded up.
+// It returns the duration, e.g. "10s".
+func Seconds(d time.Duration) string {
+
+	return ((d + time.Second - 1) / time.Second * time.Second).String()
+}
+
+// RateLimited creates the event telling a client that msg was dropped and why.
+// It returns the event.
+func RateLimited(u string, msg pb.ChatMessage, why string) *pb.ChatMessage {
+
+	return &pb.ChatMessage{Sender: u, Receiver: msg.Receiver, Kind: pb.Kind_RATE_LIMITED, Message: why}
+}
+
+// AllowCall checks whether the caller of a limited RPC, such as Register, is within the limit
+// for its address.
+// It returns a ResourceExhausted error, saying when to try again, if it isn't.
+func AllowCall(ctx context.Context) error {
+
+	if limits.CallRate <= 0 {
+		return nil
+	}
+
+	a := CallerAddress(ctx)
+
+	callLimitersLock.Lock()
+	l, ok := callLimiters[a]
+	if !ok {
+		if len(callLimiters) >= maxCallLimiters {
+			for k, v := range callLimiters {
+				if v.Full() {
+					delete(callLimiters, k)
+				}
+			}
+		}
+		l = NewLimiter(limits.CallRate, limits.CallBurst)
+		callLimiters[a] = l
+	}
+	callLimitersLock.Unlock()
+
+	if !l.Allow() {
+		return ResourceExhausted(l.Wait())
+	}
+
+	return nil
+}
+
+// CallerAddress finds the host a call came from. Calls the server's gateways make on behalf of
+// their clients come from the server itself, so for those it is the address they forward.
+// Anyone else's is stripped before the call is handled, by StripForwarding.
+// It returns the address, or an empty string if it isn't known.
+func CallerAddress(ctx context.Context) string {
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get(forwardedFor)) > 0 {
+		return Host(md.Get(forwardedFor)[0])
+	}
+
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	return Host(p.Addr.String())
+}
+
+// FromGateway checks whether a call was made by one of the server's gateways: it has to come
+// from this machine and carry gatewayKey.
+// It returns a bool value.
+func FromGateway(ctx context.Context) bool {
+
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return false
+	} else if ip := net.ParseIP(Host(p.Addr.String())); ip == nil || !ip.IsLoopback() {
+		return false
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	k := md.Get(gatewayHeader)
+
+	return len(k) == 1 && gatewayKey != "" && subtle.ConstantTimeCompare([]byte(k[0]), []byte(gatewayKey)) == 1
+}
+
+// StripForwarding removes the forwarded address and gateway mark from a call that didn't come
+// from one of the server's gateways, so a client can't pick the address its limits apply to.
+// It returns the context to handle the call with.
+func StripForwarding(ctx context.Context) context.Context {
+
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok || (len(md.Get(forwardedFor)) == 0 && len(md.Get(gatewayHeader)) == 0) || FromGateway(ctx) {
+		return ctx
+	}
+
+	md = md.Copy()
+	md.Delete(forwardedFor)
+	md.Delete(gatewayHeader)
+
+	return metadata.NewIncomingContext(ctx, md)
+}
+
+// StripForwardingUnary applies StripForwarding to every call.
+// It returns the response and an error.
+func StripForwardingUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+
+	return handler(StripForwarding(ctx), req)
+}
+
+// strippedStream is a stream whose context has been through StripForwarding.
+type strippedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context gets the stream's context.
+// It returns the context.
+func (s *strippedStream) Context() context.Context {
+
+	return s.ctx
+}
+
+// StripForwardingStream applies StripForwarding to every stream.
+// It returns an error.
+func StripForwardingStream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+
+	return handler(srv, &strippedStream{ServerStream: ss, ctx: StripForwarding(ss.Context())})
+}
+
+// Forwarded marks the outgoing call ctx as one a gateway makes for a client at addr.
+// It returns the context to make the call with.
+func Forwarded(ctx context.Context, addr string) context.Context {
+
+	return metadata.AppendToOutgoingContext(ctx, forwardedFor, addr, gatewayHeader, gatewayKey)
+}
+
+// Host strips the port from addr, if it has one.
+// It returns the host.
+func Host(addr string) string {
+
+	if h, _, err := net.SplitHostPort(addr); err == nil {
+		return h
+	}
+
+	return addr
+}
+
+// ForwardFor adds the address addr of the client a gateway stands in for to the calls it makes,
+// so that it is the client's address that limits apply to.
+// It returns the dial option.
+func ForwardFor(addr string) grpc.DialOption {
+
+	return grpc.WithUnaryInterceptor(func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(Forwarded(ctx, addr), method, req, reply, cc, opts...)
+	})
+}
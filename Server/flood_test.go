@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/taylorflatt/go-chat"
+	"github.com/taylorflatt/go-chat/chatclient"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// rateLimited matches the event telling a client a message was dropped.
+func rateLimited(m pb.ChatMessage) bool {
+
+	return m.Kind == pb.Kind_RATE_LIMITED
+}
+
+func TestMessageRateLimit(t *testing.T) {
+
+	ts := startServer(t)
+	// The join notice is a message too.
+	limits.MessageRate, limits.MessageBurst = 0.001, 3
+	alice := ts.member(t, "alice", "general", true)
+
+	for _, text := range []string{"one", "two", "three", "four"} {
+		alice.Send(text)
+	}
+
+	// The echoes go through the group, so the notices may overtake them.
+	var echoes, notices []string
+	for len(echoes)+len(notices) < 4 {
+		m := expect(t, alice, "the echoes and notices", func(m pb.ChatMessage) bool {
+			return m.Kind == pb.Kind_RATE_LIMITED || m.Sender == "alice" && m.Message != "joined chat!\n"
+		})
+		if m.Kind == pb.Kind_RATE_LIMITED {
+			notices = append(notices, m.Message)
+		} else {
+			echoes = append(echoes, m.Message)
+		}
+	}
+
+	if strings.Join(echoes, "") != "one\ntwo\n" {
+		t.Errorf("the echoes were %q, want one and two", echoes)
+	}
+	if len(notices) != 2 || !strings.Contains(notices[0], "too fast, so you're muted for 10s") || !strings.Contains(notices[1], "muted for another") {
+		t.Errorf("the notices were %q", notices)
+	}
+}
+
+func TestMuteEscalates(t *testing.T) {
+
+	saved := muteBase
+	muteBase = 20 * time.Millisecond
+	t.Cleanup(func() { muteBase = saved })
+
+	f := &Flood{limiter: NewLimiter(0.001, 1)}
+	msg := pb.ChatMessage{Receiver: "general"}
+
+	if _, ok := f.Allow(msg); !ok {
+		t.Fatal("the first message wasn't allowed")
+	}
+
+	for _, want := range []time.Duration{20, 40, 80} {
+		time.Sleep(time.Until(f.until))
+
+		before := time.Now()
+		if _, ok := f.Allow(msg); ok {
+			t.Fatal("a message over the limit was allowed")
+		}
+		if got := f.until.Sub(before); got < want*time.Millisecond || got > (want+10)*time.Millisecond {
+			t.Errorf("muted for %s, want %dms", got, want)
+		}
+	}
+}
+
+func TestGroupRateLimit(t *testing.T) {
+
+	ts := startServer(t)
+	limits.GroupRate, limits.GroupBurst = 0.001, 3
+	alice := ts.member(t, "alice", "general", true)
+	bob := ts.member(t, "bob", "general", false)
+
+	alice.Send("first")
+	expect(t, bob, "the first message", func(m pb.ChatMessage) bool { return m.Message == "first\n" })
+
+	bob.Send("second")
+	m := expect(t, bob, "the group being busy", rateLimited)
+	if !strings.Contains(m.Message, "general is getting messages too fast") {
+		t.Errorf("got %+v", m)
+	}
+
+	// Bob didn't cause the flood, so he isn't muted for it and can send once the group can take
+	// messages again.
+	lock.Lock()
+	f := clients["bob"].flood
+	groups["general"].limiter = NewLimiter(1000, 1)
+	lock.Unlock()
+
+	f.mu.Lock()
+	strikes := f.strikes
+	f.mu.Unlock()
+	if strikes != 0 {
+		t.Errorf("bob has %d strike(s) for the group being busy, want none", strikes)
+	}
+
+	bob.Send("third")
+	expect(t, alice, "the third message", func(m pb.ChatMessage) bool { return m.Message == "third\n" })
+
+	// Private messages don't count against the group.
+	if err := bob.SendDirect("alice", "psst"); err != nil {
+		t.Fatalf("sending privately: %v", err)
+	}
+}
+
+func TestCallRateLimit(t *testing.T) {
+
+	ts := startServer(t)
+	limits.CallRate, limits.CallBurst = 0.001, 2
+	c := ts.connect(t).Client()
+
+	var header metadata.MD
+	for _, u := range []string{"alice", "bob"} {
+		if _, err := c.Register(context.Background(), &pb.ClientInfo{Sender: u}, grpc.Header(&header)); err != nil {
+			t.Fatalf("registering %s: %v", u, err)
+		}
+	}
+
+	_, err := c.Register(context.Background(), &pb.ClientInfo{Sender: "carol"})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("registering a third client got %v, want ResourceExhausted", err)
+	}
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), chatclient.TokenHeader, header.Get(chatclient.TokenHeader)[0])
+	_, err = c.CreateGroup(ctx, &pb.GroupInfo{Client: "bob", GroupName: "general"})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("creating a group got %v, want ResourceExhausted", err)
+	}
+}
+
+func TestForwardedAddress(t *testing.T) {
+
+	local := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 40000}
+	remote := &net.TCPAddr{IP: net.IPv4(198, 51, 100, 7), Port: 40000}
+
+	for _, c := range []struct {
+		what string
+		from net.Addr
+		md   metadata.MD
+		want string
+	}{
+		{"a call without a forwarded address", local, metadata.Pairs(), "127.0.0.1"},
+		{"a forwarded address without the gateway key", local, metadata.Pairs(forwardedFor, "203.0.113.9"), "127.0.0.1"},
+		{"a forwarded address with the wrong key", local, metadata.Pairs(forwardedFor, "203.0.113.9", gatewayHeader, "guess"), "127.0.0.1"},
+		{"a forwarded address with an empty key", local, metadata.Pairs(forwardedFor, "203.0.113.9", gatewayHeader, ""), "127.0.0.1"},
+		{"a gateway's call", local, metadata.Pairs(forwardedFor, "203.0.113.9:5000", gatewayHeader, gatewayKey), "203.0.113.9"},
+		{"the gateway key from another machine", remote, metadata.Pairs(forwardedFor, "203.0.113.9", gatewayHeader, gatewayKey), "198.51.100.7"},
+	} {
+		ctx := metadata.NewIncomingContext(peer.NewContext(context.Background(), &peer.Peer{Addr: c.from}), c.md)
+		if got := CallerAddress(StripForwarding(ctx)); got != c.want {
+			t.Errorf("%s is from %q, want %q", c.what, got, c.want)
+		}
+	}
+}
+
+func TestLimitsChecked(t *testing.T) {
+
+	for _, c := range []struct {
+		l  Limits
+		ok bool
+	}{
+		{Limits{}, true},
+		{limits, true},
+		{Limits{MessageRate: 5, MessageBurst: 1}, true},
+		{Limits{MessageRate: 5}, false},
+		{Limits{GroupRate: -1, GroupBurst: 10}, false},
+		{Limits{CallRate: 0.5, CallBurst: -2}, false},
+	} {
+		if err := c.l.Check(); (err == nil) != c.ok {
+			t.Errorf("checking %+v got %v, want ok %v", c.l, err, c.ok)
+		}
+	}
+
+	for _, c := range []struct {
+		rate  float64
+		burst int
+	}{{0, 1}, {-1, 1}, {1, 0}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("a limiter was made with the rate %v and burst %d", c.rate, c.burst)
+				}
+			}()
+			NewLimiter(c.rate, c.burst)
+		}()
+	}
+}
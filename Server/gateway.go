@@ -0,0 +1,335 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/taylorflatt/go-chat"
+	"github.com/taylorflatt/go-chat/chatclient"
+	context "golang.org/x/net/context"
+	"golang.org/x/net/websocket"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxGatewayFrame is the largest frame a WebSocket client can send, in bytes.
+const maxGatewayFrame = 64 << 10
+
+// gatewayTarget is where the gateway reaches the gRPC service. WebSocket clients go through
+// the same RPCs as every other client, over a connection back to this server.
+var gatewayTarget = "localhost" + port
+
+// gatewayOptions are added to the defaults when dialing gatewayTarget, e.g. so tests can reach a
+// server on an in-memory listener.
+var gatewayOptions []grpc.DialOption
+
+// GatewayRequest is a frame sent by a WebSocket client. Op says what to do:
+//
+//	login   {"user"}            Registers the user. It must come first.
+//	groups                      Lists the groups.
+//	members {"group"}           Lists the members of a group.
+//	create  {"group"}           Creates a group and joins it.
+//	join    {"group"}           Joins a group, leaving the one the user is in.
+//	leave                       Leaves the group the user is in.
+//	send    {"text", "parent_id"} Sends a message, as a reply in a thread if parent_id is set.
+//	me      {"text"}            Sends an action, like /me.
+//	direct  {"to", "text"}      Sends a private message.
+//	command {"text"}            Runs a server command, e.g. "/stats".
+type GatewayRequest struct {
+	Id       string `json:"id,omitempty"` // Echoed in the reply, to match them up.
+	Op       string `json:"op"`
+	User     string `json:"user,omitempty"`
+	Group    string `json:"group,omitempty"`
+	To       string `json:"to,omitempty"`
+	Text     string `json:"text,omitempty"`
+	ParentId uint64 `json:"parent_id,omitempty"`
+}
+
+// GatewayReply is a frame sent to a WebSocket client: "ok" or "error" in reply to a request,
+// or "event" for a message or event from the server.
+type GatewayReply struct {
+	Id      string         `json:"id,omitempty"`
+	Type    string         `json:"type"`
+	Op      string         `json:"op,omitempty"`
+	Error   string         `json:"error,omitempty"`
+	Code    codes.Code     `json:"code,omitempty"` // The gRPC status code of the error.
+	Groups  []GatewayGroup `json:"groups,omitempty"`
+	Members []string       `json:"members,omitempty"`
+	Event   *GatewayEvent  `json:"event,omitempty"`
+}
+
+// GatewayGroup describes a group in the reply to a groups request.
+type GatewayGroup struct {
+	Name      string `json:"name"`
+	Unread    uint64 `json:"unread"`
+	Encrypted bool   `json:"encrypted"`
+}
+
+// GatewayEvent is a message or event from the server.
+type GatewayEvent struct {
+	Id       uint64 `json:"id,omitempty"`
+	Group    string `json:"group"`
+	Sender   string `json:"sender"`
+	Kind     string `json:"kind"`
+	Message  string `json:"message,omitempty"`
+	ParentId uint64 `json:"parent_id,omitempty"`
+	Edited   bool   `json:"edited,omitempty"`
+	Deleted  bool   `json:"deleted,omitempty"`
+	Time     string `json:"time"`
+}
+
+// Gateway is a WebSocket client's connection to the chat service.
+type Gateway struct {
+	ws *websocket.Conn
+	mu sync.Mutex // Stops frames being written concurrently.
+	s  *chatclient.Session
+}
+
+// GatewayHandler serves the WebSocket gateway.
+// It returns the handler.
+func GatewayHandler() http.Handler {
+
+	return websocket.Server{Handler: HandleGateway, Handshake: CheckOrigin}
+}
+
+// CheckOrigin accepts WebSocket connections from pages served by this server and from
+// clients that don't send an Origin, such as scripts.
+// It returns an error if the connection isn't allowed.
+func CheckOrigin(c *websocket.Config, r *http.Request) error {
+
+	o := r.Header.Get("Origin")
+	if o == "" {
+		return nil
+	}
+
+	u, err := url.Parse(o)
+	if err != nil || u.Host != r.Host {
+		return errors.New("connections from " + o + " aren't allowed")
+	}
+
+	return nil
+}
+
+// HandleGateway reads the requests of a WebSocket client until it disconnects, which logs the
+// user out.
+// It doesn't return anything.
+func HandleGateway(ws *websocket.Conn) {
+
+	ws.MaxPayloadBytes = maxGatewayFrame
+	gw := &Gateway{ws: ws}
+	defer gw.Close()
+
+	for {
+		var req GatewayRequest
+		if err := websocket.JSON.Receive(ws, &req); err == io.EOF {
+			return
+		} else if err != nil {
+			log.Print("[HandleGateway]: Couldn't read a request: " + err.Error())
+			return
+		}
+
+		gw.Handle(req)
+	}
+}
+
+// Handle carries out a request and replies to it.
+// It doesn't return anything.
+func (gw *Gateway) Handle(req GatewayRequest) {
+
+	reply := GatewayReply{Id: req.Id, Type: "ok", Op: req.Op}
+
+	var err error
+	if gw.s == nil && req.Op != "login" {
+		err = status.Error(codes.FailedPrecondition, "log in first")
+	} else {
+		switch req.Op {
+		case "login":
+			err = gw.Login(req.User)
+		case "groups":
+			reply.Groups, err = gw.Groups()
+		case "members":
+			reply.Members, err = gw.Members(req.Group)
+		case "create":
+			if err = gw.s.Create(req.Group, false); err == nil {
+				err = gw.s.Join(req.Group)
+			}
+		case "join":
+			err = gw.Join(req.Group)
+		case "leave":
+			err = gw.s.Leave()
+		case "send":
+			err = gw.s.Reply(req.ParentId, req.Text)
+		case "me":
+			err = gw.s.Act(req.Text)
+		case "direct":
+			err = gw.s.SendDirect(req.To, req.Text)
+		case "command":
+			err = gw.s.Command(req.Text)
+		default:
+			err = InvalidArgument("op", "there is no op called \""+req.Op+"\"")
+		}
+	}
+
+	if err != nil {
+		st := status.Convert(err)
+		reply.Type = "error"
+		reply.Error = st.Message()
+		reply.Code = st.Code()
+	}
+
+	gw.Write(reply)
+}
+
+// Write sends r to the client.
+// It returns an error.
+func (gw *Gateway) Write(r GatewayReply) error {
+
+	gw.mu.Lock()
+	defer gw.mu.Unlock()
+
+	return websocket.JSON.Send(gw.ws, r)
+}
+
+// Login registers user u and starts passing the events the server sends them to the client.
+// It returns an error.
+func (gw *Gateway) Login(u string) error {
+
+	if gw.s != nil {
+		return status.Error(codes.FailedPrecondition, "already logged in as "+gw.s.User())
+	} else if strings.TrimSpace(u) == "" {
+		return InvalidArgument("user", "the user can't be empty")
+	}
+
+	s, err := chatclient.Connect(gatewayTarget, append([]grpc.DialOption{ForwardFor(gw.ws.Request().RemoteAddr)}, gatewayOptions...)...)
+	if err != nil {
+		return err
+	}
+
+	if err := s.Login(u); err != nil {
+		s.Close()
+		return err
+	}
+
+	gw.s = s
+	go gw.Forward(s)
+
+	log.Print("[Login]: " + u + " logged in through the WebSocket gateway")
+	return nil
+}
+
+// Forward passes the events of session s to the client until the session closes.
+// It doesn't return anything.
+func (gw *Gateway) Forward(s *chatclient.Session) {
+
+	for m := range s.Events {
+		if err := gw.Write(GatewayReply{Type: "event", Event: NewGatewayEvent(m)}); err != nil {
+			log.Print("[Forward]: Couldn't send an event to " + s.User() + ": " + err.Error())
+		}
+	}
+}
+
+// NewGatewayEvent converts a message or event from the server for a WebSocket client.
+// It returns the event.
+func NewGatewayEvent(m pb.ChatMessage) *GatewayEvent {
+
+	return &GatewayEvent{
+		Id:       m.Id,
+		Group:    m.Receiver,
+		Sender:   m.Sender,
+		Kind:     strings.ToLower(m.Kind.String()),
+		Message:  strings.TrimRight(m.Message, "\n"),
+		ParentId: m.ParentId,
+		Edited:   m.Edited,
+		Deleted:  m.Deleted,
+		Time:     time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+// Groups lists the groups on the server.
+// It returns the groups and an error.
+func (gw *Gateway) Groups() ([]GatewayGroup, error) {
+
+	l, err := gw.s.Client().GetGroupList(context.Background(), &pb.ClientInfo{Sender: gw.s.User()})
+	if err != nil {
+		return nil, err
+	}
+
+	gs := make([]GatewayGroup, len(l.Groups))
+	for i, g := range l.Groups {
+		gs[i].Name = g
+		if i < len(l.Unread) {
+			gs[i].Unread = l.Unread[i]
+		}
+		if i < len(l.Encrypted) {
+			gs[i].Encrypted = l.Encrypted[i]
+		}
+	}
+
+	return gs, nil
+}
+
+// Members lists the members of group g.
+// It returns the members and an error.
+func (gw *Gateway) Members(g string) ([]string, error) {
+
+	l, err := gw.s.Client().GetGroupClientList(context.Background(), &pb.GroupInfo{Client: gw.s.User(), GroupName: g})
+	if err != nil {
+		return nil, err
+	}
+
+	return l.Clients, nil
+}
+
+// Join joins group g.
+// It returns an error.
+func (gw *Gateway) Join(g string) error {
+
+	return JoinUnencrypted(gw.s, g, false)
+}
+
+// JoinUnencrypted joins session s to group g, creating g first if create is set and it doesn't
+// exist. Encrypted groups are refused: the messages of bridged clients would have to be
+// encrypted on the server, which defeats the point.
+// It returns an error.
+func JoinUnencrypted(s *chatclient.Session, g string, create bool) error {
+
+	l, err := s.Client().GetGroupList(context.Background(), &pb.ClientInfo{Sender: s.User()})
+	if err != nil {
+		return err
+	}
+
+	exists := false
+	for i, n := range l.Groups {
+		if n == g {
+			exists = true
+			if i < len(l.Encrypted) && l.Encrypted[i] {
+				return FailedPrecondition("group", g, g+" is end-to-end encrypted, which needs a client that can encrypt")
+			}
+		}
+	}
+
+	if !exists && create {
+		if err := s.Create(g, false); err != nil {
+			return err
+		}
+	}
+
+	return s.Join(g)
+}
+
+// Close logs the client out, if it logged in.
+// It doesn't return anything.
+func (gw *Gateway) Close() {
+
+	if gw.s != nil {
+		gw.s.Leave()
+		gw.s.Close()
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+
+	pb "github.com/taylorflatt/go-chat"
+	"golang.org/x/net/websocket"
+	"google.golang.org/grpc/codes"
+)
+
+// request sends req from the WebSocket client ws and waits for the reply to it.
+// It returns the reply.
+func request(t *testing.T, ws *websocket.Conn, req GatewayRequest) GatewayReply {
+
+	t.Helper()
+
+	if err := websocket.JSON.Send(ws, req); err != nil {
+		t.Fatalf("sending %s: %v", req.Op, err)
+	}
+
+	return expectFrame(t, ws, "the reply to "+req.Op, func(r GatewayReply) bool { return r.Id == req.Id && r.Type != "event" })
+}
+
+func TestGateway(t *testing.T) {
+
+	ts := startServer(t)
+	alice := ts.member(t, "alice", "general", true)
+	ws := ts.gateway(t)
+
+	if r := request(t, ws, GatewayRequest{Id: "1", Op: "groups"}); r.Type != "error" || r.Code != codes.FailedPrecondition {
+		t.Errorf("listing the groups before logging in got %+v", r)
+	}
+	if r := request(t, ws, GatewayRequest{Id: "2", Op: "login", User: "carol"}); r.Type != "ok" {
+		t.Fatalf("logging in got %+v", r)
+	}
+	if r := request(t, ws, GatewayRequest{Id: "3", Op: "join", Group: "general"}); r.Type != "ok" {
+		t.Fatalf("joining got %+v", r)
+	}
+	expect(t, alice, "carol's join notice", func(m pb.ChatMessage) bool { return m.Sender == "carol" && m.Message == "joined chat!\n" })
+
+	if r := request(t, ws, GatewayRequest{Id: "4", Op: "members", Group: "general"}); len(r.Members) != 2 {
+		t.Errorf("the members of general are %v, want alice and carol", r.Members)
+	}
+
+	alice.Send("hello")
+	ev := expectFrame(t, ws, "alice's message", func(r GatewayReply) bool { return r.Type == "event" && r.Event.Sender == "alice" })
+	if ev.Event.Group != "general" || ev.Event.Kind != "message" || ev.Event.Message != "hello" || ev.Event.Id == 0 {
+		t.Errorf("got the event %+v", ev.Event)
+	}
+
+	if r := request(t, ws, GatewayRequest{Id: "5", Op: "send", Text: "hi"}); r.Type != "ok" {
+		t.Errorf("sending got %+v", r)
+	}
+	expect(t, alice, "carol's message", func(m pb.ChatMessage) bool { return m.Sender == "carol" && m.Message == "hi\n" })
+
+	if r := request(t, ws, GatewayRequest{Id: "6", Op: "join", Group: "nowhere"}); r.Type != "error" || r.Code != codes.NotFound {
+		t.Errorf("joining a group that doesn't exist got %+v", r)
+	}
+
+	// Closing the socket logs carol out.
+	ws.Close()
+	eventually(t, "carol to be logged out", func() bool { return len(members(t, alice, "general")) == 1 })
+}
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"log"
+	"net"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/taylorflatt/go-chat"
+	"github.com/taylorflatt/go-chat/chatclient"
+	"golang.org/x/net/websocket"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// eventTimeout is how long a test client waits for a message before failing.
+const eventTimeout = 5 * time.Second
+
+// TestMain silences the server's logging, which is far too chatty for test output, unless the
+// tests are run with -v.
+func TestMain(m *testing.M) {
+
+	flag.Parse()
+	if !testing.Verbose() {
+		log.SetOutput(io.Discard)
+	}
+
+	os.Exit(m.Run())
+}
+
+// testServer is a Chat server running in the test process on an in-memory listener.
+type testServer struct {
+	lis *bufconn.Listener
+}
+
+// startServer starts a Chat server with no clients or groups, which is stopped when the test
+// finishes. It serves the Admin service too, on the same listener for simplicity. Rate limits
+// are off, since every test client calls from the same address, unless the test sets them
+// before creating any clients or groups.
+// It returns the server.
+func startServer(t *testing.T) *testServer {
+
+	t.Helper()
+	resetState()
+
+	saved := limits
+	limits = Limits{}
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(StripForwardingUnary, SanitizeUnary, AuthenticateUnary), grpc.ChainStreamInterceptor(StripForwardingStream))
+	pb.RegisterChatServer(srv, &server{})
+	pb.RegisterAdminServer(srv, &admin{})
+	go srv.Serve(lis)
+
+	t.Cleanup(func() {
+		srv.Stop()
+		resetState()
+		limits = saved
+	})
+
+	return &testServer{lis: lis}
+}
+
+// resetState throws away every client, group and file and the call limits, since the server keeps
+// them in globals.
+// It doesn't return anything.
+func resetState() {
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	clients = make(map[string]*Client)
+	groups = make(map[string]*Group)
+	files = make(map[string]*pb.FileInfo)
+	fileStorage = 0
+
+	callLimitersLock.Lock()
+	callLimiters = make(map[string]*Limiter)
+	callLimitersLock.Unlock()
+}
+
+// dial connects to ts over its in-memory listener.
+// It returns the connection and an error.
+func (ts *testServer) dial(ctx context.Context, _ string) (net.Conn, error) {
+
+	return ts.lis.DialContext(ctx)
+}
+
+// connect opens a session to ts that isn't logged in yet, closed when the test finishes.
+// It returns the session.
+func (ts *testServer) connect(t *testing.T) *chatclient.Session {
+
+	t.Helper()
+
+	s, err := chatclient.Connect("bufnet", grpc.WithContextDialer(ts.dial))
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	return s
+}
+
+// admin connects to the Admin service of ts.
+// It returns the client.
+func (ts *testServer) admin(t *testing.T) pb.AdminClient {
+
+	t.Helper()
+
+	conn, err := grpc.Dial("bufnet", grpc.WithInsecure(), grpc.WithContextDialer(ts.dial))
+	if err != nil {
+		t.Fatalf("connect to the Admin service: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return pb.NewAdminClient(conn)
+}
+
+// login opens a session to ts logged in as u.
+// It returns the session.
+func (ts *testServer) login(t *testing.T, u string) *chatclient.Session {
+
+	t.Helper()
+
+	s := ts.connect(t)
+	if err := s.Login(u); err != nil {
+		t.Fatalf("login %s: %v", u, err)
+	}
+
+	return s
+}
+
+// member logs in as u and joins group g, creating it first if create is set. The user's own
+// join notice is read off, so the session's next event is whatever comes after it.
+// It returns the session.
+func (ts *testServer) member(t *testing.T, u string, g string, create bool) *chatclient.Session {
+
+	t.Helper()
+
+	s := ts.login(t, u)
+	if create {
+		if err := s.Create(g, false); err != nil {
+			t.Fatalf("%s creating %s: %v", u, g, err)
+		}
+	}
+	if err := s.Join(g); err != nil {
+		t.Fatalf("%s joining %s: %v", u, g, err)
+	}
+	expect(t, s, u+"'s own join notice", func(m pb.ChatMessage) bool {
+		return m.Sender == u && m.Message == "joined chat!\n"
+	})
+
+	return s
+}
+
+// expect waits for s to receive a message that match accepts, skipping any others.
+// It returns the message.
+func expect(t *testing.T, s *chatclient.Session, what string, match func(pb.ChatMessage) bool) pb.ChatMessage {
+
+	t.Helper()
+
+	timeout := time.After(eventTimeout)
+	for {
+		select {
+		case m, ok := <-s.Events:
+			if !ok {
+				t.Fatalf("%s's stream closed while waiting for %s", s.User(), what)
+			} else if match(m) {
+				return m
+			}
+		case <-timeout:
+			t.Fatalf("%s didn't receive %s", s.User(), what)
+		}
+	}
+}
+
+// raw logs in as u and joins group g over a connection of its own, without a Session, so a
+// test can send what a Session never would. The stream carries the token the server gave it,
+// has already identified itself and is closed when the test finishes.
+// It returns the stream.
+func (ts *testServer) raw(t *testing.T, u string, g string, opts ...grpc.DialOption) pb.Chat_RouteChatClient {
+
+	t.Helper()
+
+	opts = append([]grpc.DialOption{grpc.WithInsecure(), grpc.WithContextDialer(ts.dial)}, opts...)
+	conn, err := grpc.Dial("bufnet", opts...)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	c := pb.NewChatClient(conn)
+	var header metadata.MD
+	if _, err := c.Register(context.Background(), &pb.ClientInfo{Sender: u}, grpc.Header(&header)); err != nil {
+		t.Fatalf("registering %s: %v", u, err)
+	}
+	ctx := metadata.AppendToOutgoingContext(context.Background(), chatclient.TokenHeader, header.Get(chatclient.TokenHeader)[0])
+	if _, err := c.JoinGroup(ctx, &pb.GroupInfo{Client: u, GroupName: g}); err != nil {
+		t.Fatalf("%s joining %s: %v", u, g, err)
+	}
+	stream, err := c.RouteChat(ctx)
+	if err != nil {
+		t.Fatalf("opening %s's stream: %v", u, err)
+	}
+	if err := stream.Send(&pb.ChatMessage{Sender: u}); err != nil {
+		t.Fatalf("identifying %s's stream: %v", u, err)
+	}
+
+	return stream
+}
+
+// expectRaw waits for stream to receive a message that match accepts, skipping any others.
+// It returns the message.
+func expectRaw(t *testing.T, stream pb.Chat_RouteChatClient, what string, match func(*pb.ChatMessage) bool) *pb.ChatMessage {
+
+	t.Helper()
+
+	found := make(chan *pb.ChatMessage, 1)
+	failed := make(chan error, 1)
+	go func() {
+		for {
+			m, err := stream.Recv()
+			if err != nil {
+				failed <- err
+				return
+			} else if match(m) {
+				found <- m
+				return
+			}
+		}
+	}()
+
+	select {
+	case m := <-found:
+		return m
+	case err := <-failed:
+		t.Fatalf("the stream closed while waiting for %s: %v", what, err)
+	case <-time.After(eventTimeout):
+		t.Fatalf("didn't receive %s", what)
+	}
+
+	return nil
+}
+
+// gateway serves the WebSocket gateway in front of ts and connects a WebSocket client to it,
+// which is closed when the test finishes.
+// It returns the client.
+func (ts *testServer) gateway(t *testing.T) *websocket.Conn {
+
+	t.Helper()
+
+	savedTarget, savedOptions := gatewayTarget, gatewayOptions
+	gatewayTarget, gatewayOptions = "bufnet", []grpc.DialOption{grpc.WithContextDialer(ts.dial)}
+	t.Cleanup(func() { gatewayTarget, gatewayOptions = savedTarget, savedOptions })
+
+	hs := httptest.NewServer(GatewayHandler())
+	t.Cleanup(hs.Close)
+
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(hs.URL, "http"), "", hs.URL)
+	if err != nil {
+		t.Fatalf("connect to the gateway: %v", err)
+	}
+	t.Cleanup(func() { ws.Close() })
+
+	return ws
+}
+
+// expectFrame waits for the WebSocket client ws to be sent a frame that match accepts, skipping
+// any others.
+// It returns the frame.
+func expectFrame(t *testing.T, ws *websocket.Conn, what string, match func(GatewayReply) bool) GatewayReply {
+
+	t.Helper()
+
+	ws.SetReadDeadline(time.Now().Add(eventTimeout))
+	for {
+		var r GatewayReply
+		if err := websocket.JSON.Receive(ws, &r); err != nil {
+			t.Fatalf("didn't receive %s: %v", what, err)
+		} else if match(r) {
+			return r
+		}
+	}
+}
+
+// members lists the members of group g as the server sees them.
+// It returns the members.
+func members(t *testing.T, s *chatclient.Session, g string) []string {
+
+	t.Helper()
+
+	l, err := s.Client().GetGroupClientList(context.Background(), &pb.GroupInfo{Client: s.User(), GroupName: g})
+	if err != nil {
+		t.Fatalf("listing the members of %s: %v", g, err)
+	}
+
+	return l.Clients
+}
+
+// groupNames lists the groups on the server.
+// It returns the groups.
+func groupNames(t *testing.T, s *chatclient.Session) []string {
+
+	t.Helper()
+
+	l, err := s.Client().GetGroupList(context.Background(), &pb.ClientInfo{Sender: s.User()})
+	if err != nil {
+		t.Fatalf("listing the groups: %v", err)
+	}
+
+	return l.Groups
+}
+
+// eventually retries cond until it holds, failing the test if it doesn't within eventTimeout.
+// Some changes, such as a client leaving when its session closes, aren't seen straight away.
+// It doesn't return anything.
+func eventually(t *testing.T, what string, cond func() bool) {
+
+	t.Helper()
+
+	deadline := time.Now().Add(eventTimeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
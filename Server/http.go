@@ -0,0 +1,38 @@
+package main
+
+import (
+	"embed"
+	"io/fs"
+	"log"
+	"net/http"
+)
+
+// web holds the page served at /, which chats through the WebSocket gateway.
+//
+//go:embed web
+var web embed.FS
+
+// ServeHTTP serves the server's HTTP endpoints, such as incoming webhooks, the WebSocket
+// gateway and the REST API, on addr.
+// It doesn't return anything.
+func ServeHTTP(addr string) {
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/hooks/", HandleIncomingHook)
+	mux.Handle("/ws", GatewayHandler())
+
+	rest, err := RESTHandler()
+	if err != nil {
+		log.Fatal(err)
+	}
+	mux.Handle("/v1/", rest)
+
+	page, err := fs.Sub(web, "web")
+	if err != nil {
+		log.Fatal(err)
+	}
+	mux.Handle("/", http.FileServer(http.FS(page)))
+
+	log.Print("[ServeHTTP]: Listening on " + addr)
+	log.Fatal(http.ListenAndServe(addr, mux))
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	pb "github.com/taylorflatt/go-chat"
+	context "golang.org/x/net/context"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+)
+
+// Limits on incoming webhooks.
+const (
+	maxHookBody    = 64 << 10 // The largest request body accepted, in bytes.
+	maxHookMessage = 4000     // The longest message that can be posted, in bytes.
+	hookRate       = 1        // Messages each hook can post per second once its burst is used up.
+	hookBurst      = 5        // Messages each hook can post at once.
+	defaultHook    = "webhook"
+)
+
+// Hook is an incoming webhook of a group.
+type Hook struct {
+	name    string   // The name its messages are shown as.
+	creator string   // The client who created it.
+	limiter *Limiter // Limits how fast messages can be posted through it.
+}
+
+// NewToken picks a random token for an incoming webhook or a client's session.
+// It returns the token and an error.
+func NewToken() (string, error) {
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// NameTakenLocked checks whether n is the name of a client or of an incoming webhook, since
+// messages posted by a hook are shown as coming from its name and members couldn't tell the
+// two apart. The lock must be held.
+// It returns true if the name is taken.
+func NameTakenLocked(n string) bool {
+
+	if _, ok := clients[n]; ok {
+		return true
+	}
+
+	for _, g := range groups {
+		for _, h := range g.hooks {
+			if h.name == n {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// ModeratedGroup finds group g, provided client c moderates it. The lock must be held.
+// It returns the group and an error.
+func ModeratedGroup(g string, c string) (*Group, error) {
+
+	grp, ok := groups[g]
+	if !ok {
+		return nil, NotFound("group", g)
+	} else if grp.owner != c {
+		return nil, PermissionDenied(reasonNotModerator, "only the moderator of "+g+" can manage its webhooks")
+	}
+
+	return grp, nil
+}
+
+// CreateIncomingHook creates an incoming webhook for a group, which posts messages as the
+// name given or "webhook". Only the group's moderator can create one, and not for an
+// encrypted group since the server can't encrypt the messages. The name can't be a client's,
+// and clients can't take it while the hook exists.
+// It returns the hook with its token and an error.
+func (s *server) CreateIncomingHook(ctx context.Context, in *pb.IncomingHook) (*pb.IncomingHook, error) {
+
+	n := strings.TrimSpace(in.Name)
+	if n == "" {
+		n = defaultHook
+	}
+
+	n, err := CheckName("name", "hook name", n, 1, true)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := NewToken()
+	if err != nil {
+		return nil, err
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	g, err := ModeratedGroup(in.GroupName, in.Client)
+	if err != nil {
+		return nil, err
+	} else if g.encrypted {
+		return nil, FailedPrecondition("group", g.name, "encrypted groups can't have webhooks")
+	} else if _, ok := clients[n]; ok {
+		return nil, AlreadyExists("client", n)
+	}
+
+	g.hooks[t] = &Hook{name: n, creator: in.Client, limiter: NewLimiter(hookRate, hookBurst)}
+	log.Print("[CreateIncomingHook]: " + in.Client + " added a webhook called " + n + " to " + g.name)
+
+	return &pb.IncomingHook{Client: in.Client, GroupName: g.name, Name: n, Token: t}, nil
+}
+
+// ListIncomingHooks lists the incoming webhooks of a group for its moderator.
+// It returns the hooks sorted by name and an error.
+func (s *server) ListIncomingHooks(ctx context.Context, in *pb.GroupInfo) (*pb.IncomingHookList, error) {
+
+	lock.RLock()
+	defer lock.RUnlock()
+
+	g, err := ModeratedGroup(in.GroupName, in.Client)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &pb.IncomingHookList{}
+	for t, h := range g.hooks {
+		l.Hooks = append(l.Hooks, &pb.IncomingHook{Client: h.creator, GroupName: g.name, Name: h.name, Token: t})
+	}
+	sort.Slice(l.Hooks, func(i, j int) bool { return l.Hooks[i].Name < l.Hooks[j].Name })
+
+	return l, nil
+}
+
+// DeleteIncomingHook removes an incoming webhook from a group, so its token stops working.
+// It returns an empty object and an error.
+func (s *server) DeleteIncomingHook(ctx context.Context, in *pb.IncomingHook) (*pb.Empty, error) {
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	g, err := ModeratedGroup(in.GroupName, in.Client)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := g.hooks[in.Token]; !ok {
+		return nil, StatusError(codes.NotFound, "that webhook doesn't exist", &errdetails.ResourceInfo{ResourceType: "webhook"})
+	}
+
+	delete(g.hooks, in.Token)
+	log.Print("[DeleteIncomingHook]: " + in.Client + " removed a webhook from " + g.name)
+
+	return &pb.Empty{}, nil
+}
+
+// FindHook finds the incoming webhook with token t.
+// It returns the hook, the name of its group and whether it exists.
+func FindHook(t string) (*Hook, string, bool) {
+
+	lock.RLock()
+	defer lock.RUnlock()
+
+	for n, g := range groups {
+		if h, ok := g.hooks[t]; ok {
+			return h, n, true
+		}
+	}
+
+	return nil, "", false
+}
+
+// HandleIncomingHook handles a POST to /hooks/<token>, which sends the body to the hook's
+// group as a message. The body is either the text itself or JSON with a "text" field.
+// It doesn't return anything.
+func HandleIncomingHook(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "use POST", http.StatusMethodNotAllowed)
+		return
+	}
+
+	h, g, ok := FindHook(strings.TrimPrefix(r.URL.Path, "/hooks/"))
+	if !ok {
+		http.Error(w, "there is no webhook with that token", http.StatusNotFound)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxHookBody))
+	if err != nil {
+		http.Error(w, "the body is too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	text := string(body)
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		var p struct {
+			Text string `json:"text"`
+		}
+		if err := json.Unmarshal(body, &p); err != nil {
+			http.Error(w, "the body isn't valid JSON: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		text = p.Text
+	}
+
+	text = strings.TrimSpace(CleanText(text))
+	if text == "" {
+		http.Error(w, "there is no message to post", http.StatusBadRequest)
+		return
+	} else if len(text) > maxHookMessage {
+		http.Error(w, "the message is longer than "+strconv.Itoa(maxHookMessage)+" bytes", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	if !h.limiter.Allow() {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(h.limiter.Wait().Seconds()))))
+		http.Error(w, "too many messages, slow down", http.StatusTooManyRequests)
+		return
+	}
+
+	log.Print("[HandleIncomingHook]: " + h.name + " posted to " + g)
+
+	Broadcast(g, pb.ChatMessage{Sender: h.name, Receiver: g, Message: text + "\n"})
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	pb "github.com/taylorflatt/go-chat"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestIncomingHook(t *testing.T) {
+
+	ts := startServer(t)
+	alice := ts.member(t, "alice", "general", true)
+	bob := ts.member(t, "bob", "general", false)
+
+	h, err := alice.Client().CreateIncomingHook(context.Background(), &pb.IncomingHook{Client: "alice", GroupName: "general", Name: "deploy"})
+	if err != nil {
+		t.Fatalf("creating the hook: %v", err)
+	}
+
+	for _, c := range []struct {
+		token string
+		want  int
+	}{{h.Token, http.StatusNoContent}, {"nope", http.StatusNotFound}} {
+		w := httptest.NewRecorder()
+		HandleIncomingHook(w, httptest.NewRequest(http.MethodPost, "/hooks/"+c.token, strings.NewReader("Deploy finished")))
+		if w.Code != c.want {
+			t.Errorf("posting to the hook %s got %d, want %d", c.token, w.Code, c.want)
+		}
+	}
+
+	expect(t, bob, "the hook's message", func(m pb.ChatMessage) bool {
+		return m.Sender == "deploy" && m.Message == "Deploy finished\n"
+	})
+
+	if _, err := alice.Client().DeleteIncomingHook(context.Background(), &pb.IncomingHook{Client: "alice", GroupName: "general", Token: h.Token}); err != nil {
+		t.Fatalf("deleting the hook: %v", err)
+	}
+	w := httptest.NewRecorder()
+	HandleIncomingHook(w, httptest.NewRequest(http.MethodPost, "/hooks/"+h.Token, strings.NewReader("again")))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("posting to a deleted hook got %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestIncomingHooksNeedModerator(t *testing.T) {
+
+	ts := startServer(t)
+	alice := ts.member(t, "alice", "general", true)
+	bob := ts.member(t, "bob", "general", false).Client()
+	ctx := context.Background()
+
+	h, err := alice.Client().CreateIncomingHook(ctx, &pb.IncomingHook{Client: "alice", GroupName: "general"})
+	if err != nil {
+		t.Fatalf("creating the hook: %v", err)
+	}
+
+	for _, who := range []struct {
+		client string
+		want   codes.Code
+	}{{"alice", codes.Unauthenticated}, {"bob", codes.PermissionDenied}} {
+		_, err := bob.CreateIncomingHook(ctx, &pb.IncomingHook{Client: who.client, GroupName: "general"})
+		if status.Code(err) != who.want {
+			t.Errorf("bob creating a hook as %s got %v, want %v", who.client, err, who.want)
+		}
+		_, err = bob.ListIncomingHooks(ctx, &pb.GroupInfo{Client: who.client, GroupName: "general"})
+		if status.Code(err) != who.want {
+			t.Errorf("bob listing the hooks as %s got %v, want %v", who.client, err, who.want)
+		}
+		_, err = bob.DeleteIncomingHook(ctx, &pb.IncomingHook{Client: who.client, GroupName: "general", Token: h.Token})
+		if status.Code(err) != who.want {
+			t.Errorf("bob deleting the hook as %s got %v, want %v", who.client, err, who.want)
+		}
+	}
+
+	l, err := alice.Client().ListIncomingHooks(ctx, &pb.GroupInfo{Client: "alice", GroupName: "general"})
+	if err != nil || len(l.Hooks) != 1 || l.Hooks[0].Token != h.Token {
+		t.Errorf("the hooks are %v, %v, want just the one alice created", l, err)
+	}
+}
+
+func TestHookNamesReserved(t *testing.T) {
+
+	ts := startServer(t)
+	alice := ts.member(t, "alice", "general", true)
+	ctx := context.Background()
+
+	if _, err := alice.Client().CreateIncomingHook(ctx, &pb.IncomingHook{Client: "alice", GroupName: "general", Name: "deploy"}); err != nil {
+		t.Fatalf("creating the hook: %v", err)
+	}
+
+	_, err := alice.Client().CreateIncomingHook(ctx, &pb.IncomingHook{Client: "alice", GroupName: "general", Name: "alice"})
+	checkStatus(t, "naming a hook after a user", err, codes.AlreadyExists, "The user name \"alice\" is already taken.")
+
+	err = ts.connect(t).Login("deploy")
+	checkStatus(t, "logging in as a hook", err, codes.AlreadyExists, "The user name \"deploy\" is already taken.")
+
+	checkStatus(t, "renaming to a hook's name", alice.Rename("deploy"), codes.AlreadyExists, "The user name \"deploy\" is already taken.")
+}
@@ -0,0 +1,587 @@
+package main
+
+import (
+	"bufio"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	pb "github.com/taylorflatt/go-chat"
+	"github.com/taylorflatt/go-chat/chatclient"
+	context "golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	ircServer  = "go-chat" // The name the IRC listener goes by.
+	maxIRCLine = 8 << 10   // The longest line read from an IRC client, in bytes.
+)
+
+// IRCConn is an IRC client's connection to the chat service. Groups show up as channels, so
+// the group general is #general.
+type IRCConn struct {
+	conn net.Conn
+	mu   sync.Mutex // Stops lines being written concurrently.
+	nick string     // The nick given with NICK before registering.
+	user bool       // Whether USER has been sent.
+	s    *chatclient.Session
+}
+
+// ServeIRC accepts IRC clients on addr. Like the WebSocket gateway, each one goes through the
+// same RPCs as every other client, over a connection back to this server.
+// It doesn't return anything.
+func ServeIRC(addr string) {
+
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Fatalf("Failed to listen for IRC %v", err)
+	}
+
+	log.Print("[ServeIRC]: Listening on " + addr)
+	for {
+		conn, err := lis.Accept()
+		if err != nil {
+			log.Print("[ServeIRC]: Couldn't accept a connection: " + err.Error())
+			continue
+		}
+
+		go HandleIRC(conn)
+	}
+}
+
+// HandleIRC reads the commands of an IRC client until it quits or disconnects, which logs the
+// user out.
+// It doesn't return anything.
+func HandleIRC(conn net.Conn) {
+
+	ic := &IRCConn{conn: conn}
+	defer ic.Close()
+
+	sc := bufio.NewScanner(conn)
+	sc.Buffer(make([]byte, 4096), maxIRCLine)
+	for sc.Scan() {
+		cmd, params := ParseIRC(sc.Text())
+		if cmd == "" {
+			continue
+		} else if cmd == "QUIT" {
+			ic.Send("ERROR :Closing link")
+			return
+		}
+
+		ic.Handle(cmd, params)
+	}
+}
+
+// ParseIRC splits an IRC line into its command and parameters, dropping any tags and prefix.
+// It returns the command in upper case and the parameters.
+func ParseIRC(line string) (string, []string) {
+
+	line = strings.TrimRight(line, "\r\n")
+	for _, lead := range []string{"@", ":"} {
+		if strings.HasPrefix(line, lead) {
+			if i := strings.IndexByte(line, ' '); i >= 0 {
+				line = line[i+1:]
+			} else {
+				line = ""
+			}
+		}
+	}
+
+	var params []string
+	for {
+		line = strings.TrimLeft(line, " ")
+		if line == "" {
+			break
+		} else if line[0] == ':' {
+			params = append(params, line[1:])
+			break
+		}
+
+		i := strings.IndexByte(line, ' ')
+		if i < 0 {
+			params = append(params, line)
+			break
+		}
+		params = append(params, line[:i])
+		line = line[i+1:]
+	}
+
+	if len(params) == 0 {
+		return "", nil
+	}
+
+	return strings.ToUpper(params[0]), params[1:]
+}
+
+// Handle carries out one command from the client.
+// It doesn't return anything.
+func (ic *IRCConn) Handle(cmd string, params []string) {
+
+	need := map[string]int{
+		"NICK": 1, "USER": 4, "PING": 1, "JOIN": 1, "PART": 1, "PRIVMSG": 2, "NOTICE": 2, "TOPIC": 1, "KICK": 2,
+	}
+	if len(params) < need[cmd] {
+		ic.Numeric("461", cmd, "Not enough parameters")
+		return
+	}
+
+	switch cmd {
+	case "CAP":
+		if len(params) > 0 && strings.ToUpper(params[0]) == "LS" {
+			ic.Send(":" + ircServer + " CAP * LS :")
+		}
+		return
+	case "PING":
+		ic.Send(":" + ircServer + " PONG " + ircServer + " :" + params[0])
+		return
+	case "PONG":
+		return
+	case "NICK":
+		ic.Nick(params[0])
+		return
+	case "USER":
+		ic.user = true
+		ic.Register()
+		return
+	}
+
+	if ic.s == nil {
+		ic.Numeric("451", "You have not registered")
+		return
+	}
+
+	switch cmd {
+	case "JOIN":
+		for _, ch := range strings.Split(params[0], ",") {
+			ic.Join(ch)
+		}
+	case "PART":
+		ic.Part(params[0])
+	case "PRIVMSG", "NOTICE":
+		ic.Privmsg(params[0], params[1], cmd == "PRIVMSG")
+	case "NAMES":
+		ch := "#" + ic.s.Group()
+		if len(params) > 0 {
+			ch = params[0]
+		}
+		ic.Names(ch)
+	case "LIST":
+		ic.List()
+	case "TOPIC":
+		if len(params) > 1 {
+			ic.SetTopic(params[0], params[1])
+		} else {
+			ic.Topic(params[0])
+		}
+	case "WHO":
+		ic.Who(params)
+	case "MODE":
+		if len(params) > 0 && strings.HasPrefix(params[0], "#") {
+			ic.Numeric("324", params[0], "+")
+		} else {
+			ic.Numeric("221", "+")
+		}
+	case "KICK":
+		ic.s.Command("/kick " + params[1])
+	default:
+		// Anything else might be a server command, such as STATS or UPTIME.
+		ic.s.Command("/" + strings.ToLower(cmd) + " " + strings.Join(params, " "))
+	}
+}
+
+// Send writes line to the client.
+// It doesn't return anything.
+func (ic *IRCConn) Send(line string) {
+
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+
+	if _, err := ic.conn.Write([]byte(line + "\r\n")); err != nil {
+		log.Print("[Send]: Couldn't write to " + ic.conn.RemoteAddr().String() + ": " + err.Error())
+	}
+}
+
+// Numeric sends the client a numeric reply from the server, with the last of params as the
+// trailing parameter.
+// It doesn't return anything.
+func (ic *IRCConn) Numeric(code string, params ...string) {
+
+	nick := ic.nick
+	if nick == "" {
+		nick = "*"
+	}
+
+	ic.Send(":" + ircServer + " " + code + " " + nick + " " + IRCParams(params))
+}
+
+// From sends the client a command coming from user u, with the last of params as the trailing
+// parameter.
+// It doesn't return anything.
+func (ic *IRCConn) From(u string, cmd string, params ...string) {
+
+	ic.Send(":" + u + "!" + u + "@" + ircServer + " " + cmd + " " + IRCParams(params))
+}
+
+// IRCParams joins the parameters of an IRC line, marking the last one as trailing.
+// It returns the parameters.
+func IRCParams(params []string) string {
+
+	if len(params) == 0 {
+		return ""
+	}
+
+	last := len(params) - 1
+	return strings.TrimSpace(strings.Join(params[:last], " ") + " :" + params[last])
+}
+
+// Nick sets the nick to register with or, once registered, changes the user's name.
+// It doesn't return anything.
+func (ic *IRCConn) Nick(n string) {
+
+	if ic.s == nil {
+		ic.nick = n
+		ic.Register()
+		return
+	}
+
+	old := ic.s.User()
+	if n == old {
+		return
+	}
+
+	if err := ic.s.Rename(n); err != nil {
+		ic.Numeric("433", n, "Nickname is already in use")
+		return
+	}
+
+	ic.nick = n
+	ic.From(old, "NICK", n)
+}
+
+// Register logs the user in once both NICK and USER have been sent, and starts passing on what
+// the server sends them.
+// It doesn't return anything.
+func (ic *IRCConn) Register() {
+
+	if ic.s != nil || ic.nick == "" || !ic.user {
+		return
+	}
+
+	s, err := chatclient.Connect(gatewayTarget, append([]grpc.DialOption{ForwardFor(ic.conn.RemoteAddr().String())}, gatewayOptions...)...)
+	if err != nil {
+		ic.Send("ERROR :" + err.Error())
+		return
+	}
+
+	if err := s.Login(ic.nick); err != nil {
+		s.Close()
+		n := ic.nick
+		ic.nick = ""
+		switch status.Code(err) {
+		case codes.AlreadyExists:
+			ic.Numeric("433", n, "Nickname is already in use")
+		case codes.InvalidArgument:
+			ic.Numeric("432", n, status.Convert(err).Message())
+		default:
+			ic.Send("ERROR :" + status.Convert(err).Message())
+		}
+		return
+	}
+
+	ic.s = s
+	go ic.Forward(s)
+
+	log.Print("[Register]: " + ic.nick + " logged in over IRC")
+
+	ic.Numeric("001", "Welcome to go-chat, "+ic.nick)
+	ic.Numeric("002", "Your host is "+ircServer)
+	ic.Numeric("003", "This server bridges IRC to go-chat groups")
+	ic.Send(":" + ircServer + " 004 " + ic.nick + " " + ircServer + " go-chat o o")
+	ic.Numeric("422", "MOTD File is missing")
+}
+
+// ChannelGroup works out the group a channel name stands for.
+// It returns the group and whether ch is a channel name.
+func ChannelGroup(ch string) (string, bool) {
+
+	if !strings.HasPrefix(ch, "#") || len(ch) == 1 {
+		return "", false
+	}
+
+	return ch[1:], true
+}
+
+// Join joins the group for channel ch, creating it if it doesn't exist. A user chats in one
+// group at a time, so this parts the channel they were in.
+// It doesn't return anything.
+func (ic *IRCConn) Join(ch string) {
+
+	g, ok := ChannelGroup(ch)
+	if !ok {
+		ic.Numeric("403", ch, "No such channel")
+		return
+	} else if g == ic.s.Group() {
+		return
+	}
+
+	old := ic.s.Group()
+	if err := JoinUnencrypted(ic.s, g, true); err != nil {
+		ic.Numeric("403", ch, status.Convert(err).Message())
+		return
+	}
+
+	if old != "" {
+		ic.From(ic.nick, "PART", "#"+old, "Joined "+ch)
+	}
+	ic.From(ic.nick, "JOIN", ch)
+
+	if t, err := ic.s.Client().GetTopic(context.Background(), &pb.GroupInfo{Client: ic.nick, GroupName: g}); err == nil && t.Topic != "" {
+		ic.Numeric("332", ch, t.Topic)
+	}
+	ic.Names(ch)
+}
+
+// Part leaves the group for channel ch.
+// It doesn't return anything.
+func (ic *IRCConn) Part(ch string) {
+
+	if g, _ := ChannelGroup(ch); g == "" || g != ic.s.Group() {
+		ic.Numeric("442", ch, "You're not on that channel")
+		return
+	}
+
+	if err := ic.s.Leave(); err != nil {
+		ic.Numeric("442", ch, status.Convert(err).Message())
+		return
+	}
+
+	ic.From(ic.nick, "PART", ch)
+}
+
+// Privmsg sends text to the channel the user is in or privately to another user. CTCP ACTIONs
+// are sent as actions. Errors are only reported for a PRIVMSG, not a NOTICE.
+// It doesn't return anything.
+func (ic *IRCConn) Privmsg(target string, text string, reply bool) {
+
+	var err error
+	var code string
+	if g, ok := ChannelGroup(target); ok {
+		if g != ic.s.Group() {
+			if reply {
+				ic.Numeric("404", target, "Cannot send to channel")
+			}
+			return
+		}
+
+		code = "404"
+		if a := strings.TrimPrefix(text, "\x01ACTION "); a != text {
+			err = ic.s.Act(strings.TrimSuffix(a, "\x01"))
+		} else {
+			err = ic.s.Send(text)
+		}
+	} else {
+		code = "401"
+		if !ic.Online(target) {
+			if reply {
+				ic.Numeric(code, target, "No such nick/channel")
+			}
+			return
+		}
+		err = ic.s.SendDirect(target, text)
+	}
+
+	if err != nil && reply {
+		ic.Numeric(code, target, status.Convert(err).Message())
+	}
+}
+
+// Online checks whether user u is logged in.
+// It returns a bool value.
+func (ic *IRCConn) Online(u string) bool {
+
+	l, err := ic.s.Client().GetClientList(context.Background(), &pb.Empty{})
+	if err != nil {
+		return false
+	}
+
+	for _, c := range l.Clients {
+		if c == u {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Names lists the members of the group for channel ch.
+// It doesn't return anything.
+func (ic *IRCConn) Names(ch string) {
+
+	if g, ok := ChannelGroup(ch); ok {
+		l, err := ic.s.Client().GetGroupClientList(context.Background(), &pb.GroupInfo{Client: ic.nick, GroupName: g})
+		if err == nil && len(l.Clients) > 0 {
+			ic.Numeric("353", "=", ch, strings.Join(l.Clients, " "))
+		}
+	}
+
+	ic.Numeric("366", ch, "End of /NAMES list")
+}
+
+// List lists every group, with how many are in it and its topic.
+// It doesn't return anything.
+func (ic *IRCConn) List() {
+
+	c := ic.s.Client()
+	l, err := c.GetGroupList(context.Background(), &pb.ClientInfo{Sender: ic.nick})
+	if err != nil {
+		ic.Send("ERROR :" + status.Convert(err).Message())
+		return
+	}
+
+	ic.Numeric("321", "Channel", "Users  Name")
+	for _, g := range l.Groups {
+		n := 0
+		if m, err := c.GetGroupClientList(context.Background(), &pb.GroupInfo{Client: ic.nick, GroupName: g}); err == nil {
+			n = len(m.Clients)
+		}
+
+		t := ""
+		if tp, err := c.GetTopic(context.Background(), &pb.GroupInfo{Client: ic.nick, GroupName: g}); err == nil {
+			t = tp.Topic
+		}
+
+		ic.Numeric("322", "#"+g, strconv.Itoa(n), t)
+	}
+	ic.Numeric("323", "End of /LIST")
+}
+
+// Topic shows the topic of the group for channel ch.
+// It doesn't return anything.
+func (ic *IRCConn) Topic(ch string) {
+
+	g, _ := ChannelGroup(ch)
+	t, err := ic.s.Client().GetTopic(context.Background(), &pb.GroupInfo{Client: ic.nick, GroupName: g})
+	if err != nil {
+		ic.Numeric("403", ch, "No such channel")
+	} else if t.Topic == "" {
+		ic.Numeric("331", ch, "No topic is set")
+	} else {
+		ic.Numeric("332", ch, t.Topic)
+	}
+}
+
+// SetTopic changes the topic of the group for channel ch. The group's other members are told by
+// the server, and the client is told here.
+// It doesn't return anything.
+func (ic *IRCConn) SetTopic(ch string, t string) {
+
+	g, _ := ChannelGroup(ch)
+	if _, err := ic.s.Client().SetTopic(context.Background(), &pb.Topic{Client: ic.nick, GroupName: g, Topic: t}); status.Code(err) == codes.NotFound {
+		ic.Numeric("403", ch, "No such channel")
+		return
+	} else if err != nil {
+		ic.Numeric("442", ch, status.Convert(err).Message())
+		return
+	}
+
+	ic.From(ic.nick, "TOPIC", ch, t)
+}
+
+// Who lists the members of a channel.
+// It doesn't return anything.
+func (ic *IRCConn) Who(params []string) {
+
+	mask := "*"
+	if len(params) > 0 {
+		mask = params[0]
+	}
+
+	if g, ok := ChannelGroup(mask); ok {
+		if l, err := ic.s.Client().GetGroupClientList(context.Background(), &pb.GroupInfo{Client: ic.nick, GroupName: g}); err == nil {
+			for _, u := range l.Clients {
+				ic.Numeric("352", mask, u, ircServer, ircServer, u, "H", "0 "+u)
+			}
+		}
+	}
+
+	ic.Numeric("315", mask, "End of /WHO list")
+}
+
+// Forward passes what the server sends session s on to the client as IRC commands until the
+// session closes.
+// It doesn't return anything.
+func (ic *IRCConn) Forward(s *chatclient.Session) {
+
+	for m := range s.Events {
+		ic.Event(s.User(), m)
+	}
+}
+
+// Event passes one message or event on to user me, skipping the echoes of what they sent.
+// It doesn't return anything.
+func (ic *IRCConn) Event(me string, m pb.ChatMessage) {
+
+	ch := "#" + m.Receiver
+	text := strings.TrimRight(m.Message, "\n")
+
+	switch m.Kind {
+	case pb.Kind_MESSAGE:
+		switch {
+		case m.Sender == me:
+		case m.Message == "joined chat!\n":
+			ic.From(m.Sender, "JOIN", ch)
+		case m.Message == m.Sender+" left chat!\n":
+			ic.From(m.Sender, "PART", ch)
+		default:
+			for _, l := range strings.Split(text, "\n") {
+				ic.From(m.Sender, "PRIVMSG", ch, l)
+			}
+		}
+	case pb.Kind_ACTION:
+		if m.Sender != me {
+			ic.From(m.Sender, "PRIVMSG", ch, "\x01ACTION "+text+"\x01")
+		}
+	case pb.Kind_DIRECT:
+		if m.Sender != me {
+			for _, l := range strings.Split(text, "\n") {
+				ic.From(m.Sender, "PRIVMSG", me, l)
+			}
+		}
+	case pb.Kind_EDIT:
+		ic.From(m.Sender, "NOTICE", ch, "(edited) "+text)
+	case pb.Kind_MENTION:
+		if m.Receiver != ic.s.Group() {
+			ic.From(m.Sender, "NOTICE", me, "You were mentioned in "+ch+": "+text)
+		}
+	case pb.Kind_TOPIC:
+		if m.Sender != me {
+			ic.From(m.Sender, "TOPIC", ch, text)
+		}
+	case pb.Kind_NICK:
+		// The user's own change may arrive before or after the session knows its new name.
+		if m.Sender != me && text != me {
+			ic.From(m.Sender, "NICK", text)
+		}
+	case pb.Kind_COMMAND_RESULT, pb.Kind_ANNOUNCEMENT, pb.Kind_RATE_LIMITED, pb.Kind_REJECTED:
+		for _, l := range strings.Split(text, "\n") {
+			ic.Send(":" + ircServer + " NOTICE " + me + " :" + l)
+		}
+	}
+}
+
+// Close logs the user out, if they registered, and hangs up.
+// It doesn't return anything.
+func (ic *IRCConn) Close() {
+
+	if ic.s != nil {
+		ic.s.Leave()
+		ic.s.Close()
+		log.Print("[Close]: " + ic.nick + " left IRC")
+	}
+
+	ic.conn.Close()
+}
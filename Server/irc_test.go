@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/taylorflatt/go-chat"
+	"google.golang.org/grpc"
+)
+
+// ircClient is the client end of a connection to the IRC listener.
+type ircClient struct {
+	conn  net.Conn
+	lines chan string
+}
+
+// irc connects an IRC client to ts over an in-memory pipe, hung up when the test finishes.
+// It returns the client.
+func (ts *testServer) irc(t *testing.T) *ircClient {
+
+	t.Helper()
+
+	savedTarget, savedOptions := gatewayTarget, gatewayOptions
+	gatewayTarget, gatewayOptions = "bufnet", []grpc.DialOption{grpc.WithContextDialer(ts.dial)}
+	t.Cleanup(func() { gatewayTarget, gatewayOptions = savedTarget, savedOptions })
+
+	client, server := net.Pipe()
+	go HandleIRC(server)
+	t.Cleanup(func() { client.Close() })
+
+	// Writes to a pipe wait for them to be read, so the server's lines are read as they come.
+	ic := &ircClient{conn: client, lines: make(chan string, 100)}
+	go func() {
+		defer close(ic.lines)
+		sc := bufio.NewScanner(client)
+		for sc.Scan() {
+			ic.lines <- strings.TrimRight(sc.Text(), "\r")
+		}
+	}()
+
+	return ic
+}
+
+// send writes line to the server.
+// It doesn't return anything.
+func (ic *ircClient) send(t *testing.T, line string) {
+
+	t.Helper()
+
+	if _, err := ic.conn.Write([]byte(line + "\r\n")); err != nil {
+		t.Fatalf("sending %q: %v", line, err)
+	}
+}
+
+// expect waits for the server to send the line want, skipping any others.
+// It doesn't return anything.
+func (ic *ircClient) expect(t *testing.T, want string) {
+
+	t.Helper()
+
+	timeout := time.After(eventTimeout)
+	for {
+		select {
+		case l, ok := <-ic.lines:
+			if !ok {
+				t.Fatalf("the connection closed before %q was sent", want)
+			} else if l == want {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("didn't receive %q", want)
+		}
+	}
+}
+
+func TestIRC(t *testing.T) {
+
+	ts := startServer(t)
+	bob := ts.member(t, "bob", "general", true)
+	ic := ts.irc(t)
+
+	// Only a few commands work before registering.
+	ic.send(t, "JOIN #general")
+	ic.expect(t, ":go-chat 451 * :You have not registered")
+	ic.send(t, "NICK")
+	ic.expect(t, ":go-chat 461 * NICK :Not enough parameters")
+	ic.send(t, "PING :check")
+	ic.expect(t, ":go-chat PONG go-chat :check")
+
+	// A taken nick has to be changed before registering.
+	ic.send(t, "NICK bob")
+	ic.send(t, "USER alice 0 * :Alice")
+	ic.expect(t, ":go-chat 433 * bob :Nickname is already in use")
+	ic.send(t, "NICK alice")
+	ic.expect(t, ":go-chat 001 alice :Welcome to go-chat, alice")
+
+	ic.send(t, "JOIN general")
+	ic.expect(t, ":go-chat 403 alice general :No such channel")
+	ic.send(t, "JOIN #general")
+	ic.expect(t, ":alice!alice@go-chat JOIN :#general")
+	ic.expect(t, ":go-chat 366 alice #general :End of /NAMES list")
+	expect(t, bob, "alice joining", func(m pb.ChatMessage) bool { return m.Sender == "alice" && m.Message == "joined chat!\n" })
+
+	// Messages go both ways, to the channel and privately.
+	ic.send(t, "PRIVMSG #general :hello from irc")
+	expect(t, bob, "alice's message", func(m pb.ChatMessage) bool { return m.Sender == "alice" && m.Message == "hello from irc\n" })
+	ic.send(t, "PRIVMSG bob :psst")
+	expect(t, bob, "alice's private message", func(m pb.ChatMessage) bool {
+		return m.Kind == pb.Kind_DIRECT && m.Sender == "alice" && m.Message == "psst\n"
+	})
+
+	bob.Send("hello from go-chat")
+	ic.expect(t, ":bob!bob@go-chat PRIVMSG #general :hello from go-chat")
+	bob.SendDirect("alice", "psst back")
+	ic.expect(t, ":bob!bob@go-chat PRIVMSG alice :psst back")
+
+	// Messages that can't be delivered are answered with the matching numeric.
+	ic.send(t, "PRIVMSG nobody :hi")
+	ic.expect(t, ":go-chat 401 alice nobody :No such nick/channel")
+	ic.send(t, "PRIVMSG #random :hi")
+	ic.expect(t, ":go-chat 404 alice #random :Cannot send to channel")
+	ic.send(t, "PART #random")
+	ic.expect(t, ":go-chat 442 alice #random :You're not on that channel")
+
+	ic.send(t, "QUIT :bye")
+	ic.expect(t, "ERROR :Closing link")
+	expect(t, bob, "alice leaving", func(m pb.ChatMessage) bool { return m.Sender == "alice" && m.Message == "alice left chat!\n" })
+	eventually(t, "alice to be logged out", func() bool { return !ClientExists("alice") })
+}
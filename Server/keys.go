@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"strconv"
+
+	pb "github.com/taylorflatt/go-chat"
+	context "golang.org/x/net/context"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+)
+
+// Messages in end-to-end encrypted groups are encrypted by the clients with a group key that
+// the server never sees. Clients publish their public keys here, and whenever the membership of
+// an encrypted group changes one member generates a new group key and shares it with the others
+// by wrapping it for each of their public keys. The server only stores and relays the wrapped
+// keys and the resulting ciphertext.
+
+// IsEncrypted checks whether group g is end-to-end encrypted.
+// It returns a bool value.
+func IsEncrypted(g string) bool {
+
+	lock.RLock()
+	defer lock.RUnlock()
+
+	grp, ok := groups[g]
+	return ok && grp.encrypted
+}
+
+// AcceptsMessage checks whether a message sent over the chat stream may be relayed to group g.
+// Encrypted groups only take ciphertext, apart from the notice clients send when they join
+// before they have a key.
+// It returns a bool value.
+func AcceptsMessage(g string, msg pb.ChatMessage) bool {
+
+	if !IsEncrypted(g) {
+		return true
+	}
+
+	return msg.Message == "" || msg.Message == "joined chat!\n"
+}
+
+// RequestRekey asks a member of encrypted group gName to share a new group key after its
+// membership changed. Only one member is asked so that members don't race each other to do it:
+// the longest standing member that has published a public key and has its stream open to hear
+// the request.
+// It doesn't return anything.
+func RequestRekey(gName string) {
+
+	lock.RLock()
+	g, ok := groups[gName]
+	if !ok || !g.encrypted {
+		lock.RUnlock()
+		return
+	}
+
+	ev := pb.ChatMessage{Receiver: gName, Kind: pb.Kind_REKEY, KeyEpoch: g.keyEpoch}
+	for _, n := range g.clients {
+		if c := clients[n]; c.publicKey != nil && c.streams > 0 {
+			ev.Message = n
+			break
+		}
+	}
+	lock.RUnlock()
+
+	if ev.Message == "" {
+		log.Printf("[RequestRekey]: Nobody in %s can share a new key", gName)
+		return
+	}
+
+	log.Printf("[RequestRekey]: Asked %s for a new key for %s", ev.Message, gName)
+
+	Broadcast(gName, ev)
+}
+
+// PublishKey adds a client's public key to the key directory. A session publishes its key once,
+// and it can't be replaced afterwards, since members who have been sent group keys wrapped for
+// it would otherwise be handing them to someone else.
+// It returns an empty object and an error.
+func (s *server) PublishKey(ctx context.Context, in *pb.PublicKey) (*pb.Empty, error) {
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	c, ok := clients[in.Client]
+	if !ok {
+		return &pb.Empty{}, NotFound("client", in.Client)
+	} else if len(in.Key) != 32 {
+		return &pb.Empty{}, InvalidArgument("key", "public keys must be 32 byte X25519 keys")
+	} else if c.publicKey != nil && !bytes.Equal(c.publicKey, in.Key) {
+		return &pb.Empty{}, FailedPrecondition("client", in.Client, "the client "+in.Client+" has already published a public key")
+	}
+
+	c.publicKey = in.Key
+
+	log.Print("[PublishKey]: Published a public key for " + in.Client)
+	return &pb.Empty{}, nil
+}
+
+// GetPublicKeys looks up the public keys of the given clients. Clients that don't exist or
+// haven't published a key are left out.
+// It returns a list of public keys and an error.
+func (s *server) GetPublicKeys(ctx context.Context, in *pb.ClientList) (*pb.PublicKeyList, error) {
+
+	lock.RLock()
+	defer lock.RUnlock()
+
+	var keys []*pb.PublicKey
+	for _, n := range in.Clients {
+		if c, ok := clients[n]; ok && c.publicKey != nil {
+			keys = append(keys, &pb.PublicKey{Client: n, Key: c.publicKey})
+		}
+	}
+
+	return &pb.PublicKeyList{Keys: keys}, nil
+}
+
+// ShareGroupKey stores a new group key for the next epoch of an encrypted group and tells the
+// other members to fetch it. Keys for any epoch other than the next one are rejected so two
+// members can't replace each other's keys. Only members can share a key, from their own session.
+// It returns an empty object and an error.
+func (s *server) ShareGroupKey(ctx context.Context, in *pb.GroupKey) (*pb.Empty, error) {
+
+	lock.Lock()
+
+	g, ok := groups[in.GroupName]
+	if !ok || !g.encrypted {
+		lock.Unlock()
+		return &pb.Empty{}, FailedPrecondition("group", in.GroupName, "the group "+in.GroupName+" isn't an encrypted group")
+	} else if in.Epoch != g.keyEpoch+1 {
+		lock.Unlock()
+		return &pb.Empty{}, StatusError(codes.AlreadyExists, "a key for that epoch has already been shared", &errdetails.ResourceInfo{ResourceType: "key", ResourceName: strconv.FormatUint(in.Epoch, 10)})
+	}
+
+	members := make(map[string]bool)
+	for _, c := range g.clients {
+		members[c] = true
+	}
+
+	if !members[in.Client] {
+		lock.Unlock()
+		return &pb.Empty{}, PermissionDenied(reasonNotMember, "the client "+in.Client+" isn't in the group "+in.GroupName)
+	}
+
+	keys := make(map[string]*pb.WrappedKey)
+	for _, k := range in.Keys {
+		if members[k.Client] {
+			keys[k.Client] = k
+		}
+	}
+
+	g.keyEpoch = in.Epoch
+	g.keys = keys
+	lock.Unlock()
+
+	log.Printf("[ShareGroupKey]: %s shared key epoch %d for %s", in.Client, in.Epoch, in.GroupName)
+
+	Broadcast(in.GroupName, pb.ChatMessage{Sender: in.Client, Receiver: in.GroupName, Kind: pb.Kind_KEY, KeyEpoch: in.Epoch})
+	return &pb.Empty{}, nil
+}
+
+// GetGroupKey gets the current group key of a group wrapped for the requesting client.
+// It returns the group key, which is marked as unencrypted for ordinary groups, and an error.
+func (s *server) GetGroupKey(ctx context.Context, in *pb.GroupInfo) (*pb.GroupKey, error) {
+
+	lock.RLock()
+	defer lock.RUnlock()
+
+	g, ok := groups[in.GroupName]
+	if !ok {
+		return &pb.GroupKey{}, NotFound("group", in.GroupName)
+	}
+
+	k := &pb.GroupKey{GroupName: g.name, Epoch: g.keyEpoch, Encrypted: g.encrypted}
+	if w, ok := g.keys[in.Client]; ok {
+		k.Keys = []*pb.WrappedKey{w}
+	}
+
+	return k, nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	pb "github.com/taylorflatt/go-chat"
+	"github.com/taylorflatt/go-chat/chatclient"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// expectDecrypted has from send text to its group until to receives it decrypted, since the
+// key for the group's latest members is shared in the background.
+// It doesn't return anything.
+func expectDecrypted(t *testing.T, from *chatclient.Session, to *chatclient.Session, text string) {
+
+	t.Helper()
+
+	deadline := time.After(eventTimeout)
+	for {
+		from.Send(text)
+
+		retry := time.After(100 * time.Millisecond)
+		for waiting := true; waiting; {
+			select {
+			case m, ok := <-to.Events:
+				if !ok {
+					t.Fatalf("%s's stream closed while waiting for %q", to.User(), text)
+				} else if m.Message == text+"\n" {
+					return
+				}
+			case <-retry:
+				waiting = false
+			case <-deadline:
+				t.Fatalf("%s didn't receive %q decrypted", to.User(), text)
+			}
+		}
+	}
+}
+
+func TestEncryptedGroup(t *testing.T) {
+
+	ts := startServer(t)
+	alice := ts.login(t, "alice")
+	if err := alice.Create("secret", true); err != nil {
+		t.Fatalf("creating the group: %v", err)
+	}
+	alice.Join("secret")
+	bob := ts.login(t, "bob")
+	bob.Join("secret")
+
+	expectDecrypted(t, alice, bob, "hello")
+
+	lock.RLock()
+	h := groups["secret"].history
+	last := h[len(h)-1]
+	lock.RUnlock()
+	if last.Message != "" || len(last.Ciphertext) == 0 {
+		t.Errorf("the server stored %+v, want only ciphertext", last)
+	}
+}
+
+func TestKeysOnlyFromOwnSession(t *testing.T) {
+
+	ts := startServer(t)
+	alice := ts.login(t, "alice")
+	if err := alice.Create("secret", true); err != nil {
+		t.Fatalf("creating the group: %v", err)
+	}
+	alice.Join("secret")
+
+	lock.RLock()
+	published := clients["alice"].publicKey
+	lock.RUnlock()
+
+	other := ts.connect(t).Client()
+	key := bytes.Repeat([]byte{1}, 32)
+	_, err := other.PublishKey(context.Background(), &pb.PublicKey{Client: "alice", Key: key})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("publishing a key for someone else got %v, want %v", err, codes.Unauthenticated)
+	}
+	_, err = alice.Client().PublishKey(context.Background(), &pb.PublicKey{Client: "alice", Key: key})
+	checkStatus(t, "replacing a public key", err, codes.FailedPrecondition, "The client alice has already published a public key.")
+
+	lock.RLock()
+	if !bytes.Equal(clients["alice"].publicKey, published) {
+		t.Error("alice's public key was replaced")
+	}
+	epoch := groups["secret"].keyEpoch
+	lock.RUnlock()
+
+	_, err = other.ShareGroupKey(context.Background(), &pb.GroupKey{Client: "alice", GroupName: "secret", Epoch: epoch + 1})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("sharing a key as someone else got %v, want %v", err, codes.Unauthenticated)
+	}
+}
+
+func TestRekeyAsksMemberWhoCanAnswer(t *testing.T) {
+
+	ts := startServer(t)
+
+	// The longest standing member never opens a stream or publishes a key, so it can't be the
+	// one asked to share them.
+	ghost := ts.connect(t).Client()
+	var header metadata.MD
+	if _, err := ghost.Register(context.Background(), &pb.ClientInfo{Sender: "ghost"}, grpc.Header(&header)); err != nil {
+		t.Fatalf("registering: %v", err)
+	}
+	ctx := metadata.AppendToOutgoingContext(context.Background(), chatclient.TokenHeader, header.Get(chatclient.TokenHeader)[0])
+	if _, err := ghost.CreateGroup(ctx, &pb.GroupInfo{Client: "ghost", GroupName: "secret", Encrypted: true}); err != nil {
+		t.Fatalf("creating the group: %v", err)
+	}
+	if _, err := ghost.JoinGroup(ctx, &pb.GroupInfo{Client: "ghost", GroupName: "secret"}); err != nil {
+		t.Fatalf("joining: %v", err)
+	}
+
+	alice := ts.login(t, "alice")
+	alice.Join("secret")
+	bob := ts.login(t, "bob")
+	bob.Join("secret")
+
+	expectDecrypted(t, alice, bob, "hello")
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Limiter is a token bucket that allows bursts of up to burst events, refilled at rate events
+// per second.
+type Limiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewLimiter creates a limiter that starts full. The rate and burst must be positive, since a
+// limit that is turned off has no limiter; Limits.Check makes sure the server's are.
+// It returns the limiter.
+func NewLimiter(rate float64, burst int) *Limiter {
+
+	if rate <= 0 || burst <= 0 {
+		panic("a limiter needs a positive rate and burst, not " + strconv.FormatFloat(rate, 'g', -1, 64) + " and " + strconv.Itoa(burst))
+	}
+
+	return &Limiter{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// Allow takes a token from the bucket if there is one.
+// It returns true if the event is allowed.
+func (l *Limiter) Allow() bool {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill()
+	if l.tokens < 1 {
+		return false
+	}
+
+	l.tokens--
+	return true
+}
+
+// Ready checks whether there is a token in the bucket without taking it.
+// It returns true if an event would be allowed.
+func (l *Limiter) Ready() bool {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill()
+	return l.tokens >= 1
+}
+
+// refill adds the tokens earned since the bucket was last used. The mutex must be held.
+// It doesn't return anything.
+func (l *Limiter) refill() {
+
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+}
+
+// Wait estimates how long until the next token is added.
+// It returns the time to wait.
+func (l *Limiter) Wait() time.Duration {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.tokens >= 1 {
+		return 0
+	}
+
+	return time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+}
+
+// Full checks whether the bucket has refilled completely, in which case forgetting the limiter
+// loses nothing.
+// It returns a bool value.
+func (l *Limiter) Full() bool {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.tokens+time.Since(l.last).Seconds()*l.rate >= l.burst
+}
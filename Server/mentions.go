@@ -0,0 +1,82 @@
+package main
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	pb "github.com/taylorflatt/go-chat"
+)
+
+// The number of mentions kept in each client's inbox.
+const (
+	maxMentions = 50
+)
+
+// MentionedClients parses the @mentions in a message sent to group g. Besides mentioning a
+// user by name, @here mentions everyone currently in the group and @all also mentions anyone
+// who has been in it before. Senders never mention themselves and unknown names are ignored.
+// The caller must hold the lock.
+// It returns the names of the mentioned clients.
+func MentionedClients(g *Group, msg pb.ChatMessage) []string {
+
+	var names []string
+	seen := make(map[string]bool)
+
+	add := func(n string) {
+		if _, ok := clients[n]; ok && n != msg.Sender && !seen[n] {
+			seen[n] = true
+			names = append(names, n)
+		}
+	}
+
+	for _, w := range strings.Fields(msg.Message) {
+		if !strings.HasPrefix(w, "@") {
+			continue
+		}
+
+		switch n := strings.TrimRight(w[1:], ".,:;!?"); n {
+		case "here":
+			for _, c := range g.clients {
+				add(c)
+			}
+		case "all":
+			for _, c := range g.clients {
+				add(c)
+			}
+			for c := range g.read {
+				add(c)
+			}
+		default:
+			add(n)
+		}
+	}
+
+	return names
+}
+
+// NotifyMentions files msg, which was just stored in group g, in the mentions inbox of every
+// client it mentions and sends each of them a mention event. The events go through Deliver, so
+// a mentioned client who isn't keeping up is dealt with like any other rather than holding up
+// the broadcast. The caller must hold the lock.
+// It doesn't return anything.
+func NotifyMentions(g *Group, msg pb.ChatMessage) {
+
+	for _, n := range MentionedClients(g, msg) {
+		ev := msg
+		ev.Kind = pb.Kind_MENTION
+		ev.Reactions = nil
+
+		EmitWebhook(WebhookEvent{Event: "mention", Group: g.name, Sender: msg.Sender, Id: msg.Id, Message: msg.Message, Mentioned: n, Time: time.Now().UTC().Format(time.RFC3339)})
+
+		c := clients[n]
+		c.mentions = append(c.mentions, ev)
+		if len(c.mentions) > maxMentions {
+			c.mentions = c.mentions[len(c.mentions)-maxMentions:]
+		}
+
+		if Deliver(c, ev) {
+			log.Printf("[NotifyMentions]: Told %s that %s mentioned them in %s.", n, msg.Sender, g.name)
+		}
+	}
+}
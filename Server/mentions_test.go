@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/taylorflatt/go-chat"
+	"github.com/taylorflatt/go-chat/chatclient"
+)
+
+// mentions lists the messages in the mentions inbox of the user of s.
+// It returns the text of each.
+func mentions(t *testing.T, s *chatclient.Session) []string {
+
+	t.Helper()
+
+	l, err := s.Client().GetMentions(context.Background(), &pb.ClientInfo{Sender: s.User()})
+	if err != nil {
+		t.Fatalf("getting %s's mentions: %v", s.User(), err)
+	}
+
+	var texts []string
+	for _, m := range l.Messages {
+		texts = append(texts, m.Message)
+	}
+
+	return texts
+}
+
+func TestMentionHereAndAll(t *testing.T) {
+
+	ts := startServer(t)
+	alice := ts.member(t, "alice", "general", true)
+	bob := ts.member(t, "bob", "general", false)
+	dave := ts.member(t, "dave", "random", true)
+
+	// carol has been in general, but isn't any more.
+	carol := ts.member(t, "carol", "general", false)
+	alice.Send("hello")
+	m := expect(t, carol, "alice's message", func(m pb.ChatMessage) bool { return m.Message == "hello\n" })
+	if _, err := carol.Client().MarkRead(context.Background(), &pb.ReadMarker{Client: "carol", GroupName: "general", Id: m.Id}); err != nil {
+		t.Fatalf("marking read: %v", err)
+	}
+	if err := carol.Leave(); err != nil {
+		t.Fatalf("leaving: %v", err)
+	}
+
+	for _, c := range []struct {
+		text string
+		want map[*chatclient.Session]bool
+	}{
+		{"@here standup", map[*chatclient.Session]bool{bob: true}},
+		{"@all standup!", map[*chatclient.Session]bool{bob: true, carol: true}},
+	} {
+		alice.Send(c.text)
+		expect(t, bob, "the mention of "+c.text, func(m pb.ChatMessage) bool {
+			return m.Kind == pb.Kind_MENTION && m.Message == c.text+"\n"
+		})
+
+		for _, s := range []*chatclient.Session{alice, bob, carol, dave} {
+			got := mentions(t, s)
+			found := len(got) > 0 && got[len(got)-1] == c.text+"\n"
+			if found != c.want[s] {
+				t.Errorf("%q mentioned %s: %v, want %v", c.text, s.User(), found, c.want[s])
+			}
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/taylorflatt/go-chat/chatclient"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// maxRESTBody is the largest REST request body accepted, in bytes.
+const maxRESTBody = 64 << 10
+
+// Route is an RPC served over HTTP, as set by the google.api.http option on it in
+// services.proto.
+type Route struct {
+	Verb   string   // The HTTP method.
+	Path   string   // The path template, e.g. /v1/groups/{groupName}/clients.
+	Body   bool     // Whether the request fields come from a JSON body rather than the query.
+	Params []string // The fields set from the path.
+
+	method string // The full gRPC method name.
+	in     protoreflect.MessageType
+	out    protoreflect.MessageType
+	conn   *restConn
+}
+
+// restConn is the connection REST requests are passed on to the gRPC service over, shared by
+// the routes of a handler and made when the first request comes in.
+type restConn struct {
+	sync.Once
+	conn *grpc.ClientConn
+	err  error
+}
+
+// Routes finds the RPCs of the Chat service that have an HTTP rule.
+// It returns the routes and an error.
+func Routes() ([]*Route, error) {
+
+	fd, err := protoregistry.GlobalFiles.FindFileByPath("services.proto")
+	if err != nil {
+		return nil, err
+	}
+
+	sd := fd.Services().ByName("Chat")
+	if sd == nil {
+		return nil, errors.New("services.proto has no Chat service")
+	}
+
+	var rs []*Route
+	for i := 0; i < sd.Methods().Len(); i++ {
+		md := sd.Methods().Get(i)
+		rule, ok := proto.GetExtension(md.Options(), annotations.E_Http).(*annotations.HttpRule)
+		if !ok || rule == nil {
+			continue
+		}
+
+		rt := &Route{Body: rule.Body == "*", method: "/" + string(sd.FullName()) + "/" + string(md.Name())}
+		switch p := rule.Pattern.(type) {
+		case *annotations.HttpRule_Get:
+			rt.Verb, rt.Path = http.MethodGet, p.Get
+		case *annotations.HttpRule_Post:
+			rt.Verb, rt.Path = http.MethodPost, p.Post
+		case *annotations.HttpRule_Put:
+			rt.Verb, rt.Path = http.MethodPut, p.Put
+		case *annotations.HttpRule_Patch:
+			rt.Verb, rt.Path = http.MethodPatch, p.Patch
+		case *annotations.HttpRule_Delete:
+			rt.Verb, rt.Path = http.MethodDelete, p.Delete
+		default:
+			return nil, errors.New(string(md.Name()) + " has an HTTP rule that isn't supported")
+		}
+
+		for _, seg := range strings.Split(rt.Path, "/") {
+			if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+				rt.Params = append(rt.Params, strings.Trim(seg, "{}"))
+			}
+		}
+
+		if rt.in, err = protoregistry.GlobalTypes.FindMessageByName(md.Input().FullName()); err != nil {
+			return nil, err
+		}
+		if rt.out, err = protoregistry.GlobalTypes.FindMessageByName(md.Output().FullName()); err != nil {
+			return nil, err
+		}
+
+		rs = append(rs, rt)
+	}
+
+	return rs, nil
+}
+
+// RESTHandler serves the RPCs that have an HTTP rule as JSON. Each request is passed on to the
+// gRPC service as a call to this server, so it goes through the same checks as any other
+// client. A session token sent as "Authorization: Bearer <token>" is passed on as the
+// session's metadata, so calls made for a user are authenticated.
+// It returns the handler and an error.
+func RESTHandler() (http.Handler, error) {
+
+	rs, err := Routes()
+	if err != nil {
+		return nil, err
+	}
+
+	conn := &restConn{}
+	mux := http.NewServeMux()
+	for _, rt := range rs {
+		rt.conn = conn
+		mux.Handle(rt.Verb+" "+rt.Path, rt)
+	}
+
+	return mux, nil
+}
+
+// ServeHTTP makes the call for a REST request and writes the reply as JSON.
+// It doesn't return anything.
+func (rt *Route) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxRESTBody)
+	in := rt.in.New()
+	if err := rt.Decode(r, in); err != nil {
+		WriteRESTError(w, status.Error(codes.InvalidArgument, err.Error()))
+		return
+	}
+
+	rt.conn.Do(func() {
+		rt.conn.conn, rt.conn.err = grpc.Dial(gatewayTarget, append([]grpc.DialOption{grpc.WithInsecure()}, gatewayOptions...)...)
+	})
+	if rt.conn.err != nil {
+		WriteRESTError(w, status.Error(codes.Unavailable, rt.conn.err.Error()))
+		return
+	}
+
+	ctx := Forwarded(r.Context(), r.RemoteAddr)
+	if t, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		ctx = metadata.AppendToOutgoingContext(ctx, chatclient.TokenHeader, strings.TrimSpace(t))
+	}
+
+	out := rt.out.New()
+	if err := rt.conn.conn.Invoke(ctx, rt.method, in.Interface(), out.Interface()); err != nil {
+		WriteRESTError(w, err)
+		return
+	}
+
+	b, err := protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}.Marshal(out.Interface())
+	if err != nil {
+		log.Print("[ServeHTTP]: Couldn't encode the reply to " + rt.method + ": " + err.Error())
+		WriteRESTError(w, status.Error(codes.Internal, "couldn't encode the reply"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b)
+}
+
+// Decode fills in the request message m from the body or query of r, then from its path.
+// It returns an error.
+func (rt *Route) Decode(r *http.Request, m protoreflect.Message) error {
+
+	if rt.Body {
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			return err
+		}
+		if len(strings.TrimSpace(string(b))) > 0 {
+			if err := protojson.Unmarshal(b, m.Interface()); err != nil {
+				return err
+			}
+		}
+	} else {
+		for k, vs := range r.URL.Query() {
+			for _, v := range vs {
+				if err := SetField(m, k, v); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for _, p := range rt.Params {
+		m.Clear(m.Descriptor().Fields().ByName(protoreflect.Name(p)))
+		if err := SetField(m, p, r.PathValue(p)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SetField sets the field of m called name to the value v, taken from a query or path. A
+// repeated field has v added to it.
+// It returns an error.
+func SetField(m protoreflect.Message, name string, v string) error {
+
+	fd := m.Descriptor().Fields().ByName(protoreflect.Name(name))
+	if fd == nil {
+		return errors.New("there is no field called " + name)
+	}
+
+	var val protoreflect.Value
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		val = protoreflect.ValueOfString(v)
+	case protoreflect.BoolKind:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return errors.New(name + " must be true or false")
+		}
+		val = protoreflect.ValueOfBool(b)
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return errors.New(name + " must be a number")
+		}
+		val = protoreflect.ValueOfUint64(n)
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return errors.New(name + " must be a number")
+		}
+		val = protoreflect.ValueOfInt64(n)
+	default:
+		return errors.New(name + " can't be set from a URL")
+	}
+
+	if fd.IsList() {
+		m.Mutable(fd).List().Append(val)
+	} else {
+		m.Set(fd, val)
+	}
+
+	return nil
+}
+
+// WriteRESTError writes err as a JSON status with the HTTP status code matching its gRPC code.
+// It doesn't return anything.
+func WriteRESTError(w http.ResponseWriter, err error) {
+
+	st := status.Convert(err)
+	b, _ := protojson.Marshal(st.Proto())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(HTTPStatus(st.Code()))
+	w.Write(b)
+}
+
+// HTTPStatus maps a gRPC status code to the HTTP status code that means the same.
+// It returns the HTTP status code.
+func HTTPStatus(c codes.Code) int {
+
+	switch c {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	}
+
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	pb "github.com/taylorflatt/go-chat"
+	"github.com/taylorflatt/go-chat/chatclient"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// rest serves the REST API in front of ts, stopped when the test finishes.
+// It returns the API's base URL.
+func (ts *testServer) rest(t *testing.T) string {
+
+	t.Helper()
+
+	savedTarget, savedOptions := gatewayTarget, gatewayOptions
+	gatewayTarget, gatewayOptions = "bufnet", []grpc.DialOption{grpc.WithContextDialer(ts.dial)}
+	t.Cleanup(func() { gatewayTarget, gatewayOptions = savedTarget, savedOptions })
+
+	h, err := RESTHandler()
+	if err != nil {
+		t.Fatalf("making the REST handler: %v", err)
+	}
+	hs := httptest.NewServer(h)
+	t.Cleanup(hs.Close)
+
+	return hs.URL
+}
+
+// token logs in as u over gRPC, as a REST client would before using the API.
+// It returns the session token.
+func (ts *testServer) token(t *testing.T, u string) string {
+
+	t.Helper()
+
+	var header metadata.MD
+	if _, err := ts.connect(t).Client().Register(context.Background(), &pb.ClientInfo{Sender: u}, grpc.Header(&header)); err != nil {
+		t.Fatalf("registering %s: %v", u, err)
+	}
+
+	return header.Get(chatclient.TokenHeader)[0]
+}
+
+// call makes a REST request with the session token tok, if there is one, and decodes the JSON
+// reply into out.
+// It returns the HTTP status code.
+func call(t *testing.T, method string, url string, tok string, body string, out interface{}) int {
+
+	t.Helper()
+
+	var r io.Reader
+	if body != "" {
+		r = strings.NewReader(body)
+	}
+	req, err := http.NewRequest(method, url, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok != "" {
+		req.Header.Set("Authorization", "Bearer "+tok)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, url, err)
+	}
+	defer res.Body.Close()
+
+	if out != nil {
+		if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+			t.Fatalf("decoding the reply to %s %s: %v", method, url, err)
+		}
+	}
+
+	return res.StatusCode
+}
+
+func TestREST(t *testing.T) {
+
+	ts := startServer(t)
+	url := ts.rest(t)
+	alice := ts.token(t, "alice")
+
+	// A POST with its request in the body.
+	if code := call(t, http.MethodPost, url+"/v1/groups", alice, `{"client": "alice", "groupName": "general"}`, nil); code != http.StatusOK {
+		t.Errorf("creating a group got %d", code)
+	}
+
+	// A POST with the group in the path as well.
+	if code := call(t, http.MethodPost, url+"/v1/groups/general/join", alice, `{"client": "alice"}`, nil); code != http.StatusOK {
+		t.Errorf("joining a group got %d", code)
+	}
+
+	// A GET with nothing to set.
+	var cl struct{ Clients []string }
+	if code := call(t, http.MethodGet, url+"/v1/clients", "", "", &cl); code != http.StatusOK || len(cl.Clients) != 1 || cl.Clients[0] != "alice" {
+		t.Errorf("listing the clients got %d %v", code, cl)
+	}
+
+	// A GET with the group in the path.
+	var ml struct{ Clients []string }
+	if code := call(t, http.MethodGet, url+"/v1/groups/general/clients", "", "", &ml); code != http.StatusOK || len(ml.Clients) != 1 || ml.Clients[0] != "alice" {
+		t.Errorf("listing the members got %d %v", code, ml)
+	}
+
+	// A GET with the request in the query.
+	var gl struct{ Groups []string }
+	if code := call(t, http.MethodGet, url+"/v1/groups?sender=alice", alice, "", &gl); code != http.StatusOK || len(gl.Groups) != 1 || gl.Groups[0] != "general" {
+		t.Errorf("listing the groups got %d %v", code, gl)
+	}
+
+	// Errors come back with the gRPC code under the matching HTTP status.
+	var st struct {
+		Code    int
+		Message string
+	}
+	if code := call(t, http.MethodGet, url+"/v1/groups/nowhere/clients", "", "", &st); code != http.StatusNotFound || st.Code != 5 {
+		t.Errorf("listing the members of a group that doesn't exist got %d %v", code, st)
+	}
+	if code := call(t, http.MethodGet, url+"/v1/groups?nobody=alice", alice, "", nil); code != http.StatusBadRequest {
+		t.Errorf("listing the groups with a field that doesn't exist got %d, want %d", code, http.StatusBadRequest)
+	}
+}
+
+func TestRESTNeedsToken(t *testing.T) {
+
+	ts := startServer(t)
+	url := ts.rest(t)
+	alice := ts.member(t, "alice", "general", true)
+	bob := ts.token(t, "bob")
+
+	for _, tok := range []string{"", bob} {
+		if code := call(t, http.MethodPost, url+"/v1/groups/general/leave", tok, `{"client": "alice"}`, nil); code != http.StatusUnauthorized {
+			t.Errorf("making alice leave with the token %q got %d, want %d", tok, code, http.StatusUnauthorized)
+		}
+		if code := call(t, http.MethodGet, url+"/v1/groups?sender=alice", tok, "", nil); code != http.StatusUnauthorized {
+			t.Errorf("listing alice's groups with the token %q got %d, want %d", tok, code, http.StatusUnauthorized)
+		}
+	}
+
+	if got := members(t, alice, "general"); len(got) != 1 {
+		t.Errorf("general has %v, want alice", got)
+	}
+}
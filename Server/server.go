@@ -2,14 +2,23 @@ package main
 
 import (
 	"errors"
+	"flag"
 	"io"
 	"log"
 	"net"
+	"os"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	pb "github.com/taylorflatt/go-chat"
+	"github.com/taylorflatt/go-chat/chatclient"
 	context "golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/reflection"
 )
 
@@ -18,13 +27,28 @@ const (
 	port = ":12021"
 )
 
+// drainTimeout is how long a stream is given to pass on the last messages sent to a client that
+// was removed before it is closed.
+const drainTimeout = 2 * time.Second
+
 type server struct{}
 
 type Group struct {
 	name      string
+	owner     string // The client who created the group and moderates it.
 	ch        chan pb.ChatMessage
 	clients   []string
 	WaitGroup *sync.WaitGroup
+	seq       uint64                    // Id of the latest message sent to the group.
+	history   []pb.ChatMessage          // Every message sent to the group, ordered by id.
+	read      map[string]uint64         // Id of the last message each client has read.
+	threads   map[uint64][]uint64       // Ids of the replies to each message that started a thread.
+	encrypted bool                      // Whether messages are end-to-end encrypted by the clients.
+	keyEpoch  uint64                    // The epoch of the current group key in encrypted groups.
+	keys      map[string]*pb.WrappedKey // The current group key wrapped for each member.
+	topic     string                    // What the group is about, set by its members.
+	hooks     map[string]*Hook          // Incoming webhooks, by token.
+	limiter   *Limiter                  // Limits how fast the group is sent messages, if set.
 }
 
 type Client struct {
@@ -32,40 +56,71 @@ type Client struct {
 	groups    []string
 	ch        chan pb.ChatMessage
 	WaitGroup *sync.WaitGroup
+	mentions  []pb.ChatMessage // The most recent messages that mentioned the client.
+	publicKey []byte           // The client's X25519 public key for end-to-end encryption.
+	bot       bool             // Whether the client is a bot rather than a person.
+	connected time.Time        // When the client registered.
+	address   string           // The address the client registered from.
+	token     string           // Identifies calls from the session that registered the client.
+	streams   int              // How many chat streams the client has open.
+	quit      chan struct{}    // Closed when the client is removed, to end its stream.
+	slow      chan struct{}    // Signalled when the client's channel fills up because it isn't reading.
+	flood     *Flood           // How fast the client is sending messages.
 }
 
 var lock = &sync.RWMutex{}
 var clients = make(map[string]*Client)
 var groups = make(map[string]*Group)
 
-// AddClient adds a new client n to the server.
-// It doesn't return anything.
-func AddClient(n string) {
+// AddClient adds a new client n, connecting from addr, to the server, marked as a bot if bot is
+// set, provided the name isn't taken. Calls from the client's session carry token.
+// It returns an error.
+func AddClient(n string, bot bool, addr string, token string) error {
 
 	lock.Lock()
 	defer lock.Unlock()
 
+	if NameTakenLocked(n) {
+		return AlreadyExists("client", n)
+	}
+
 	c := &Client{
 		name:      n,
 		ch:        make(chan pb.ChatMessage, 100),
 		WaitGroup: &sync.WaitGroup{},
+		bot:       bot,
+		connected: time.Now(),
+		address:   addr,
+		token:     token,
+		quit:      make(chan struct{}),
+		slow:      make(chan struct{}, 1),
+		flood:     NewFlood(),
 	}
 
 	log.Print("[AddClient]: Registered client " + n)
 	clients[n] = c
+
+	return nil
 }
 
-// AddGroup adds a new group to the server.
+// AddGroup adds a new group n to the server moderated by client o. Encrypted groups only
+// relay messages that the clients have end-to-end encrypted.
 // It doesn't return anything.
-func AddGroup(n string) {
+func AddGroup(n string, o string, encrypted bool) {
 
 	lock.Lock()
 	defer lock.Unlock()
 
 	g := &Group{
 		name:      n,
+		owner:     o,
 		ch:        make(chan pb.ChatMessage, 100),
 		WaitGroup: &sync.WaitGroup{},
+		read:      make(map[string]uint64),
+		threads:   make(map[uint64][]uint64),
+		encrypted: encrypted,
+		hooks:     make(map[string]*Hook),
+		limiter:   NewGroupLimiter(),
 	}
 
 	log.Print("[AddGroup]: Added group " + g.name)
@@ -119,84 +174,137 @@ func InGroup(n string) bool {
 	return false
 }
 
+// IsMember checks whether client n is currently in group g.
+// It returns a bool value.
+func IsMember(n string, g string) bool {
+
+	lock.RLock()
+	defer lock.RUnlock()
+
+	return IsMemberLocked(n, g)
+}
+
+// IsMemberLocked is IsMember for callers that hold the lock.
+// It returns a bool value.
+func IsMemberLocked(n string, g string) bool {
+
+	grp, ok := groups[g]
+	if !ok {
+		return false
+	}
+
+	for _, c := range grp.clients {
+		if c == n {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GroupsOf finds every group that client n is currently in.
+// It returns a list of group names.
+func GroupsOf(n string) []string {
+
+	lock.RLock()
+	defer lock.RUnlock()
+
+	var in []string
+	for gName, g := range groups {
+		for _, c := range g.clients {
+			if c == n {
+				in = append(in, gName)
+			}
+		}
+	}
+
+	return in
+}
+
 // RemoveClient will remove a client from the server as well as any
 // groups that they are currently in.
 // It returns an error.
 func RemoveClient(name string) error {
 
-	// TODO: There is some deadlock here when a user attempts to quit
-	// 		 the chat app with !exit.
-
 	lock.Lock()
 	defer lock.Unlock()
 
-	if ClientExists(name) {
-		delete(clients, name)
-		log.Print("[RemoveClient]: Removed client " + name)
-		if InGroup(name) {
-			RemoveClientFromGroup(name)
-		} else {
-			log.Print("[RemoveClient]: " + name + " was not in any groups.")
-			return nil
+	if _, ok := clients[name]; !ok {
+		return NotFound("client", name)
+	}
+
+	if InGroup(name) {
+		for _, g := range append([]string(nil), clients[name].groups...) {
+			RemoveClientFromGroup(name, g)
 		}
+	} else {
+		log.Print("[RemoveClient]: " + name + " was not in any groups.")
 	}
 
-	return errors.New("[RemoveClient]: Client (" + name + ") doesn't exist")
+	close(clients[name].quit)
+	delete(clients, name)
+	log.Print("[RemoveClient]: Removed client " + name)
+
+	return nil
 }
 
-// AddClientToGroup will add a client to a group.
+// AddClientToGroup will add a client to a group. The lock must be held.
 // It doesn't return anything.
 func AddClientToGroup(c string, g string) {
 
-	//lock.Lock()
-	//defer lock.Unlock()
-
 	groups[g].WaitGroup.Add(1)
 	defer groups[g].WaitGroup.Done()
 
 	groups[g].clients = append(groups[g].clients, c)
 	clients[c].groups = append(clients[c].groups, g)
+	EmitMembership("join", c, g)
 
 	log.Println("[AddClientToGroup] Added " + c + " to " + g)
 }
 
-// RemoveClientFromGroup will remove a client from a specific group. It will also
-// delete a group if the client is the last one leaving it.
+// RemoveClientFromGroup will remove client n from group gName. It will also
+// delete the group and its files if the client is the last one leaving it. The lock must be
+// held.
 // It returns an error.
-func RemoveClientFromGroup(n string) error {
+func RemoveClientFromGroup(n string, gName string) error {
 
-	for _, g := range groups {
+	if g, ok := groups[gName]; ok {
 		for i, c := range g.clients {
 			if n == c {
 				c := clients[n].groups
 				// Remove the group from the user.
-				for i, _ := range c {
-					if n == g.name {
+				for i := range c {
+					if c[i] == g.name {
 						c[i] = c[len(c)-1]
-						c = c[:len(c)-1]
-						clients[n].groups = c
+						clients[n].groups = c[:len(c)-1]
+						break
 					}
 				}
 				if len(g.clients) == 1 {
 					delete(groups, g.name)
+					DeleteGroupFilesLocked(g.name)
 				} else {
 					c := g.clients
 					c[i] = c[len(c)-1]
 					c = c[:len(c)-1]
 					g.clients = c
 				}
+				EmitMembership("leave", n, g.name)
 				return nil
 			}
 		}
 	}
 
-	return errors.New("no user found in the group list. Something went wrong")
+	return errors.New("no user found in the group list of " + gName + ". Something went wrong")
 }
 
 // GetClientList will get all of the currently connected clients to the server.
 // It returns a list of connected clients.
 func (s *server) GetClientList(ctx context.Context, in *pb.Empty) (*pb.ClientList, error) {
 
+	lock.RLock()
+	defer lock.RUnlock()
+
 	var c []string
 	for key := range clients {
 		c = append(c, key)
@@ -205,22 +313,51 @@ func (s *server) GetClientList(ctx context.Context, in *pb.Empty) (*pb.ClientLis
 	log.Print("[GetClientList]: Returned list of current groups ")
 	log.Print(c)
 
-	return &pb.ClientList{Clients: c}, nil
+	return &pb.ClientList{Clients: c, Bots: Bots(c)}, nil
+}
+
+// Bots checks which of the clients named in cs are bots. The lock must be held.
+// It returns whether each client is a bot, matched by index to cs.
+func Bots(cs []string) []bool {
+
+	b := make([]bool, len(cs))
+	for i, n := range cs {
+		if c, ok := clients[n]; ok {
+			b[i] = c.bot
+		}
+	}
+
+	return b
 }
 
-// GetGroupList will get all of the groups currently registered on the server.
+// GetGroupList will get all of the groups currently registered on the server along with
+// the number of messages in each that the requesting client hasn't read. Anyone can list the
+// groups, but a client's unread counts are only given to its own session.
 // It returns a list of groups.
-func (s *server) GetGroupList(ctx context.Context, in *pb.Empty) (*pb.GroupList, error) {
+func (s *server) GetGroupList(ctx context.Context, in *pb.ClientInfo) (*pb.GroupList, error) {
+
+	if in.Sender != "" {
+		if err := Authenticate(ctx, in.Sender); err != nil {
+			return nil, err
+		}
+	}
+
+	lock.RLock()
+	defer lock.RUnlock()
 
 	var g []string
-	for gName := range groups {
+	var u []uint64
+	var e []bool
+	for gName, grp := range groups {
 		g = append(g, gName)
+		u = append(u, UnreadCount(grp, in.Sender))
+		e = append(e, grp.encrypted)
 	}
 
 	log.Print("[GetGroupList]: Returned list of current groups ")
 	log.Print(g)
 
-	return &pb.GroupList{Groups: g}, nil
+	return &pb.GroupList{Groups: g, Unread: u, Encrypted: e}, nil
 }
 
 // GetGroupClientList will get all of the clients who is current part of a specific group.
@@ -230,27 +367,55 @@ func (s *server) GetGroupClientList(ctx context.Context, in *pb.GroupInfo) (*pb.
 	g := in.GroupName
 
 	if !GroupExists(g) {
-		return &pb.ClientList{}, errors.New("that group doesn't exist")
+		return &pb.ClientList{}, NotFound("group", g)
 	}
 
-	lst := groups[g].clients
+	lock.RLock()
+	defer lock.RUnlock()
+
+	grp, ok := groups[g]
+	if !ok {
+		return &pb.ClientList{}, NotFound("group", g)
+	}
+	lst := append([]string(nil), grp.clients...)
 
 	log.Print("[GetGroupClientList]: For group " + g + " returned members ")
 	log.Print(lst)
 
-	return &pb.ClientList{Clients: lst}, nil
+	return &pb.ClientList{Clients: lst, Bots: Bots(lst)}, nil
 }
 
 // Register will add the user to the server's collection of users (and by extension restrict the username).
+// The session is given a token in the response header that authenticates its later calls.
 // It returns an empty object and an error.
 func (s *server) Register(ctx context.Context, in *pb.ClientInfo) (*pb.Empty, error) {
 
-	n := in.Sender
-	if ClientExists(n) {
-		return nil, errors.New("that name already exists")
+	if err := AllowCall(ctx); err != nil {
+		return nil, err
+	}
+
+	n, err := CheckName("sender", "username", in.Sender, minUserLen, true)
+	if err != nil {
+		return nil, err
 	}
 
-	AddClient(n)
+	addr := ""
+	if p, ok := peer.FromContext(ctx); ok {
+		addr = p.Addr.String()
+	}
+
+	token, err := NewToken()
+	if err != nil {
+		return nil, StatusError(codes.Internal, "couldn't start a session")
+	}
+
+	if err := AddClient(n, in.Bot, addr, token); err != nil {
+		return nil, err
+	}
+
+	// The session sends the token back with every call, proving the calls are its own.
+	grpc.SetHeader(ctx, metadata.Pairs(chatclient.TokenHeader, token))
+
 	return &pb.Empty{}, nil
 }
 
@@ -263,13 +428,15 @@ func (s *server) UnRegister(ctx context.Context, in *pb.ClientInfo) (*pb.Empty,
 
 	log.Print("[UnRegister]: Unregistering client " + u)
 
-	err := RemoveClient(u)
+	err := DisconnectClient(u)
 
 	log.Println("[UnRegister]: The following are the remaining clients, ")
 	keys := []string{}
+	lock.RLock()
 	for _, c := range clients {
 		keys = append(keys, c.name)
 	}
+	lock.RUnlock()
 	log.Println(keys)
 
 	if err != nil {
@@ -279,6 +446,79 @@ func (s *server) UnRegister(ctx context.Context, in *pb.ClientInfo) (*pb.Empty,
 	return &pb.Empty{}, nil
 }
 
+// Rename changes the name a client is known by. Their group memberships, read markers and keys
+// move to the new name and the groups they are in are told about it.
+// It returns an empty object and an error.
+func (s *server) Rename(ctx context.Context, in *pb.NameChange) (*pb.Empty, error) {
+
+	old := in.Client
+	n, err := CheckName("name", "new name", in.Name, minUserLen, true)
+	if err != nil {
+		return nil, err
+	}
+
+	lock.Lock()
+
+	c, ok := clients[old]
+	if !ok {
+		lock.Unlock()
+		return nil, NotFound("client", old)
+	}
+	if NameTakenLocked(n) {
+		lock.Unlock()
+		return nil, AlreadyExists("client", n)
+	}
+
+	delete(clients, old)
+	c.name = n
+	clients[n] = c
+
+	var member []string
+	for gName, g := range groups {
+		for i, m := range g.clients {
+			if m == old {
+				g.clients[i] = n
+				member = append(member, gName)
+			}
+		}
+		if g.owner == old {
+			g.owner = n
+		}
+		if id, ok := g.read[old]; ok {
+			delete(g.read, old)
+			g.read[n] = id
+		}
+		if k, ok := g.keys[old]; ok {
+			delete(g.keys, old)
+			k.Client = n
+			g.keys[n] = k
+		}
+	}
+	lock.Unlock()
+
+	log.Print("[Rename]: " + old + " is now known as " + n)
+
+	for _, g := range member {
+		Broadcast(g, pb.ChatMessage{Sender: old, Receiver: g, Kind: pb.Kind_NICK, Message: n})
+	}
+
+	return &pb.Empty{}, nil
+}
+
+// DisconnectClient removes client u from the server and asks the encrypted groups it was in
+// for a new key.
+// It returns an error.
+func DisconnectClient(u string) error {
+
+	left := GroupsOf(u)
+	err := RemoveClient(u)
+	for _, g := range left {
+		RequestRekey(g)
+	}
+
+	return err
+}
+
 // CreateGroup creates a new group provided it doesn't already exist.
 // It returns an empty object and an error.
 func (s *server) CreateGroup(ctx context.Context, in *pb.GroupInfo) (*pb.Empty, error) {
@@ -288,12 +528,21 @@ func (s *server) CreateGroup(ctx context.Context, in *pb.GroupInfo) (*pb.Empty,
 
 	log.Printf("[CreateGroup] " + cName + " is attempting to create " + gName)
 
+	if err := AllowCall(ctx); err != nil {
+		return &pb.Empty{}, err
+	}
+
+	gName, err := CheckName("groupName", "group name", gName, 1, false)
+	if err != nil {
+		return &pb.Empty{}, err
+	}
+
 	if !GroupExists(gName) {
-		AddGroup(gName)
+		AddGroup(gName, cName, in.Encrypted)
 		return &pb.Empty{}, nil
 	}
 
-	return &pb.Empty{}, errors.New("a group with that name already exists")
+	return &pb.Empty{}, AlreadyExists("group", gName)
 }
 
 // JoinGroup adds a user to an existing group.
@@ -305,13 +554,20 @@ func (s *server) JoinGroup(ctx context.Context, in *pb.GroupInfo) (*pb.Empty, er
 
 	log.Printf("[JoinGroup] Attempting to add " + c + " to " + g)
 
-	if GroupExists(g) {
-		AddClientToGroup(c, g)
-
-		return &pb.Empty{}, nil
+	lock.Lock()
+	if _, ok := groups[g]; !ok {
+		lock.Unlock()
+		return &pb.Empty{}, NotFound("group", g)
+	} else if _, ok := clients[c]; !ok {
+		lock.Unlock()
+		return &pb.Empty{}, NotFound("client", c)
 	}
+	AddClientToGroup(c, g)
+	lock.Unlock()
+
+	RequestRekey(g)
 
-	return &pb.Empty{}, errors.New("a group with that name doesn't exist")
+	return &pb.Empty{}, nil
 }
 
 // LeaveRoom removes the user from their group.
@@ -322,18 +578,184 @@ func (s *server) LeaveRoom(ctx context.Context, in *pb.GroupInfo) (*pb.Empty, er
 	g := in.GroupName
 
 	if !GroupExists(g) {
-		return &pb.Empty{}, errors.New("the group " + g + " doesn't exist")
+		return &pb.Empty{}, NotFound("group", g)
 	} else if !ClientExists(u) {
-		return &pb.Empty{}, errors.New("the client " + g + " doesn't exist")
+		return &pb.Empty{}, NotFound("client", u)
 	} else {
 		die := pb.ChatMessage{Sender: u, Receiver: g, Message: u + " left chat!\n"}
 		Broadcast(g, die)
-		RemoveClientFromGroup(u)
+
+		lock.Lock()
+		RemoveClientFromGroup(u, g)
+		lock.Unlock()
+
+		RequestRekey(g)
 		return &pb.Empty{}, nil
 	}
 }
 
-// RouteChat handles the routing of all messages on the stream.
+// MarkRead records that a client has read every message in a group up to the given id.
+// It returns an empty object and an error.
+func (s *server) MarkRead(ctx context.Context, in *pb.ReadMarker) (*pb.Empty, error) {
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	g, ok := groups[in.GroupName]
+	if !ok {
+		return &pb.Empty{}, NotFound("group", in.GroupName)
+	}
+
+	UpdateReadMarker(g, in.Client, in.Id)
+	return &pb.Empty{}, nil
+}
+
+// GetSeenBy gets the members of a group who have read the latest message sent to it.
+// It returns the id of the latest message with the members who have seen it and an error.
+func (s *server) GetSeenBy(ctx context.Context, in *pb.GroupInfo) (*pb.SeenInfo, error) {
+
+	lock.RLock()
+	defer lock.RUnlock()
+
+	g, ok := groups[in.GroupName]
+	if !ok {
+		return &pb.SeenInfo{}, NotFound("group", in.GroupName)
+	}
+
+	return &pb.SeenInfo{Id: g.seq, Clients: SeenBy(g)}, nil
+}
+
+// EditMessage replaces the text of a stored message and lets the rest of the group know.
+// Only the original sender or the group's moderator may edit a message.
+// It returns an empty object and an error.
+func (s *server) EditMessage(ctx context.Context, in *pb.MessageEdit) (*pb.Empty, error) {
+
+	lock.Lock()
+
+	if g, ok := groups[in.GroupName]; ok && g.encrypted {
+		lock.Unlock()
+		return &pb.Empty{}, FailedPrecondition("group", in.GroupName, "messages in encrypted groups can't be edited")
+	}
+
+	m, err := FindEditableMessage(in)
+	if err != nil {
+		lock.Unlock()
+		return &pb.Empty{}, err
+	}
+
+	m.Message = in.Message
+	m.Edited = true
+	lock.Unlock()
+
+	log.Printf("[EditMessage]: %s edited message %d in %s", in.Client, in.Id, in.GroupName)
+
+	Broadcast(in.GroupName, pb.ChatMessage{Sender: in.Client, Receiver: in.GroupName, Message: in.Message, Id: in.Id, Kind: pb.Kind_EDIT})
+	return &pb.Empty{}, nil
+}
+
+// DeleteMessage removes the text of a stored message and lets the rest of the group know.
+// Only the original sender or the group's moderator may delete a message.
+// It returns an empty object and an error.
+func (s *server) DeleteMessage(ctx context.Context, in *pb.MessageEdit) (*pb.Empty, error) {
+
+	lock.Lock()
+
+	m, err := FindEditableMessage(in)
+	if err != nil {
+		lock.Unlock()
+		return &pb.Empty{}, err
+	}
+
+	m.Message = ""
+	m.Deleted = true
+	lock.Unlock()
+
+	log.Printf("[DeleteMessage]: %s deleted message %d in %s", in.Client, in.Id, in.GroupName)
+
+	Broadcast(in.GroupName, pb.ChatMessage{Sender: in.Client, Receiver: in.GroupName, Id: in.Id, Kind: pb.Kind_DELETE})
+	return &pb.Empty{}, nil
+}
+
+// React toggles a client's emoji reaction on a stored message and lets the rest of the group know.
+// It returns the reaction event, carrying the message's updated reactions, and an error.
+func (s *server) React(ctx context.Context, in *pb.ReactionInfo) (*pb.ChatMessage, error) {
+
+	e := strings.TrimSpace(in.Emoji)
+	if e == "" || len(e) > maxReactionLen {
+		return nil, InvalidArgument("emoji", "that isn't a valid reaction")
+	}
+
+	lock.Lock()
+
+	g, ok := groups[in.GroupName]
+	if !ok {
+		lock.Unlock()
+		return nil, NotFound("group", in.GroupName)
+	}
+
+	m := FindMessage(g, in.Id)
+	if m == nil || m.Deleted {
+		lock.Unlock()
+		return nil, NotFound("message", strconv.FormatUint(in.Id, 10))
+	}
+
+	k := pb.Kind_UNREACT
+	if ToggleReaction(m, in.Client, e) {
+		k = pb.Kind_REACT
+	}
+
+	ev := pb.ChatMessage{Sender: in.Client, Receiver: in.GroupName, Message: e, Id: in.Id, Kind: k, Reactions: CopyReactions(m.Reactions)}
+	lock.Unlock()
+
+	log.Printf("[React]: %s toggled %s on message %d in %s", in.Client, e, in.Id, in.GroupName)
+
+	Broadcast(in.GroupName, ev)
+	return &ev, nil
+}
+
+// GetThread gets a message along with every reply in the thread it belongs to.
+// It returns the message that started the thread followed by its replies and an error.
+func (s *server) GetThread(ctx context.Context, in *pb.MessageRef) (*pb.MessageList, error) {
+
+	lock.RLock()
+	defer lock.RUnlock()
+
+	g, ok := groups[in.GroupName]
+	if !ok {
+		return &pb.MessageList{}, NotFound("group", in.GroupName)
+	}
+
+	msgs := ThreadMessages(g, in.Id)
+	if msgs == nil {
+		return &pb.MessageList{}, NotFound("message", strconv.FormatUint(in.Id, 10))
+	}
+
+	return &pb.MessageList{Messages: msgs}, nil
+}
+
+// GetMentions gets the most recent messages that mentioned a client, oldest first.
+// It returns a list of messages and an error.
+func (s *server) GetMentions(ctx context.Context, in *pb.ClientInfo) (*pb.MessageList, error) {
+
+	lock.RLock()
+	defer lock.RUnlock()
+
+	c, ok := clients[in.Sender]
+	if !ok {
+		return &pb.MessageList{}, NotFound("client", in.Sender)
+	}
+
+	var msgs []*pb.ChatMessage
+	for i := range c.mentions {
+		msgs = append(msgs, CopyMessage(&c.mentions[i]))
+	}
+
+	return &pb.MessageList{Messages: msgs}, nil
+}
+
+// RouteChat handles the routing of all messages on the stream. The first message only
+// identifies the client the stream belongs to. Every message after it is sent to the group
+// it names, so a client can keep one stream open while moving between groups.
 // It returns an error.
 func (s *server) RouteChat(stream pb.Chat_RouteChatServer) error {
 
@@ -342,72 +764,280 @@ func (s *server) RouteChat(stream pb.Chat_RouteChatServer) error {
 	if err != nil {
 		return err
 	}
+	Sanitize(msg)
 
 	log.Printf("[RouteChat]: Client " + msg.Sender + " sent " + msg.Receiver + " a message: " + msg.Message)
 
+	// Everything sent on the stream is done as this client, so it has to be the client's session.
+	if err := Authenticate(stream.Context(), msg.Sender); err != nil {
+		return err
+	}
+
+	lock.RLock()
+	c, ok := clients[msg.Sender]
+	lock.RUnlock()
+
+	if !ok {
+		return NotFound("client", msg.Sender)
+	}
+
+	// Anything queued before the stream opened is about to be sent, so the client has caught up.
+	select {
+	case <-c.slow:
+	default:
+	}
+
+	lock.Lock()
+	c.streams++
+	lock.Unlock()
+	defer func() {
+		lock.Lock()
+		c.streams--
+		lock.Unlock()
+	}()
+
 	outbox := make(chan pb.ChatMessage, 100)
+	written := make(chan struct{})
 
 	go ListenToClient(stream, outbox)
+	go WriteToClient(stream, c, written)
 
 	for {
 		select {
-		case outMsg := <-outbox:
-			Broadcast(msg.Receiver, outMsg)
-		case inMsg := <-clients[msg.Sender].ch:
-			log.Println("Sending message to channel: ")
-			log.Println(clients[msg.Sender])
-			log.Println("[LOOK HERE]: Sending message to STREAM: ")
-			log.Println(stream)
-			stream.Send(&inMsg)
+		case outMsg, open := <-outbox:
+			if !open {
+				// Nobody would read the client's channel any more, so it can't stay registered.
+				lock.RLock()
+				n := c.name
+				current := clients[n] == c
+				lock.RUnlock()
+
+				if current {
+					log.Print("[RouteChat]: " + n + " disconnected without unregistering")
+					DisconnectClient(n)
+				}
+				return nil
+			}
+			if err := CheckMessage(&outMsg); err != nil {
+				log.Print("[RouteChat]: Rejected a message from " + outMsg.Sender + ": " + err.Error())
+				Deliver(c, *Rejected(ClientName(c), outMsg, err))
+				continue
+			}
+			if n := ClientName(c); outMsg.Sender != n {
+				// Everything from here on trusts the sender, so it has to be the stream's client.
+				log.Print("[RouteChat]: Rejected a message from " + n + " claiming to be from " + outMsg.Sender)
+				Deliver(c, *Rejected(n, outMsg, InvalidArgument("sender", "you can only send messages as "+n)))
+				continue
+			}
+			if why, ok := c.flood.Allow(outMsg); !ok {
+				log.Print("[RouteChat]: Dropped a message from " + outMsg.Sender + ": " + why)
+				Deliver(c, *RateLimited(ClientName(c), outMsg, why))
+				continue
+			}
+			if outMsg.Kind == pb.Kind_COMMAND {
+				Deliver(c, *CommandResult(ClientName(c), outMsg))
+				continue
+			}
+			if outMsg.Kind == pb.Kind_DIRECT {
+				if err := SendDirect(outMsg); err != nil {
+					log.Print(err)
+				}
+				continue
+			}
+			if !IsMember(outMsg.Sender, outMsg.Receiver) {
+				log.Print("[RouteChat]: Dropped a message from " + outMsg.Sender + " to " + outMsg.Receiver + " which they aren't in")
+				continue
+			}
+			if !AcceptsMessage(outMsg.Receiver, outMsg) {
+				log.Print("[RouteChat]: Dropped a plaintext message from " + outMsg.Sender + " to encrypted group " + outMsg.Receiver)
+				continue
+			}
+			Broadcast(outMsg.Receiver, outMsg)
+		case <-c.slow:
+			// The client isn't reading what it is sent, so it can't stay registered either.
+			lock.RLock()
+			n := c.name
+			current := clients[n] == c
+			lock.RUnlock()
+
+			if current {
+				log.Print("[RouteChat]: " + n + " isn't keeping up with its messages, disconnecting it")
+				DisconnectClient(n)
+			}
+		case <-c.quit:
+			// The client was removed without its stream ending, e.g. by an operator. Give the
+			// writer a moment to pass on anything it was sent on the way out. Returning ends the
+			// stream, which frees a writer stuck on a client that isn't reading.
+			select {
+			case <-written:
+			case <-time.After(drainTimeout):
+			}
+			return nil
 		}
 	}
 }
 
+// WriteToClient sends the messages queued on client c's channel down its stream until the
+// client is removed, then passes on whatever is left in the channel and closes written. A
+// client that stops reading only holds up this goroutine, never the senders.
+// It doesn't return anything.
+func WriteToClient(stream pb.Chat_RouteChatServer, c *Client, written chan<- struct{}) {
+
+	defer close(written)
+
+	for {
+		select {
+		case msg := <-c.ch:
+			if err := stream.Send(&msg); err != nil {
+				log.Print("[WriteToClient] The stream failed: " + err.Error())
+				return
+			}
+		case <-c.quit:
+			for {
+				select {
+				case msg := <-c.ch:
+					if stream.Send(&msg) != nil {
+						return
+					}
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// Deliver queues msg on client c's channel without waiting for room, so it is safe to call
+// with the lock held. A client whose channel is full isn't reading what it is sent, so the
+// message is dropped and its stream is told to disconnect it.
+// It returns whether the message was queued.
+func Deliver(c *Client, msg pb.ChatMessage) bool {
+
+	select {
+	case c.ch <- msg:
+		return true
+	default:
+	}
+
+	log.Print("[Deliver]: A client's channel is full, dropping a message from " + msg.Sender + " to " + msg.Receiver)
+	select {
+	case c.slow <- struct{}{}:
+	default:
+	}
+
+	return false
+}
+
 // Broadcast takes any messages that need to be sent and sorts them by group. It then
-// adds the message to the channel of each member of that group.
+// adds the message to the channel of each member of that group, without waiting on members
+// who aren't keeping up (see Deliver). Regular messages and
+// attachments are stored and echoed back to their sender so it learns the id they were given.
+// Webhooks are queued for the events they want and delivered in the background.
 // It doesn't return anything.
 func Broadcast(gName string, msg pb.ChatMessage) {
 
 	lock.Lock()
 	defer lock.Unlock()
 
+	BroadcastLocked(gName, msg)
+}
+
+// BroadcastLocked is Broadcast for callers that check or change a group in the same step as
+// telling its members. The lock must be held.
+// It doesn't return anything.
+func BroadcastLocked(gName string, msg pb.ChatMessage) {
+
 	for gn := range groups {
 		log.Printf("[Broadcast]: I found " + gn + ".")
 		if gn == gName {
 			log.Printf("[Broadcast]: Client " + msg.Sender + " sent " + msg.Receiver + " a message: " + msg.Message)
+			if IsStored(msg.Kind) {
+				msg = StoreMessage(groups[gn], msg)
+				NotifyMentions(groups[gn], msg)
+			}
+			if ev, ok := WebhookEventFor(groups[gn], msg); ok {
+				EmitWebhook(ev)
+			}
 			for _, c := range groups[gn].clients {
 				log.Printf("[Broadcast]: I found " + c + " in gName")
 				if c == msg.Sender && msg.Message == msg.Sender+" left chat!\n" {
 					log.Printf("[Broadcast]: ADDING THE KILL MESSAGE TO " + c)
-					clients[c].ch <- msg
-				} else if c != msg.Sender {
+					Deliver(clients[c], msg)
+				} else if c != msg.Sender || IsStored(msg.Kind) {
 					log.Printf("[Broadcast] Adding the message to " + c + "'s channel.")
-					clients[c].ch <- msg
+					Deliver(clients[c], msg)
 				}
 			}
 		}
 	}
 }
 
-// ListenToClient listens on the incoming stream for any messages. It adds those messages to the channel.
+// ListenToClient listens on the incoming stream for any messages. It adds those messages to the channel
+// and closes it once the stream ends.
 // It doesn't return anything.
 func ListenToClient(stream pb.Chat_RouteChatServer, messages chan<- pb.ChatMessage) {
 
+	defer close(messages)
+
 	for {
 		msg, err := stream.Recv()
 		if err == io.EOF {
+			return
 		}
 		if err != nil {
-		} else {
-			log.Printf("[ListenToClient] Client " + msg.Sender + " sent " + msg.Receiver + " a message: " + msg.Message)
-			messages <- *msg
+			log.Print("[ListenToClient] The stream failed: " + err.Error())
+			return
 		}
 
+		log.Print("[ListenToClient] Client " + msg.Sender + " sent " + msg.Receiver + " a message: " + msg.Message)
+		messages <- *msg
 	}
 }
 
 func main() {
 
+	hooks := flag.String("webhooks", "", "A JSON `file` listing URLs to post group events to.")
+	flag.Float64Var(&limits.MessageRate, "message-rate", limits.MessageRate, "The `number` of messages each client may send per second, or 0 for no limit.")
+	flag.IntVar(&limits.MessageBurst, "message-burst", limits.MessageBurst, "The `number` of messages each client may send at once.")
+	flag.Float64Var(&limits.GroupRate, "group-rate", limits.GroupRate, "The `number` of messages each group may be sent per second, or 0 for no limit.")
+	flag.IntVar(&limits.GroupBurst, "group-burst", limits.GroupBurst, "The `number` of messages each group may be sent at once.")
+	flag.Float64Var(&limits.CallRate, "call-rate", limits.CallRate, "The `number` of logins and group creations each address may make per second, or 0 for no limit.")
+	flag.IntVar(&limits.CallBurst, "call-burst", limits.CallBurst, "The `number` of logins and group creations each address may make at once.")
+	adminAddr := flag.String("admin", "", "The `address` to serve the Admin service on, e.g. localhost:12023. It is off unless set, and anyone who can reach it can manage the server.")
+	ircAddr := flag.String("irc", "", "The `address` to accept IRC clients on, e.g. :6667. IRC is off unless it is set.")
+	httpAddr := flag.String("http", "", "The `address` to serve HTTP on, e.g. :12022, for incoming webhooks, the WebSocket gateway and the REST API. HTTP is off unless it is set.")
+	flag.Parse()
+
+	if err := limits.Check(); err != nil {
+		log.Fatalf("Invalid limits: %v", err)
+	}
+
+	if *hooks != "" {
+		webhooksFile = *hooks
+		if err := LoadWebhooks(*hooks); err != nil {
+			log.Fatalf("Failed to load the webhooks %v", err)
+		}
+	}
+
+	if *httpAddr != "" {
+		go ServeHTTP(*httpAddr)
+	}
+
+	if *ircAddr != "" {
+		go ServeIRC(*ircAddr)
+	}
+
+	if *adminAddr != "" {
+		go ServeAdmin(*adminAddr)
+	}
+
+	if err := os.MkdirAll(fileDir, 0700); err != nil {
+		log.Fatalf("Failed to create the file directory %v", err)
+	}
+	if err := LoadFileStorage(); err != nil {
+		log.Fatalf("Failed to read the file directory %v", err)
+	}
+
 	lis, err := net.Listen("tcp", port)
 
 	if err != nil {
@@ -415,7 +1045,7 @@ func main() {
 	}
 
 	// Initializes the gRPC server.
-	s := grpc.NewServer()
+	s := grpc.NewServer(grpc.ChainUnaryInterceptor(StripForwardingUnary, SanitizeUnary, AuthenticateUnary), grpc.ChainStreamInterceptor(StripForwardingStream))
 
 	// Register the server with gRPC.
 	pb.RegisterChatServer(s, &server{})
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pb "github.com/taylorflatt/go-chat"
+	"github.com/taylorflatt/go-chat/chatclient"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRegisterDuplicateName(t *testing.T) {
+
+	ts := startServer(t)
+	ts.login(t, "alice")
+
+	if err := ts.connect(t).Login("alice"); err == nil {
+		t.Fatal("a second client logged in as alice")
+	}
+
+	ts.login(t, "bob")
+}
+
+func TestRegisterSameNameConcurrently(t *testing.T) {
+
+	ts := startServer(t)
+	c := ts.connect(t).Client()
+
+	const tries = 20
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	ok := 0
+	for i := 0; i < tries; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.Register(context.Background(), &pb.ClientInfo{Sender: "carol"}); err == nil {
+				mu.Lock()
+				ok++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if ok != 1 {
+		t.Fatalf("%d of %d registrations of the same name succeeded, want 1", ok, tries)
+	}
+}
+
+func TestCreateJoinLeave(t *testing.T) {
+
+	ts := startServer(t)
+	alice := ts.member(t, "alice", "general", true)
+
+	if err := alice.Create("general", false); err == nil {
+		t.Error("created general twice")
+	}
+
+	bob := ts.login(t, "bob")
+	if err := bob.Join("nowhere"); err == nil {
+		t.Error("joined a group that doesn't exist")
+	}
+	if err := bob.Join("general"); err != nil {
+		t.Fatalf("bob joining general: %v", err)
+	}
+
+	if got := sorted(members(t, alice, "general")); strings.Join(got, ",") != "alice,bob" {
+		t.Errorf("general has %v, want [alice bob]", got)
+	}
+
+	if err := bob.Leave(); err != nil {
+		t.Fatalf("bob leaving general: %v", err)
+	}
+	if got := members(t, alice, "general"); strings.Join(got, ",") != "alice" {
+		t.Errorf("general has %v after bob left, want [alice]", got)
+	}
+
+	if err := alice.Leave(); err != nil {
+		t.Fatalf("alice leaving general: %v", err)
+	}
+	if got := groupNames(t, alice); len(got) != 0 {
+		t.Errorf("the groups are %v after everyone left, want none", got)
+	}
+}
+
+func TestBroadcast(t *testing.T) {
+
+	ts := startServer(t)
+
+	const n = 6
+	ss := make([]*chatclient.Session, n)
+	for i := range ss {
+		ss[i] = ts.member(t, fmt.Sprintf("user%d", i), "general", i == 0)
+	}
+
+	// Everyone already in the group sees each later join, so drain those first.
+	for i, s := range ss {
+		for j := i + 1; j < n; j++ {
+			u := fmt.Sprintf("user%d", j)
+			expect(t, s, u+" joining", func(m pb.ChatMessage) bool { return m.Sender == u && m.Message == "joined chat!\n" })
+		}
+	}
+
+	if err := ss[2].Send("hello everyone"); err != nil {
+		t.Fatalf("sending: %v", err)
+	}
+
+	for _, s := range ss {
+		m := expect(t, s, "the broadcast", func(m pb.ChatMessage) bool { return m.Message == "hello everyone\n" })
+		if m.Sender != "user2" || m.Receiver != "general" || m.Id == 0 {
+			t.Errorf("%s got %+v", s.User(), m)
+		}
+	}
+}
+
+func TestLeaveNotification(t *testing.T) {
+
+	ts := startServer(t)
+	alice := ts.member(t, "alice", "general", true)
+	bob := ts.member(t, "bob", "general", false)
+
+	if err := bob.Leave(); err != nil {
+		t.Fatalf("bob leaving: %v", err)
+	}
+
+	expect(t, alice, "bob's leave notice", func(m pb.ChatMessage) bool {
+		return m.Sender == "bob" && m.Message == "bob left chat!\n"
+	})
+}
+
+func TestUnregisterCleanup(t *testing.T) {
+
+	ts := startServer(t)
+	alice := ts.member(t, "alice", "general", true)
+	bob := ts.member(t, "bob", "general", false)
+	carol := ts.member(t, "carol", "solo", true)
+
+	bob.Close()
+	carol.Close()
+
+	eventually(t, "bob and carol to be unregistered", func() bool {
+		l, err := alice.Client().GetClientList(context.Background(), &pb.Empty{})
+		return err == nil && strings.Join(l.Clients, ",") == "alice"
+	})
+
+	if got := members(t, alice, "general"); strings.Join(got, ",") != "alice" {
+		t.Errorf("general has %v after bob unregistered, want [alice]", got)
+	}
+	if got := groupNames(t, alice); strings.Join(got, ",") != "general" {
+		t.Errorf("the groups are %v after carol unregistered, want [general]", got)
+	}
+
+	// The name is free again.
+	ts.login(t, "bob")
+}
+
+func TestConcurrentMembership(t *testing.T) {
+
+	ts := startServer(t)
+	ts.member(t, "host", "general", true)
+
+	const n = 10
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		s := ts.login(t, fmt.Sprintf("user%d", i))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 5; j++ {
+				if err := s.Join("general"); err != nil {
+					t.Errorf("%s joining: %v", s.User(), err)
+					return
+				}
+				s.Send("hi")
+				if err := s.Leave(); err != nil {
+					t.Errorf("%s leaving: %v", s.User(), err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	host := ts.connect(t)
+	eventually(t, "everyone but the host to have left", func() bool {
+		l, err := host.Client().GetGroupClientList(context.Background(), &pb.GroupInfo{GroupName: "general"})
+		return err == nil && strings.Join(l.Clients, ",") == "host"
+	})
+}
+
+func TestSlowClient(t *testing.T) {
+
+	ts := startServer(t)
+	alice := ts.member(t, "alice", "general", true)
+
+	var echoes atomic.Int64
+	go func() {
+		for m := range alice.Events {
+			if m.Sender == "alice" {
+				echoes.Add(1)
+			}
+		}
+	}()
+
+	// The slow client opens its stream but never reads from it. Its window is kept small so
+	// the stream backs up quickly.
+	ts.raw(t, "slow", "general", grpc.WithInitialWindowSize(1<<16), grpc.WithInitialConnWindowSize(1<<16))
+
+	// Alice keeps up with her own echoes, so only the slow client falls behind.
+	text := strings.Repeat("x", 2000)
+	for sent := 1; sent <= 500; sent++ {
+		if err := alice.Send(text); err != nil {
+			t.Fatalf("sending: %v", err)
+		}
+		if sent%50 == 0 {
+			eventually(t, "alice's echoes", func() bool { return echoes.Load() >= int64(sent) })
+		}
+	}
+
+	eventually(t, "the slow client to be disconnected", func() bool {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		l, err := alice.Client().GetClientList(ctx, &pb.Empty{})
+		if err != nil {
+			t.Fatalf("the server stopped answering: %v", err)
+		}
+		return strings.Join(l.Clients, ",") == "alice"
+	})
+
+	ts.login(t, "bob")
+}
+
+func TestSenderMustBeStreamClient(t *testing.T) {
+
+	ts := startServer(t)
+	alice := ts.member(t, "alice", "general", true)
+	bob := ts.member(t, "bob", "general", false)
+	mallory := ts.raw(t, "mallory", "general")
+
+	for _, m := range []*pb.ChatMessage{
+		{Sender: "alice", Receiver: "general", Message: "forged\n"},
+		{Sender: "alice", Receiver: "bob", Message: "forged\n", Kind: pb.Kind_DIRECT},
+	} {
+		mallory.Send(m)
+		r := expectRaw(t, mallory, "the forged "+m.Kind.String()+" being turned away", func(m *pb.ChatMessage) bool { return m.Kind == pb.Kind_REJECTED })
+		if r.Message != "you can only send messages as mallory" {
+			t.Errorf("got %+v", r)
+		}
+	}
+
+	alice.Send("real")
+	expect(t, bob, "alice's real message", func(m pb.ChatMessage) bool {
+		if m.Message == "forged\n" {
+			t.Errorf("bob got the forged message %+v", m)
+		}
+		return m.Message == "real\n"
+	})
+}
+
+func TestCallsNeedOwnSession(t *testing.T) {
+
+	ts := startServer(t)
+	alice := ts.member(t, "alice", "general", true)
+	bob := ts.login(t, "bob").Client()
+	ctx := context.Background()
+
+	calls := map[string]func() error{
+		"UnRegister": func() error {
+			_, err := bob.UnRegister(ctx, &pb.ClientInfo{Sender: "alice"})
+			return err
+		},
+		"LeaveRoom": func() error {
+			_, err := bob.LeaveRoom(ctx, &pb.GroupInfo{Client: "alice", GroupName: "general"})
+			return err
+		},
+		"JoinGroup": func() error {
+			_, err := bob.JoinGroup(ctx, &pb.GroupInfo{Client: "alice", GroupName: "general"})
+			return err
+		},
+		"Rename": func() error {
+			_, err := bob.Rename(ctx, &pb.NameChange{Client: "alice", Name: "eve"})
+			return err
+		},
+		"MarkRead": func() error {
+			_, err := bob.MarkRead(ctx, &pb.ReadMarker{Client: "alice", GroupName: "general"})
+			return err
+		},
+	}
+	for name, call := range calls {
+		if err := call(); status.Code(err) != codes.Unauthenticated {
+			t.Errorf("%s as alice from bob's session got %v, want %v", name, err, codes.Unauthenticated)
+		}
+	}
+
+	if got := members(t, alice, "general"); len(got) != 1 || got[0] != "alice" {
+		t.Errorf("general has %v, want just alice", got)
+	}
+}
+
+// sorted sorts l.
+// It returns l.
+func sorted(l []string) []string {
+
+	sort.Strings(l)
+	return l
+}
+
+func TestGroupListWithoutLogin(t *testing.T) {
+
+	ts := startServer(t)
+	ts.member(t, "alice", "general", true)
+
+	l, err := ts.connect(t).Client().GetGroupList(context.Background(), &pb.ClientInfo{})
+	if err != nil || len(l.Groups) != 1 || l.Groups[0] != "general" {
+		t.Errorf("listing the groups without logging in got %v, %v", l, err)
+	}
+
+	_, err = ts.login(t, "bob").Client().GetGroupList(context.Background(), &pb.ClientInfo{Sender: "alice"})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("listing alice's unread counts from bob's session got %v, want %v", err, codes.Unauthenticated)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/duration"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errorDomain names the server in the ErrorInfo details of its errors.
+const errorDomain = "go-chat"
+
+// Reasons given in the ErrorInfo details of PermissionDenied errors, so clients can tell them
+// apart without reading the message.
+const (
+	reasonNotModerator = "NOT_MODERATOR" // Only the group's moderator can do it.
+	reasonNotMember    = "NOT_MEMBER"    // Only members of the group can do it.
+	reasonNotSender    = "NOT_SENDER"    // Only the sender of the message or the moderator can do it.
+)
+
+// StatusError creates an error with the status code c and message msg, carrying details that
+// tell clients more about what went wrong. Details that can't be attached are left out.
+// It returns the error.
+func StatusError(c codes.Code, msg string, details ...proto.Message) error {
+
+	st := status.New(c, msg)
+	if withDetails, err := st.WithDetails(details...); err == nil {
+		st = withDetails
+	}
+
+	return st.Err()
+}
+
+// NotFound creates the error for a kind of thing, e.g. a "group", called name that doesn't
+// exist.
+// It returns a NotFound error.
+func NotFound(kind string, name string) error {
+
+	return StatusError(codes.NotFound, "the "+kind+" "+name+" doesn't exist",
+		&errdetails.ResourceInfo{ResourceType: kind, ResourceName: name})
+}
+
+// AlreadyExists creates the error for a kind of thing called name that can't be created
+// because the name is taken.
+// It returns an AlreadyExists error.
+func AlreadyExists(kind string, name string) error {
+
+	return StatusError(codes.AlreadyExists, "a "+kind+" called "+name+" already exists",
+		&errdetails.ResourceInfo{ResourceType: kind, ResourceName: name})
+}
+
+// PermissionDenied creates the error for a client that isn't allowed to do something, for
+// one of the reasons above.
+// It returns a PermissionDenied error.
+func PermissionDenied(reason string, msg string) error {
+
+	return StatusError(codes.PermissionDenied, msg, &errdetails.ErrorInfo{Reason: reason, Domain: errorDomain})
+}
+
+// InvalidArgument creates the error for a request whose field isn't valid.
+// It returns an InvalidArgument error.
+func InvalidArgument(field string, msg string) error {
+
+	return StatusError(codes.InvalidArgument, msg,
+		&errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: field, Description: msg}}})
+}
+
+// FailedPrecondition creates the error for a request that can't be done to the kind of thing
+// called name in the state it is in, e.g. sharing a file in an encrypted group.
+// It returns a FailedPrecondition error.
+func FailedPrecondition(kind string, name string, msg string) error {
+
+	return StatusError(codes.FailedPrecondition, msg,
+		&errdetails.PreconditionFailure{Violations: []*errdetails.PreconditionFailure_Violation{{Type: kind, Subject: name, Description: msg}}})
+}
+
+// ResourceExhausted creates the error for a client that is over a limit and can try again
+// after wait.
+// It returns a ResourceExhausted error.
+func ResourceExhausted(wait time.Duration) error {
+
+	return StatusError(codes.ResourceExhausted, "too many requests, try again in "+Seconds(wait),
+		&errdetails.RetryInfo{RetryDelay: &duration.Duration{Seconds: int64(wait / time.Second), Nanos: int32(wait % time.Second)}})
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/taylorflatt/go-chat"
+	"github.com/taylorflatt/go-chat/chatclient"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// checkStatus fails the test if err, from doing what, doesn't have the code c or isn't
+// described to users as want.
+// It doesn't return anything.
+func checkStatus(t *testing.T, what string, err error, c codes.Code, want string) {
+
+	t.Helper()
+
+	if status.Code(err) != c {
+		t.Errorf("%s got %v, want %v", what, err, c)
+	} else if got := chatclient.ErrorMessage(err); got != want {
+		t.Errorf("%s is described as %q, want %q", what, got, want)
+	}
+}
+
+// Detail gets the first detail of the status error err.
+// It returns the detail, or nil if there isn't one.
+func Detail(err error) interface{} {
+
+	if d := status.Convert(err).Details(); len(d) > 0 {
+		return d[0]
+	}
+
+	return nil
+}
+
+func TestStatusCodes(t *testing.T) {
+
+	ts := startServer(t)
+	alice := ts.member(t, "alice", "general", true)
+	bob := ts.member(t, "bob", "general", false)
+
+	_, err := ts.connect(t).Client().Register(context.Background(), &pb.ClientInfo{Sender: "alice"})
+	checkStatus(t, "registering a taken name", err, codes.AlreadyExists, "The user name \"alice\" is already taken.")
+	checkStatus(t, "renaming to a taken name", alice.Rename("bob"), codes.AlreadyExists, "The user name \"bob\" is already taken.")
+	checkStatus(t, "creating a group that exists", alice.Create("general", false), codes.AlreadyExists, "The group name \"general\" is already taken.")
+	checkStatus(t, "creating a group with an invalid name", alice.Create("-", false), codes.InvalidArgument, "The group name must start with a letter or digit.")
+
+	alice.Send("hello")
+	m := expect(t, alice, "the echo of the message", func(m pb.ChatMessage) bool { return m.Message == "hello\n" && m.Id != 0 })
+
+	_, err = bob.Client().EditMessage(context.Background(), &pb.MessageEdit{Client: "bob", GroupName: "general", Id: m.Id, Message: "bye\n"})
+	checkStatus(t, "editing someone else's message", err, codes.PermissionDenied, "Only the sender or the group's moderator can change that message.")
+	if info, ok := Detail(err).(*errdetails.ErrorInfo); !ok || info.Reason != reasonNotSender {
+		t.Errorf("editing someone else's message has the details %v, want the reason %s", Detail(err), reasonNotSender)
+	}
+
+	_, err = bob.Client().EditMessage(context.Background(), &pb.MessageEdit{Client: "bob", GroupName: "general", Id: 99, Message: "bye\n"})
+	checkStatus(t, "editing a message that doesn't exist", err, codes.NotFound, "The message \"99\" doesn't exist.")
+
+	_, err = bob.Client().JoinGroup(context.Background(), &pb.GroupInfo{Client: "bob", GroupName: "nowhere"})
+	checkStatus(t, "joining a group that doesn't exist", err, codes.NotFound, "The group \"nowhere\" doesn't exist.")
+
+	_, err = bob.Client().SetTopic(context.Background(), &pb.Topic{Client: "bob", GroupName: "nowhere", Topic: "hi"})
+	checkStatus(t, "setting the topic of a group that doesn't exist", err, codes.NotFound, "The group \"nowhere\" doesn't exist.")
+
+	_, err = bob.Client().ShareGroupKey(context.Background(), &pb.GroupKey{Client: "bob", GroupName: "general", Epoch: 1})
+	checkStatus(t, "sharing a key for a group that isn't encrypted", err, codes.FailedPrecondition, "The group general isn't an encrypted group.")
+
+	// Commands run over the stream get the message without the status.
+	bob.Command("/kick alice")
+	expect(t, bob, "the kick being refused", func(m pb.ChatMessage) bool {
+		return m.Kind == pb.Kind_COMMAND_RESULT && m.Message == "only the moderator of general can kick people"
+	})
+}
+
+func TestStatusDetails(t *testing.T) {
+
+	ts := startServer(t)
+	limits.CallRate, limits.CallBurst = 0.5, 1
+	c := ts.connect(t).Client()
+
+	_, err := c.Register(context.Background(), &pb.ClientInfo{Sender: "a"})
+	if bad, ok := Detail(err).(*errdetails.BadRequest); !ok || len(bad.FieldViolations) != 1 || bad.FieldViolations[0].Field != "sender" {
+		t.Errorf("registering an invalid name has the details %v, want a violation of sender", Detail(err))
+	}
+
+	_, err = c.Register(context.Background(), &pb.ClientInfo{Sender: "alice"})
+	checkStatus(t, "registering too often", err, codes.ResourceExhausted, "You're doing that too often. Try again in 2s.")
+	if retry, ok := Detail(err).(*errdetails.RetryInfo); !ok || retry.RetryDelay.AsDuration() <= 0 {
+		t.Errorf("registering too often has the details %v, want a retry delay", Detail(err))
+	}
+}
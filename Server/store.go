@@ -0,0 +1,240 @@
+package main
+
+import (
+	"strconv"
+
+	pb "github.com/taylorflatt/go-chat"
+)
+
+// The longest reaction, in bytes, that will be stored on a message, and the most messages kept
+// in each group's history. The oldest are dropped a batch at a time once there are a quarter
+// more than that, so storing a message doesn't mean moving the whole history every time.
+const (
+	maxReactionLen = 32
+	maxHistory     = 1000
+)
+
+// The functions below manage the message history and read markers kept for each group.
+// None of them take the lock themselves so the caller must already hold it.
+
+// IsStored checks whether messages of kind k are kept in a group's history. Everything else is
+// either an event about a message that is already stored or, like private messages, not kept.
+// It returns a bool value.
+func IsStored(k pb.Kind) bool {
+
+	return k == pb.Kind_MESSAGE || k == pb.Kind_ATTACHMENT || k == pb.Kind_ACTION
+}
+
+// StoreMessage assigns the next sequence number in group g to msg and appends it to the
+// group's history. Replies are filed under the message that started their thread, and
+// senders have always read their own messages.
+// It returns the stored message.
+func StoreMessage(g *Group, msg pb.ChatMessage) pb.ChatMessage {
+
+	g.seq++
+	msg.Id = g.seq
+	msg.Replies = 0
+
+	if root := ThreadRoot(g, msg.ParentId); root != nil {
+		msg.ParentId = root.Id
+		root.Replies++
+		g.threads[root.Id] = append(g.threads[root.Id], msg.Id)
+	} else {
+		msg.ParentId = 0
+	}
+
+	g.history = append(g.history, msg)
+	UpdateReadMarker(g, msg.Sender, msg.Id)
+	if len(g.history) > maxHistory+maxHistory/4 {
+		TrimHistory(g)
+	}
+
+	return msg
+}
+
+// TrimHistory drops the oldest messages in group g's history, along with the threads they
+// started, keeping the newest maxHistory. Replies are newer than the message they reply to, so
+// every thread that is kept is whole.
+// It doesn't return anything.
+func TrimHistory(g *Group) {
+
+	drop := len(g.history) - maxHistory
+	if drop <= 0 {
+		return
+	}
+
+	for _, m := range g.history[:drop] {
+		delete(g.threads, m.Id)
+	}
+
+	g.history = append([]pb.ChatMessage(nil), g.history[drop:]...)
+}
+
+// UpdateReadMarker moves client n's read marker in group g forward to id. Markers never
+// move backwards or past the latest message in the group.
+// It doesn't return anything.
+func UpdateReadMarker(g *Group, n string, id uint64) {
+
+	if id > g.seq {
+		id = g.seq
+	}
+
+	if id > g.read[n] {
+		g.read[n] = id
+	}
+}
+
+// UnreadCount counts the messages in group g that client n hasn't read yet. Only members have
+// anything to read, and only what is still in the history.
+// It returns the number of unread messages.
+func UnreadCount(g *Group, n string) uint64 {
+
+	if !IsMemberLocked(n, g.name) {
+		return 0
+	}
+
+	unread := g.seq - g.read[n]
+	if stored := uint64(len(g.history)); unread > stored {
+		unread = stored
+	}
+
+	return unread
+}
+
+// SeenBy finds the members of group g, other than its sender, who have read the latest message.
+// It returns a list of clients.
+func SeenBy(g *Group) []string {
+
+	if len(g.history) == 0 {
+		return nil
+	}
+
+	latest := g.history[len(g.history)-1]
+
+	var seen []string
+	for _, c := range g.clients {
+		if c != latest.Sender && g.read[c] >= latest.Id {
+			seen = append(seen, c)
+		}
+	}
+
+	return seen
+}
+
+// FindMessage looks up the message with the given id in group g's history. Ids are handed out
+// in order with no gaps, so a message's offset in the history is how much newer it is than the
+// oldest message still kept.
+// It returns the stored message or nil if there isn't one, e.g. because it was trimmed.
+func FindMessage(g *Group, id uint64) *pb.ChatMessage {
+
+	if len(g.history) == 0 || id < g.history[0].Id || id > g.seq {
+		return nil
+	}
+
+	return &g.history[id-g.history[0].Id]
+}
+
+// FindEditableMessage looks up the message targeted by an edit or delete and checks that
+// the requesting client is allowed to change it.
+// It returns the stored message and an error.
+func FindEditableMessage(in *pb.MessageEdit) (*pb.ChatMessage, error) {
+
+	g, ok := groups[in.GroupName]
+	if !ok {
+		return nil, NotFound("group", in.GroupName)
+	}
+
+	m := FindMessage(g, in.Id)
+	if m == nil || m.Deleted {
+		return nil, NotFound("message", strconv.FormatUint(in.Id, 10))
+	}
+
+	if m.Sender != in.Client && g.owner != in.Client {
+		return nil, PermissionDenied(reasonNotSender, "only the sender or the group's moderator can change that message")
+	}
+
+	return m, nil
+}
+
+// ToggleReaction adds client n's reaction e to message m, or takes it back if n has already
+// reacted to m with e. Reactions with no one left behind them are dropped.
+// It returns true if the reaction was added and false if it was removed.
+func ToggleReaction(m *pb.ChatMessage, n string, e string) bool {
+
+	for i, r := range m.Reactions {
+		if r.Emoji != e {
+			continue
+		}
+
+		for j, c := range r.Clients {
+			if c == n {
+				r.Clients = append(r.Clients[:j], r.Clients[j+1:]...)
+				if len(r.Clients) == 0 {
+					m.Reactions = append(m.Reactions[:i], m.Reactions[i+1:]...)
+				}
+				return false
+			}
+		}
+
+		r.Clients = append(r.Clients, n)
+		return true
+	}
+
+	m.Reactions = append(m.Reactions, &pb.Reaction{Emoji: e, Clients: []string{n}})
+	return true
+}
+
+// CopyReactions makes a deep copy of a message's reactions so they can be sent to clients
+// without holding the lock.
+// It returns the copied reactions.
+func CopyReactions(rs []*pb.Reaction) []*pb.Reaction {
+
+	var cp []*pb.Reaction
+	for _, r := range rs {
+		cp = append(cp, &pb.Reaction{Emoji: r.Emoji, Clients: append([]string(nil), r.Clients...)})
+	}
+
+	return cp
+}
+
+// ThreadRoot finds the message that started the thread containing message id. Replies to a
+// reply belong to the same thread as the message they reply to.
+// It returns the first message of the thread or nil if message id doesn't exist.
+func ThreadRoot(g *Group, id uint64) *pb.ChatMessage {
+
+	m := FindMessage(g, id)
+	if m != nil && m.ParentId != 0 {
+		return FindMessage(g, m.ParentId)
+	}
+
+	return m
+}
+
+// ThreadMessages copies the thread containing message id out of group g's history.
+// It returns the first message of the thread followed by its replies, or nil if message id
+// doesn't exist.
+func ThreadMessages(g *Group, id uint64) []*pb.ChatMessage {
+
+	root := ThreadRoot(g, id)
+	if root == nil {
+		return nil
+	}
+
+	msgs := []*pb.ChatMessage{CopyMessage(root)}
+	for _, r := range g.threads[root.Id] {
+		msgs = append(msgs, CopyMessage(FindMessage(g, r)))
+	}
+
+	return msgs
+}
+
+// CopyMessage makes a deep copy of a stored message so it can be sent to clients without
+// holding the lock.
+// It returns the copied message.
+func CopyMessage(m *pb.ChatMessage) *pb.ChatMessage {
+
+	cp := *m
+	cp.Reactions = CopyReactions(m.Reactions)
+
+	return &cp
+}
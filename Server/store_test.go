@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	pb "github.com/taylorflatt/go-chat"
+	"github.com/taylorflatt/go-chat/chatclient"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestHistoryTrimmed(t *testing.T) {
+
+	g := &Group{name: "general", read: make(map[string]uint64), threads: make(map[uint64][]uint64)}
+
+	root := StoreMessage(g, pb.ChatMessage{Sender: "alice", Message: "first"})
+	StoreMessage(g, pb.ChatMessage{Sender: "bob", Message: "reply", ParentId: root.Id})
+	for i := 0; i < maxHistory; i++ {
+		StoreMessage(g, pb.ChatMessage{Sender: "alice", Message: "more"})
+	}
+	recent := StoreMessage(g, pb.ChatMessage{Sender: "alice", Message: "recent"})
+	reply := StoreMessage(g, pb.ChatMessage{Sender: "bob", Message: "reply", ParentId: recent.Id})
+
+	// Keep storing until the history is trimmed.
+	for len(g.history) > maxHistory {
+		StoreMessage(g, pb.ChatMessage{Sender: "alice", Message: "more"})
+	}
+	last := StoreMessage(g, pb.ChatMessage{Sender: "alice", Message: "last"})
+
+	if n := len(g.history); n > maxHistory+maxHistory/4 {
+		t.Errorf("the history holds %d messages, want at most %d", n, maxHistory+maxHistory/4)
+	}
+	if FindMessage(g, root.Id) != nil || ThreadMessages(g, root.Id) != nil || g.threads[root.Id] != nil {
+		t.Error("the oldest thread is still kept")
+	}
+	if m := FindMessage(g, last.Id); m == nil || m.Message != "last" {
+		t.Errorf("looking up the latest message got %+v", m)
+	}
+	if m := FindMessage(g, last.Id+1); m != nil {
+		t.Errorf("looking up a message that hasn't been sent got %+v", m)
+	}
+	if th := ThreadMessages(g, reply.Id); len(th) != 2 || th[0].Id != recent.Id || th[1].Id != reply.Id {
+		t.Errorf("the recent thread is %v", th)
+	}
+}
+
+func TestUnreadOnlyForMembers(t *testing.T) {
+
+	ts := startServer(t)
+	alice := ts.member(t, "alice", "general", true)
+	bob := ts.member(t, "bob", "general", false)
+	carol := ts.member(t, "carol", "random", true)
+
+	alice.Send("hello")
+	expect(t, bob, "alice's message", func(m pb.ChatMessage) bool { return m.Message == "hello\n" })
+
+	for _, c := range []struct {
+		s    *chatclient.Session
+		want uint64
+	}{{bob, 1}, {carol, 0}} {
+		l, err := c.s.Client().GetGroupList(context.Background(), &pb.ClientInfo{Sender: c.s.User()})
+		if err != nil {
+			t.Fatalf("listing the groups: %v", err)
+		}
+		for i, g := range l.Groups {
+			if g == "general" && l.Unread[i] != c.want {
+				t.Errorf("%s has %d unread in general, want %d", c.s.User(), l.Unread[i], c.want)
+			}
+		}
+	}
+}
+
+func TestOnlySenderChangesMessage(t *testing.T) {
+
+	ts := startServer(t)
+	alice := ts.member(t, "alice", "general", true)
+	bob := ts.member(t, "bob", "general", false)
+	ctx := context.Background()
+
+	alice.Send("hello")
+	m := expect(t, bob, "alice's message", func(m pb.ChatMessage) bool { return m.Message == "hello\n" })
+
+	for _, who := range []struct {
+		client string
+		want   codes.Code
+	}{{"bob", codes.PermissionDenied}, {"alice", codes.Unauthenticated}} {
+		edit := &pb.MessageEdit{Client: who.client, GroupName: "general", Id: m.Id, Message: "forged\n"}
+		if _, err := bob.Client().EditMessage(ctx, edit); status.Code(err) != who.want {
+			t.Errorf("bob editing alice's message as %s got %v, want %v", who.client, err, who.want)
+		}
+		if _, err := bob.Client().DeleteMessage(ctx, edit); status.Code(err) != who.want {
+			t.Errorf("bob deleting alice's message as %s got %v, want %v", who.client, err, who.want)
+		}
+	}
+
+	lock.RLock()
+	stored := *FindMessage(groups["general"], m.Id)
+	lock.RUnlock()
+	if stored.Message != "hello\n" || stored.Edited || stored.Deleted {
+		t.Errorf("alice's message was changed to %+v", stored)
+	}
+
+	if _, err := alice.Client().EditMessage(ctx, &pb.MessageEdit{Client: "alice", GroupName: "general", Id: m.Id, Message: "hi\n"}); err != nil {
+		t.Errorf("the sender editing the message: %v", err)
+	}
+}
+
+func TestToggleReaction(t *testing.T) {
+
+	m := &pb.ChatMessage{Sender: "alice", Message: "hello"}
+
+	for _, step := range []struct {
+		client, emoji string
+		added         bool
+		want          string
+	}{
+		{"alice", "+1", true, "+1:alice"},
+		{"bob", "+1", true, "+1:alice,bob"},
+		{"bob", "tada", true, "+1:alice,bob tada:bob"},
+		{"alice", "+1", false, "+1:bob tada:bob"},
+		{"bob", "+1", false, "tada:bob"},
+		{"bob", "tada", false, ""},
+	} {
+		if added := ToggleReaction(m, step.client, step.emoji); added != step.added {
+			t.Errorf("%s toggling %s got %v, want %v", step.client, step.emoji, added, step.added)
+		}
+		if got := reactions(m.Reactions); got != step.want {
+			t.Errorf("after %s toggled %s the reactions are %q, want %q", step.client, step.emoji, got, step.want)
+		}
+	}
+}
+
+func TestCopyReactions(t *testing.T) {
+
+	m := &pb.ChatMessage{Sender: "alice", Message: "hello"}
+	ToggleReaction(m, "alice", "+1")
+	ToggleReaction(m, "bob", "+1")
+
+	cp := CopyReactions(m.Reactions)
+	ToggleReaction(m, "alice", "+1")
+	ToggleReaction(m, "carol", "tada")
+
+	if got := reactions(cp); got != "+1:alice,bob" {
+		t.Errorf("the copy changed along with the message to %q", got)
+	}
+	if CopyReactions(nil) != nil {
+		t.Error("copying no reactions didn't give nil")
+	}
+}
+
+func TestReactNeedsOwnSession(t *testing.T) {
+
+	ts := startServer(t)
+	alice := ts.member(t, "alice", "general", true)
+	bob := ts.member(t, "bob", "general", false)
+
+	alice.Send("hello")
+	m := expect(t, bob, "alice's message", func(m pb.ChatMessage) bool { return m.Message == "hello\n" })
+
+	_, err := bob.Client().React(context.Background(), &pb.ReactionInfo{Client: "alice", GroupName: "general", Id: m.Id, Emoji: "+1"})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("bob reacting as alice got %v, want %v", err, codes.Unauthenticated)
+	}
+
+	ev, err := bob.Client().React(context.Background(), &pb.ReactionInfo{Client: "bob", GroupName: "general", Id: m.Id, Emoji: "+1"})
+	if err != nil {
+		t.Fatalf("bob reacting: %v", err)
+	}
+	if got := reactions(ev.Reactions); got != "+1:bob" {
+		t.Errorf("the reactions are %q, want \"+1:bob\"", got)
+	}
+}
+
+// reactions describes rs as space separated emoji, each followed by who reacted with it.
+// It returns the description.
+func reactions(rs []*pb.Reaction) string {
+
+	var l []string
+	for _, r := range rs {
+		l = append(l, r.Emoji+":"+strings.Join(r.Clients, ","))
+	}
+
+	return strings.Join(l, " ")
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"log"
+
+	pb "github.com/taylorflatt/go-chat"
+	context "golang.org/x/net/context"
+)
+
+const (
+	maxTopicLen = 200
+)
+
+// SetTopic changes the topic of a group and tells its members. Any member of the group can
+// change it, and an empty topic clears it.
+// It returns an empty object and an error.
+func (s *server) SetTopic(ctx context.Context, in *pb.Topic) (*pb.Empty, error) {
+
+	if err := ChangeTopic(in.Client, in.GroupName, in.Topic); err != nil {
+		return nil, err
+	}
+
+	return &pb.Empty{}, nil
+}
+
+// ChangeTopic sets the topic of group g to t on behalf of client u and tells the group's members.
+// The caller has to have authenticated u, as SetTopic and the command callers do.
+// It returns an error.
+func ChangeTopic(u string, g string, t string) error {
+
+	if len(t) > maxTopicLen {
+		return InvalidArgument("topic", "the topic is too long")
+	}
+
+	if !GroupExists(g) {
+		return NotFound("group", g)
+	} else if !IsMember(u, g) {
+		return PermissionDenied(reasonNotMember, "only members of the group can change its topic")
+	}
+
+	lock.Lock()
+	grp, ok := groups[g]
+	if !ok {
+		lock.Unlock()
+		return NotFound("group", g)
+	}
+	grp.topic = t
+	lock.Unlock()
+
+	log.Print("[ChangeTopic]: " + u + " set the topic of " + g + " to " + t)
+
+	Broadcast(g, pb.ChatMessage{Sender: u, Receiver: g, Kind: pb.Kind_TOPIC, Message: t})
+
+	return nil
+}
+
+// GetTopic gets the topic of a group.
+// It returns the topic and an error.
+func (s *server) GetTopic(ctx context.Context, in *pb.GroupInfo) (*pb.Topic, error) {
+
+	lock.RLock()
+	defer lock.RUnlock()
+
+	g, ok := groups[in.GroupName]
+	if !ok {
+		return nil, NotFound("group", in.GroupName)
+	}
+
+	return &pb.Topic{GroupName: g.name, Topic: g.topic}, nil
+}
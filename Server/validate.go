@@ -0,0 +1,273 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/taylorflatt/go-chat"
+	context "golang.org/x/net/context"
+	"golang.org/x/text/unicode/norm"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Limits on what clients send.
+const (
+	minUserLen       = 3    // The shortest username, in characters.
+	maxNameLen       = 32   // The longest username, group name or hook name, in characters.
+	maxTextLen       = 4096 // The longest message, topic or other text, in bytes.
+	maxCiphertextLen = 8192 // The longest end-to-end encrypted message, in bytes.
+)
+
+// reservedNames can't be taken by users or hooks, so nobody can pass themselves off as the
+// server or clash with @here and @all mentions. They are matched regardless of case.
+var reservedNames = map[string]bool{
+	"admin":         true,
+	"administrator": true,
+	"all":           true,
+	"go-chat":       true,
+	"here":          true,
+	"operator":      true,
+	"root":          true,
+	"server":        true,
+	"system":        true,
+}
+
+// nameFields are the fields of requests that hold the names of users and groups. They are only
+// normalized, since the server looks them up exactly, and checked where the names are chosen.
+// Every other text field is cleaned.
+var nameFields = map[protoreflect.Name]bool{
+	"sender":    true,
+	"receiver":  true,
+	"client":    true,
+	"clients":   true,
+	"groupName": true,
+	"name":      true,
+}
+
+// SanitizeUnary cleans every request before it is handled, turning away those with text that is
+// too long.
+// It returns the response and an error.
+func SanitizeUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+
+	if m, ok := req.(proto.Message); ok {
+		if err := Sanitize(m); err != nil {
+			return nil, err
+		}
+	}
+
+	return handler(ctx, req)
+}
+
+// Sanitize normalizes the names in m and cleans the rest of its text, along with that of any
+// messages inside it.
+// It returns an InvalidArgument error if any of the text is too long.
+func Sanitize(m proto.Message) error {
+
+	return SanitizeFields(proto.MessageReflect(m))
+}
+
+// SanitizeFields sanitizes the fields of m, as Sanitize does.
+// It returns an error.
+func SanitizeFields(m protoreflect.Message) error {
+
+	var err error
+	set := make(map[protoreflect.FieldDescriptor]string)
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case fd.Kind() == protoreflect.MessageKind && fd.IsList():
+			l := v.List()
+			for i := 0; i < l.Len() && err == nil; i++ {
+				err = SanitizeFields(l.Get(i).Message())
+			}
+		case fd.Kind() == protoreflect.MessageKind && !fd.IsMap():
+			err = SanitizeFields(v.Message())
+		case fd.Kind() == protoreflect.StringKind && fd.IsList():
+			l := v.List()
+			for i := 0; i < l.Len() && err == nil; i++ {
+				var s string
+				s, err = SanitizeString(fd, l.Get(i).String())
+				l.Set(i, protoreflect.ValueOfString(s))
+			}
+		case fd.Kind() == protoreflect.StringKind && !fd.IsMap():
+			set[fd], err = SanitizeString(fd, v.String())
+		case fd.Kind() == protoreflect.BytesKind && fd.Name() == "ciphertext" && len(v.Bytes()) > maxCiphertextLen:
+			err = InvalidArgument("ciphertext", "encrypted messages can't be longer than "+strconv.Itoa(maxCiphertextLen)+" bytes")
+		}
+		return err == nil
+	})
+
+	// Fields of m itself can't be changed while ranging over them.
+	for fd, s := range set {
+		m.Set(fd, protoreflect.ValueOfString(s))
+	}
+
+	return err
+}
+
+// SanitizeString normalizes s if the field fd holds a name, or cleans it otherwise.
+// It returns the string and an InvalidArgument error if it is too long.
+func SanitizeString(fd protoreflect.FieldDescriptor, s string) (string, error) {
+
+	if nameFields[fd.Name()] {
+		s = norm.NFC.String(s)
+	} else {
+		s = CleanText(s)
+	}
+
+	if len(s) > maxTextLen {
+		return s, InvalidArgument(string(fd.Name()), "the "+string(fd.Name())+" can't be longer than "+strconv.Itoa(maxTextLen)+" bytes")
+	}
+
+	return s, nil
+}
+
+// CleanText normalizes s and strips anything a terminal would act on rather than print, such as
+// escape sequences, carriage returns and other control characters. Newlines and tabs are kept.
+// It returns the cleaned text.
+func CleanText(s string) string {
+
+	s = norm.NFC.String(strings.ToValidUTF8(s, string(utf8.RuneError)))
+
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		r, n := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == '\x1b' || r == '\u009b' || r == '\u009d' || r == '\u0090' || r == '\u0098' || r == '\u009e' || r == '\u009f':
+			n = EscapeLen(s[i:])
+		case r == '\n' || r == '\t':
+			b.WriteRune(r)
+		case unicode.IsControl(r):
+		default:
+			b.WriteRune(r)
+		}
+		i += n
+	}
+
+	return b.String()
+}
+
+// EscapeLen measures the terminal escape sequence s starts with, which starts with ESC or one of
+// the 8-bit control characters that stand for ESC and another character.
+// It returns the length of the sequence in bytes.
+func EscapeLen(s string) int {
+
+	r, n := utf8.DecodeRuneInString(s)
+
+	// Work out which kind of sequence it is from the character that follows ESC, or that the
+	// 8-bit control stands for.
+	kind := byte(r - 0x40)
+	if r == '\x1b' {
+		if len(s) < 2 || s[1] >= utf8.RuneSelf {
+			return n
+		}
+		kind, n = s[1], 2
+	}
+
+	switch kind {
+	case '[':
+		// A control sequence: parameters and intermediate bytes up to a final byte.
+		for i := n; i < len(s); i++ {
+			if s[i] >= 0x40 && s[i] <= 0x7e {
+				return i + 1
+			}
+		}
+		return len(s)
+	case ']', 'P', 'X', '^', '_':
+		// A string, such as an operating system command, ended by BEL or ESC \.
+		for i := n; i < len(s); i++ {
+			if s[i] == '\a' {
+				return i + 1
+			} else if s[i] == '\x1b' && i+1 < len(s) && s[i+1] == '\\' {
+				return i + 2
+			}
+		}
+		return len(s)
+	}
+
+	// Anything else is ESC and a final byte, e.g. ESC c, possibly with intermediate bytes
+	// between them, e.g. ESC ( B.
+	if kind >= 0x20 && kind <= 0x2f {
+		for n < len(s) && s[n] >= 0x20 && s[n] <= 0x2f {
+			n++
+		}
+		if n < len(s) {
+			n++
+		}
+	}
+
+	return n
+}
+
+// CheckName checks that n, from the field of the request called field, is a valid name for a
+// new user, group or hook, called what in errors. Names are letters, digits, '-', '_' and '.',
+// start with a letter or digit and are between min and maxNameLen characters long. Reserved
+// names are turned away if reserved is set.
+// It returns the name, normalized, and an InvalidArgument error if it isn't valid.
+func CheckName(field string, what string, n string, min int, reserved bool) (string, error) {
+
+	n = norm.NFC.String(n)
+	l := utf8.RuneCountInString(n)
+
+	invalid := func(why string) (string, error) {
+		return "", InvalidArgument(field, "the "+what+" "+why)
+	}
+
+	if l < min {
+		return invalid("must be at least " + strconv.Itoa(min) + " character(s) long")
+	} else if l > maxNameLen {
+		return invalid("can't be longer than " + strconv.Itoa(maxNameLen) + " characters")
+	} else if reserved && reservedNames[strings.ToLower(n)] {
+		return invalid(n + " is reserved")
+	}
+
+	for i, r := range n {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+		case i == 0:
+			return invalid("must start with a letter or digit")
+		case unicode.Is(unicode.M, r) || r == '-' || r == '_' || r == '.':
+		default:
+			return invalid("can only have letters, digits, '-', '_' and '.' in it")
+		}
+	}
+
+	return n, nil
+}
+
+// CheckMessage sanitizes a message sent over the chat stream and checks that it is something
+// clients may send: a message, action, private message or command. Every other kind of event,
+// such as an edit or an announcement, comes from the server, which sends it once it has checked
+// the client may do what it says. The fields the server fills in, such as the id, are cleared.
+// It returns an InvalidArgument error if it isn't valid.
+func CheckMessage(m *pb.ChatMessage) error {
+
+	if err := Sanitize(m); err != nil {
+		return err
+	}
+
+	switch m.Kind {
+	case pb.Kind_MESSAGE, pb.Kind_ACTION, pb.Kind_DIRECT:
+		if strings.TrimSpace(m.Message) == "" && len(m.Ciphertext) == 0 {
+			return InvalidArgument("message", "the message is empty")
+		}
+	case pb.Kind_COMMAND:
+	default:
+		return InvalidArgument("kind", "clients can't send "+m.Kind.String()+" events")
+	}
+
+	m.Id, m.Edited, m.Deleted, m.Reactions, m.Replies, m.File = 0, false, false, nil, 0, nil
+
+	return nil
+}
+
+// Rejected creates the event telling client u that msg was turned away and why.
+// It returns the event.
+func Rejected(u string, msg pb.ChatMessage, err error) *pb.ChatMessage {
+
+	return &pb.ChatMessage{Sender: u, Receiver: msg.Receiver, Kind: pb.Kind_REJECTED, Message: status.Convert(err).Message()}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	pb "github.com/taylorflatt/go-chat"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCleanText(t *testing.T) {
+
+	tests := []struct{ in, want string }{
+		{"hello", "hello"},
+		{"line one\nline two\ttabbed", "line one\nline two\ttabbed"},
+		{"\x1b[31mred\x1b[0m text", "red text"},
+		{"\x1b]0;new title\atext", "text"},
+		{"\x1b]8;;http://evil\x1b\\link\x1b]8;;\x1b\\", "link"},
+		{"\x1b(Bcharset \x1bcreset", "charset reset"},
+		{"over\rwrite\x08\x07\x00", "overwrite"},
+		{"\u009b31mC1", "C1"},
+		{"café", "café"},
+		{"trailing \x1b[", "trailing "},
+		{"bad \xff utf8", "bad � utf8"},
+	}
+
+	for _, tt := range tests {
+		if got := CleanText(tt.in); got != tt.want {
+			t.Errorf("CleanText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCheckName(t *testing.T) {
+
+	valid := []string{"alice", "bob_2", "j.doe", "load-1", "José", "名前です"}
+	for _, n := range valid {
+		if _, err := CheckName("sender", "username", n, minUserLen, true); err != nil {
+			t.Errorf("%q was turned away: %v", n, err)
+		}
+	}
+
+	invalid := []string{"", "al", "has space", "tab\tname", "esc\x1b[31m", "-dash", "ünïcödé-but-far-too-long-for-a-name", "Server", "here", "semi;colon"}
+	for _, n := range invalid {
+		if _, err := CheckName("sender", "username", n, minUserLen, true); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%q got %v, want InvalidArgument", n, err)
+		}
+	}
+
+	if n, _ := CheckName("sender", "username", "José", minUserLen, true); n != "José" {
+		t.Errorf("the name was normalized to %q, want %q", n, "José")
+	}
+}
+
+func TestInvalidNamesRejected(t *testing.T) {
+
+	ts := startServer(t)
+	c := ts.connect(t).Client()
+
+	if _, err := c.Register(context.Background(), &pb.ClientInfo{Sender: "\x1b[2J"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("registering an escape sequence got %v, want InvalidArgument", err)
+	}
+	if _, err := c.Register(context.Background(), &pb.ClientInfo{Sender: "admin"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("registering a reserved name got %v, want InvalidArgument", err)
+	}
+
+	alice := ts.login(t, "alice")
+	if err := alice.Create("bad group", false); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("creating a group with a space in its name got %v, want InvalidArgument", err)
+	}
+	if err := alice.Rename(" "); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("renaming to a space got %v, want InvalidArgument", err)
+	}
+
+	_, err := c.SetTopic(context.Background(), &pb.Topic{Client: "alice", GroupName: "general", Topic: strings.Repeat("x", maxTextLen+1)})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("setting a topic that is too long got %v, want InvalidArgument", err)
+	}
+}
+
+func TestMessagesSanitized(t *testing.T) {
+
+	ts := startServer(t)
+	alice := ts.member(t, "alice", "general", true)
+	bob := ts.member(t, "bob", "general", false)
+
+	alice.Send("\x1b[31mhello\x1b[0m")
+	expect(t, bob, "the cleaned message", func(m pb.ChatMessage) bool { return m.Message == "hello\n" })
+
+	alice.Send(strings.Repeat("x", maxTextLen+1))
+	m := expect(t, alice, "the long message being turned away", func(m pb.ChatMessage) bool { return m.Kind == pb.Kind_REJECTED })
+	if !strings.Contains(m.Message, "can't be longer") || m.Receiver != "general" {
+		t.Errorf("got %+v", m)
+	}
+
+	alice.Send("\x1b[2J")
+	m = expect(t, alice, "the empty message being turned away", func(m pb.ChatMessage) bool { return m.Kind == pb.Kind_REJECTED })
+	if m.Message != "the message is empty" {
+		t.Errorf("got %+v", m)
+	}
+
+	// The stream still works.
+	alice.Send("still here")
+	expect(t, bob, "a later message", func(m pb.ChatMessage) bool { return m.Message == "still here\n" })
+}
+
+func TestServerEventsRejected(t *testing.T) {
+
+	ts := startServer(t)
+	alice := ts.member(t, "alice", "general", true)
+	mallory := ts.raw(t, "mallory", "general")
+
+	alice.Send("hello")
+	m := expect(t, alice, "the echo of the message", func(m pb.ChatMessage) bool { return m.Message == "hello\n" })
+
+	for _, k := range []pb.Kind{pb.Kind_EDIT, pb.Kind_DELETE, pb.Kind_ANNOUNCEMENT, pb.Kind_REKEY, pb.Kind_KEY} {
+		mallory.Send(&pb.ChatMessage{Sender: "mallory", Receiver: "general", Kind: k, Id: m.Id, Message: "changed"})
+		r := expectRaw(t, mallory, k.String()+" being turned away", func(m *pb.ChatMessage) bool { return m.Kind == pb.Kind_REJECTED })
+		if r.Message != "clients can't send "+k.String()+" events" {
+			t.Errorf("sending %s got %+v", k, r)
+		}
+	}
+
+	// Fields only the server sets are ignored.
+	mallory.Send(&pb.ChatMessage{Sender: "mallory", Receiver: "general", Message: "after\n", Id: m.Id, Edited: true})
+	got := expect(t, alice, "a later message", func(m pb.ChatMessage) bool {
+		if m.Sender == "mallory" && m.Kind != pb.Kind_MESSAGE {
+			t.Errorf("alice got %+v", m)
+		}
+		return m.Message == "after\n"
+	})
+	if got.Id == m.Id || got.Edited {
+		t.Errorf("got %+v, want a new unedited message", got)
+	}
+}
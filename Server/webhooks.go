@@ -0,0 +1,295 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	pb "github.com/taylorflatt/go-chat"
+)
+
+// Limits on delivering webhooks.
+const (
+	webhookQueue    = 100              // Events waiting to be delivered to each webhook.
+	webhookAttempts = 5                // Tries at delivering an event before giving up on it.
+	webhookTimeout  = 10 * time.Second // How long a receiver has to answer each try.
+)
+
+// webhookBackoff is how long to wait before the second try at delivering an event. It doubles
+// after every failed try.
+var webhookBackoff = time.Second
+
+// The events webhooks can be sent.
+var webhookEvents = map[string]bool{
+	"message": true,
+	"join":    true,
+	"leave":   true,
+	"mention": true,
+	"topic":   true,
+}
+
+// Webhook is a URL the server posts group events to.
+type Webhook struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret"` // The key the payloads are signed with. It is required.
+	Groups []string `json:"groups"` // The groups whose events are sent, or every group if empty.
+	Events []string `json:"events"` // The events that are sent, or every event if empty.
+
+	queue   chan WebhookEvent
+	backoff time.Duration // webhookBackoff when delivery started.
+}
+
+// WebhookEvent is the JSON payload posted to webhooks.
+type WebhookEvent struct {
+	Event     string `json:"event"`
+	Group     string `json:"group"`
+	Sender    string `json:"sender"`
+	Id        uint64 `json:"id,omitempty"`
+	Message   string `json:"message,omitempty"`
+	Mentioned string `json:"mentioned,omitempty"` // The client a mention event is for.
+	Encrypted bool   `json:"encrypted,omitempty"` // Set if the message was end-to-end encrypted and so left out.
+	Time      string `json:"time"`
+}
+
+// webhooks are the outgoing webhooks events are posted to. They change on reload, so use them
+// with the lock held.
+var webhooks []*Webhook
+
+// webhooksFile is the file the webhooks were read from, if any, so they can be reloaded.
+var webhooksFile string
+
+// deliveries numbers the events posted to webhooks, so receivers can spot retries.
+var deliveries uint64
+
+var webhookClient = &http.Client{Timeout: webhookTimeout}
+
+// LoadWebhooks reads the webhooks listed in the JSON file at path and starts delivering events
+// to them in place of any loaded before. Events already queued for the old ones are still sent.
+// If the file can't be read the old webhooks are kept.
+// It returns an error.
+func LoadWebhooks(path string) error {
+
+	hs, err := ReadWebhooks(path)
+	if err != nil {
+		return err
+	}
+
+	lock.Lock()
+	old := webhooks
+	webhooks = nil
+	for _, h := range hs {
+		StartWebhook(h)
+	}
+	lock.Unlock()
+
+	for _, h := range old {
+		close(h.queue)
+	}
+
+	return nil
+}
+
+// ReadWebhooks reads and checks the webhooks listed in the JSON file at path.
+// It returns the webhooks and an error.
+func ReadWebhooks(path string) ([]*Webhook, error) {
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var hs []*Webhook
+	if err := json.Unmarshal(b, &hs); err != nil {
+		return nil, errors.New(path + ": " + err.Error())
+	}
+
+	for _, h := range hs {
+		if u, err := url.Parse(h.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return nil, errors.New(path + ": " + h.URL + " isn't an http or https URL")
+		} else if h.Secret == "" {
+			return nil, errors.New(path + ": " + h.URL + " needs a secret to sign its events with")
+		}
+		for _, e := range h.Events {
+			if !webhookEvents[e] {
+				return nil, errors.New(path + ": there is no event called \"" + e + "\"")
+			}
+		}
+	}
+
+	return hs, nil
+}
+
+// StartWebhook starts delivering events to h in the background. The lock must be held.
+// It doesn't return anything.
+func StartWebhook(h *Webhook) {
+
+	h.queue = make(chan WebhookEvent, webhookQueue)
+	h.backoff = webhookBackoff
+	webhooks = append(webhooks, h)
+	go h.Deliver()
+
+	log.Print("[StartWebhook]: Sending events to " + h.URL)
+}
+
+// Wants checks whether h is set to be sent ev.
+// It returns a bool value.
+func (h *Webhook) Wants(ev WebhookEvent) bool {
+
+	return (len(h.Groups) == 0 || Contains(h.Groups, ev.Group)) && (len(h.Events) == 0 || Contains(h.Events, ev.Event))
+}
+
+// Contains checks whether s is in l.
+// It returns a bool value.
+func Contains(l []string, s string) bool {
+
+	for _, e := range l {
+		if e == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WebhookEventFor works out which webhook event, if any, msg is in group g.
+// It returns the event and whether there is one.
+func WebhookEventFor(g *Group, msg pb.ChatMessage) (WebhookEvent, bool) {
+
+	ev := WebhookEvent{Group: g.name, Sender: msg.Sender, Id: msg.Id, Time: time.Now().UTC().Format(time.RFC3339)}
+
+	switch {
+	case msg.Kind == pb.Kind_TOPIC:
+		ev.Event = "topic"
+		ev.Message = msg.Message
+	case msg.Kind != pb.Kind_MESSAGE && msg.Kind != pb.Kind_ACTION:
+		return ev, false
+	case msg.Message == "joined chat!\n" || msg.Message == msg.Sender+" left chat!\n":
+		// Clients send their own join notices, so joins and leaves are emitted by
+		// EmitMembership when the server changes the group instead.
+		return ev, false
+	default:
+		ev.Event = "message"
+		ev.Message = msg.Message
+		ev.Encrypted = g.encrypted
+	}
+
+	if ev.Encrypted {
+		ev.Message = ""
+	}
+
+	return ev, true
+}
+
+// EmitMembership queues a join or leave event, named by event, for client n in group g. The
+// lock must be held.
+// It doesn't return anything.
+func EmitMembership(event string, n string, g string) {
+
+	EmitWebhook(WebhookEvent{Event: event, Group: g, Sender: n, Time: time.Now().UTC().Format(time.RFC3339)})
+}
+
+// EmitWebhook queues ev for every webhook that wants it. It is called while broadcasting, so
+// it never waits: if a webhook has fallen too far behind the event is dropped for it.
+// It doesn't return anything.
+func EmitWebhook(ev WebhookEvent) {
+
+	for _, h := range webhooks {
+		if !h.Wants(ev) {
+			continue
+		}
+
+		select {
+		case h.queue <- ev:
+		default:
+			log.Print("[EmitWebhook]: The queue for " + h.URL + " is full, dropping a " + ev.Event + " event")
+		}
+	}
+}
+
+// Deliver posts the events queued for h one at a time, in order, retrying each with a growing
+// delay if the receiver can't be reached or has a problem of its own.
+// It doesn't return anything.
+func (h *Webhook) Deliver() {
+
+	for ev := range h.queue {
+		body, err := json.Marshal(ev)
+		if err != nil {
+			log.Print("[Deliver]: Couldn't encode a " + ev.Event + " event: " + err.Error())
+			continue
+		}
+
+		id := strconv.FormatUint(atomic.AddUint64(&deliveries, 1), 10)
+		delay := h.backoff
+		for attempt := 1; ; attempt++ {
+			retry, err := h.Post(ev.Event, id, body)
+			if err == nil {
+				break
+			}
+
+			if !retry || attempt == webhookAttempts {
+				log.Print("[Deliver]: Gave up sending a " + ev.Event + " event to " + h.URL + ": " + err.Error())
+				break
+			}
+
+			log.Print("[Deliver]: Couldn't send a " + ev.Event + " event to " + h.URL + ", retrying: " + err.Error())
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
+}
+
+// Post makes one try at posting the payload body of event to h, signed with its secret along
+// with the time of the try, so a receiver can turn away old requests replayed to it. The
+// delivery id is the same for every try at sending the same event.
+// It returns whether it is worth trying again and an error.
+func (h *Webhook) Post(event string, id string, body []byte) (bool, error) {
+
+	req, err := http.NewRequest(http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Chat-Event", event)
+	req.Header.Set("X-Chat-Delivery", id)
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("X-Chat-Timestamp", ts)
+	req.Header.Set("X-Chat-Signature", "sha256="+Sign(h.Secret, ts, body))
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, errors.New(resp.Status)
+	}
+
+	return false, errors.New(resp.Status)
+}
+
+// Sign signs body, sent at the Unix time ts, with secret the way receivers are expected to
+// check it.
+// It returns the hex encoded HMAC-SHA256 of ts, a full stop and body.
+func Sign(secret string, ts string, body []byte) string {
+
+	m := hmac.New(sha256.New, []byte(secret))
+	m.Write([]byte(ts + "."))
+	m.Write(body)
+
+	return hex.EncodeToString(m.Sum(nil))
+}
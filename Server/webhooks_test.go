@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	pb "github.com/taylorflatt/go-chat"
+)
+
+// hookRequest is what a test webhook receiver was sent.
+type hookRequest struct {
+	header http.Header
+	body   []byte
+}
+
+func TestWebhookDelivery(t *testing.T) {
+
+	var mu sync.Mutex
+	var got []hookRequest
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		mu.Lock()
+		got = append(got, hookRequest{r.Header, body})
+		first := len(got) == 1
+		mu.Unlock()
+
+		// The first try fails so the event has to be sent again.
+		if first {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer receiver.Close()
+
+	saved := webhookBackoff
+	webhookBackoff = 10 * time.Millisecond
+	t.Cleanup(func() { webhookBackoff = saved })
+
+	ts := startServer(t)
+	h := &Webhook{URL: receiver.URL, Secret: "s3cret", Events: []string{"message"}}
+	lock.Lock()
+	StartWebhook(h)
+	lock.Unlock()
+	t.Cleanup(func() {
+		lock.Lock()
+		webhooks = nil
+		lock.Unlock()
+		close(h.queue)
+	})
+
+	alice := ts.member(t, "alice", "general", true)
+	alice.Send("hello")
+
+	eventually(t, "the event to be sent again", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(got) >= 2
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for i, r := range got[:2] {
+		stamp := r.header.Get("X-Chat-Timestamp")
+		if r.header.Get("X-Chat-Signature") != "sha256="+Sign("s3cret", stamp, r.body) {
+			t.Errorf("try %d has the signature %q, which doesn't match its timestamp and body", i+1, r.header.Get("X-Chat-Signature"))
+		}
+		if sent, err := strconv.ParseInt(stamp, 10, 64); err != nil || time.Since(time.Unix(sent, 0)) > time.Minute {
+			t.Errorf("try %d has the timestamp %q, want the time it was sent", i+1, stamp)
+		}
+		if r.header.Get("X-Chat-Event") != "message" {
+			t.Errorf("try %d is for the event %q, want message", i+1, r.header.Get("X-Chat-Event"))
+		}
+	}
+
+	if a, b := got[0].header.Get("X-Chat-Delivery"), got[1].header.Get("X-Chat-Delivery"); a == "" || a != b {
+		t.Errorf("the tries have the delivery ids %q and %q, want the same one", a, b)
+	}
+
+	var ev WebhookEvent
+	if err := json.Unmarshal(got[1].body, &ev); err != nil || ev.Group != "general" || ev.Sender != "alice" || ev.Message != "hello\n" {
+		t.Errorf("got the event %s (%v)", got[1].body, err)
+	}
+
+	// A signature doesn't carry over to another time.
+	if Sign("s3cret", "1", got[0].body) == Sign("s3cret", "2", got[0].body) {
+		t.Error("the signature doesn't depend on the timestamp")
+	}
+
+	if len(got) != 2 {
+		t.Errorf("the event was sent %d times, want 2", len(got))
+	}
+}
+
+func TestWebhookMembershipEvents(t *testing.T) {
+
+	var mu sync.Mutex
+	var got []string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ev WebhookEvent
+		json.NewDecoder(r.Body).Decode(&ev)
+
+		mu.Lock()
+		got = append(got, ev.Event+" "+ev.Sender+" "+ev.Group)
+		mu.Unlock()
+	}))
+	defer receiver.Close()
+
+	ts := startServer(t)
+	h := &Webhook{URL: receiver.URL, Secret: "s3cret", Events: []string{"join", "leave"}}
+	lock.Lock()
+	StartWebhook(h)
+	lock.Unlock()
+	t.Cleanup(func() {
+		lock.Lock()
+		webhooks = nil
+		lock.Unlock()
+		close(h.queue)
+	})
+
+	alice := ts.member(t, "alice", "general", true)
+
+	// Leaving, logging out and dropping the stream all take people out of the group.
+	bob := ts.member(t, "bob", "general", false)
+	if err := bob.Leave(); err != nil {
+		t.Fatalf("leaving: %v", err)
+	}
+	carol := ts.member(t, "carol", "general", false)
+	carol.Close()
+	dave := ts.raw(t, "dave", "general")
+	dave.CloseSend()
+	eventually(t, "dave to be disconnected", func() bool { return !ClientExists("dave") })
+
+	// A client claiming to have joined in a message doesn't count.
+	alice.Send("joined chat!")
+
+	want := []string{
+		"join alice general",
+		"join bob general", "leave bob general",
+		"join carol general", "leave carol general",
+		"join dave general", "leave dave general",
+	}
+	eventually(t, "the join and leave events", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(got) >= len(want)
+	})
+	expect(t, alice, "alice's message", func(m pb.ChatMessage) bool { return m.Sender == "alice" && m.Message == "joined chat!\n" })
+
+	// Give any events that shouldn't have been sent time to arrive.
+	time.Sleep(50 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("got the events %q, want %q", got, want)
+	}
+}
+
+func TestWebhooksNeedSecret(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "hooks.json")
+	if err := os.WriteFile(path, []byte(`[{"url": "https://example.com/events"}]`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ReadWebhooks(path); err == nil || !strings.Contains(err.Error(), "needs a secret") {
+		t.Errorf("reading a webhook without a secret got %v, want an error", err)
+	}
+}
@@ -0,0 +1,331 @@
+// Package bot is for writing bots, such as a standup reminder or a deploy announcer, that
+// take part in a go-chat group as users of their own. Bots are marked as bots in member lists.
+//
+// A bot registers handlers for what it cares about and then runs until its context is done,
+// reconnecting if the connection to the server is lost:
+//
+//	b := bot.New("localhost:12021", "echo-bot", "general")
+//	b.OnMessage(func(b *bot.Bot, m pb.ChatMessage) {
+//		b.Respond(m, m.Message)
+//	})
+//	b.Command("ping", "Checks that the bot is alive.", func(b *bot.Bot, m pb.ChatMessage, args string) {
+//		b.Respond(m, "pong")
+//	})
+//	log.Fatal(b.Run(context.Background()))
+//
+// Commands are messages that start with the bot's prefix, "!" unless it is changed, such as
+// "!ping". Every bot has a "help" command that lists its commands.
+//
+// Handlers are called one at a time, in the order events arrive, so a handler that takes a
+// while should start a goroutine to avoid holding up the rest.
+package bot
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/taylorflatt/go-chat"
+	"github.com/taylorflatt/go-chat/chatclient"
+	"google.golang.org/grpc"
+)
+
+// How long the bot waits before reconnecting, doubling after each failed attempt.
+const (
+	minBackoff = time.Second
+	maxBackoff = 30 * time.Second
+)
+
+// Handler handles a message or mention m sent to the bot's group.
+type Handler func(b *Bot, m pb.ChatMessage)
+
+// CommandHandler handles a command, given the message it was sent in and the text after its name.
+type CommandHandler func(b *Bot, m pb.ChatMessage, args string)
+
+// MemberHandler handles someone joining or leaving the bot's group.
+type MemberHandler func(b *Bot, user string)
+
+// Bot is an automated user of a chat server.
+type Bot struct {
+	Server string // The host:port of the chat server.
+	Name   string // The name the bot logs in with.
+	Group  string // The group the bot chats in.
+	Prefix string // What commands start with.
+	Create bool   // Whether to create the group if it doesn't exist.
+
+	// Options are added to the defaults when connecting, e.g. to dial an in-memory listener
+	// in tests.
+	Options []grpc.DialOption
+
+	onMessage []Handler
+	onMention []Handler
+	onJoin    []MemberHandler
+	onLeave   []MemberHandler
+	commands  map[string]command
+
+	mu sync.Mutex // Guards s.
+	s  *chatclient.Session
+}
+
+// command is a command the bot responds to.
+type command struct {
+	help string
+	run  CommandHandler
+}
+
+// New creates a bot that will log in to server as name and chat in group.
+// It returns the bot.
+func New(server string, name string, group string) *Bot {
+
+	b := &Bot{
+		Server:   server,
+		Name:     name,
+		Group:    group,
+		Prefix:   "!",
+		commands: make(map[string]command),
+	}
+	b.Command("help", "Lists the commands the bot responds to.", Help)
+
+	return b
+}
+
+// OnMessage registers h to handle messages sent to the group, other than commands, the
+// bot's own messages and people joining and leaving.
+// It doesn't return anything.
+func (b *Bot) OnMessage(h Handler) {
+
+	b.onMessage = append(b.onMessage, h)
+}
+
+// OnMention registers h to handle messages that mention the bot. The server sends a mention
+// just before the message itself, which also goes to the OnMessage handlers.
+// It doesn't return anything.
+func (b *Bot) OnMention(h Handler) {
+
+	b.onMention = append(b.onMention, h)
+}
+
+// OnJoin registers h to handle people joining the group.
+// It doesn't return anything.
+func (b *Bot) OnJoin(h MemberHandler) {
+
+	b.onJoin = append(b.onJoin, h)
+}
+
+// OnLeave registers h to handle people leaving the group.
+// It doesn't return anything.
+func (b *Bot) OnLeave(h MemberHandler) {
+
+	b.onLeave = append(b.onLeave, h)
+}
+
+// Command registers h to handle the command name, described by help in the bot's help. A
+// command registered under a name that is already taken replaces it.
+// It doesn't return anything.
+func (b *Bot) Command(name string, help string, h CommandHandler) {
+
+	b.commands[name] = command{help: help, run: h}
+}
+
+// Help is the handler of the help command, which lists the bot's commands.
+// It doesn't return anything.
+func Help(b *Bot, m pb.ChatMessage, args string) {
+
+	var lines []string
+	for n, c := range b.commands {
+		lines = append(lines, b.Prefix+n+": "+c.help)
+	}
+	sort.Strings(lines)
+
+	b.Respond(m, strings.Join(lines, "\n"))
+}
+
+// Session gives access to the bot's current session, for anything the bot doesn't wrap. It
+// changes when the bot reconnects and is nil while it is disconnected.
+// It returns the session.
+func (b *Bot) Session() *chatclient.Session {
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.s
+}
+
+// Send sends text to the bot's group. It can be called from any goroutine, e.g. on a timer.
+// It returns an error.
+func (b *Bot) Send(text string) error {
+
+	s := b.Session()
+	if s == nil {
+		return errors.New("the bot isn't connected")
+	}
+
+	return s.Send(text)
+}
+
+// Respond sends text to the group in the same thread as m, or the main chat if m wasn't a reply.
+// It returns an error.
+func (b *Bot) Respond(m pb.ChatMessage, text string) error {
+
+	s := b.Session()
+	if s == nil {
+		return errors.New("the bot isn't connected")
+	}
+
+	return s.Reply(m.ParentId, text)
+}
+
+// Run connects the bot and handles events until ctx is done. If the connection is lost the
+// bot reconnects, waiting longer after each failed attempt.
+// It returns an error if the bot couldn't join its group the first time, and nil once ctx is done.
+func (b *Bot) Run(ctx context.Context) error {
+
+	delay := minBackoff
+
+	for first := true; ; first = false {
+		s, err := b.connect()
+		if err != nil && first {
+			return err
+		} else if err != nil {
+			log.Print("[Run]: Couldn't reconnect " + b.Name + ": " + err.Error())
+		} else {
+			delay = minBackoff
+			b.serve(ctx, s)
+		}
+
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(delay):
+		}
+
+		if delay *= 2; delay > maxBackoff {
+			delay = maxBackoff
+		}
+	}
+}
+
+// connect logs the bot in and joins its group, creating it first if the bot is set to.
+// It returns the session and an error.
+func (b *Bot) connect() (*chatclient.Session, error) {
+
+	s, err := chatclient.Connect(b.Server, b.Options...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.LoginBot(b.Name); err != nil {
+		s.Close()
+		return nil, errors.New("couldn't log in as " + b.Name + ": " + err.Error())
+	}
+
+	if b.Create {
+		s.Create(b.Group, false)
+	}
+
+	if err := s.Join(b.Group); err != nil {
+		s.Close()
+		return nil, errors.New("couldn't join " + b.Group + ": " + err.Error())
+	}
+
+	b.mu.Lock()
+	b.s = s
+	b.mu.Unlock()
+
+	log.Print("[connect]: " + b.Name + " joined " + b.Group)
+	return s, nil
+}
+
+// serve handles the events of session s until the connection is lost or ctx is done, and then
+// closes the session.
+// It doesn't return anything.
+func (b *Bot) serve(ctx context.Context, s *chatclient.Session) {
+
+	defer func() {
+		b.mu.Lock()
+		b.s = nil
+		b.mu.Unlock()
+		s.Close()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.Leave()
+			return
+		case m, ok := <-s.Events:
+			if !ok {
+				log.Print("[serve]: " + b.Name + " lost the connection to the server")
+				return
+			}
+			b.Dispatch(m)
+		}
+	}
+}
+
+// Dispatch passes an event from the server to the handlers registered for it. Events from other
+// groups and the bot's own messages are ignored.
+// It doesn't return anything.
+func (b *Bot) Dispatch(m pb.ChatMessage) {
+
+	if m.Receiver != b.Group || m.Sender == b.Name {
+		return
+	}
+
+	switch m.Kind {
+	case pb.Kind_MENTION:
+		for _, h := range b.onMention {
+			h(b, m)
+		}
+	case pb.Kind_MESSAGE:
+		switch {
+		case m.File != nil:
+			return
+		case m.Message == "joined chat!\n":
+			for _, h := range b.onJoin {
+				h(b, m.Sender)
+			}
+		case m.Message == m.Sender+" left chat!\n":
+			for _, h := range b.onLeave {
+				h(b, m.Sender)
+			}
+		case !b.RunCommand(m):
+			for _, h := range b.onMessage {
+				h(b, m)
+			}
+		}
+	}
+}
+
+// RunCommand runs the command in m if it is one the bot knows.
+// It returns true if it ran a command.
+func (b *Bot) RunCommand(m pb.ChatMessage) bool {
+
+	text := strings.TrimSpace(m.Message)
+	if !strings.HasPrefix(text, b.Prefix) {
+		return false
+	}
+
+	fields := strings.Fields(strings.TrimPrefix(text, b.Prefix))
+	if len(fields) == 0 {
+		return false
+	}
+
+	c, ok := b.commands[fields[0]]
+	if !ok {
+		return false
+	}
+
+	args := strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(text, b.Prefix), fields[0]))
+	c.run(b, m, args)
+
+	return true
+}
@@ -0,0 +1,314 @@
+package chatclient
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"log"
+	"strconv"
+	"sync"
+
+	pb "github.com/taylorflatt/go-chat"
+	"golang.org/x/net/context"
+)
+
+// GroupKeys holds the keys used to end-to-end encrypt messages in the group the user is
+// chatting in. A new key is shared every time the group's membership changes, so keys from
+// earlier epochs are kept around to read older messages. Keys arrive while messages are being
+// sent and received, so it is safe to use concurrently.
+type GroupKeys struct {
+	mu        sync.Mutex        // Guards the fields below.
+	encrypted bool              // Whether the group is end-to-end encrypted.
+	epoch     uint64            // The epoch of the newest key we have.
+	keys      map[uint64][]byte // Group keys by epoch.
+}
+
+// CreateIdentity generates the X25519 key pair used to receive group keys and publishes the
+// public half. The private half never leaves the client and only lasts for the session.
+// It returns the private key and an error.
+func CreateIdentity(c pb.ChatClient, u string) (*ecdh.PrivateKey, error) {
+
+	id, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = c.PublishKey(context.Background(), &pb.PublicKey{Client: u, Key: id.PublicKey().Bytes()})
+	return id, err
+}
+
+// NewGroupKeys creates an empty set of group keys.
+// It returns the group keys.
+func NewGroupKeys() *GroupKeys {
+
+	return &GroupKeys{keys: make(map[uint64][]byte)}
+}
+
+// Encrypted reports whether the group is end-to-end encrypted.
+func (k *GroupKeys) Encrypted() bool {
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	return k.encrypted
+}
+
+// add stores the group key for epoch, making it the newest key if it is.
+// It doesn't return anything.
+func (k *GroupKeys) add(epoch uint64, key []byte) {
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.keys[epoch] = key
+	if epoch > k.epoch {
+		k.epoch = epoch
+	}
+}
+
+// KeyEncryptionKey derives the AES key a group key is wrapped with from an X25519 shared secret
+// and the public keys involved.
+// It returns the derived key.
+func KeyEncryptionKey(shared []byte, ephemeral []byte, pub []byte) []byte {
+
+	h := sha256.New()
+	h.Write(shared)
+	h.Write(ephemeral)
+	h.Write(pub)
+
+	return h.Sum(nil)
+}
+
+// WrapAAD binds a wrapped key to the group and epoch it was shared for.
+// It returns the additional authenticated data.
+func WrapAAD(g string, epoch uint64) []byte {
+
+	return []byte(g + "/" + strconv.FormatUint(epoch, 10))
+}
+
+// MessageAAD binds an encrypted message to its sender, group and key epoch, so it can't be
+// relabelled with another sender or moved to another group or epoch once it is sent. It doesn't
+// prove who wrote it: every member holds the group key, so that rests on the server, which only
+// relays messages sent under the name of the client whose stream they came in on.
+// It returns the additional authenticated data.
+func MessageAAD(m *pb.ChatMessage) []byte {
+
+	return []byte(m.Sender + "/" + m.Receiver + "/" + strconv.FormatUint(m.KeyEpoch, 10))
+}
+
+// NewGCM creates an AES-256-GCM cipher for key.
+// It returns the cipher and an error.
+func NewGCM(key []byte) (cipher.AEAD, error) {
+
+	b, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(b)
+}
+
+// WrapKey encrypts a group key for the member with public key pub.
+// It returns the wrapped key and an error.
+func WrapKey(member string, pub []byte, key []byte, aad []byte) (*pb.WrappedKey, error) {
+
+	p, err := ecdh.X25519().NewPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+
+	eph, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	shared, err := eph.ECDH(p)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := NewGCM(KeyEncryptionKey(shared, eph.PublicKey().Bytes(), pub))
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return &pb.WrappedKey{Client: member, Ephemeral: eph.PublicKey().Bytes(), Nonce: nonce, Ciphertext: gcm.Seal(nil, nonce, key, aad)}, nil
+}
+
+// UnwrapKey decrypts a group key that was wrapped for the holder of private key id.
+// It returns the group key and an error.
+func UnwrapKey(id *ecdh.PrivateKey, w *pb.WrappedKey, aad []byte) ([]byte, error) {
+
+	eph, err := ecdh.X25519().NewPublicKey(w.Ephemeral)
+	if err != nil {
+		return nil, err
+	}
+
+	shared, err := id.ECDH(eph)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := NewGCM(KeyEncryptionKey(shared, w.Ephemeral, id.PublicKey().Bytes()))
+	if err != nil {
+		return nil, err
+	}
+
+	return gcm.Open(nil, w.Nonce, w.Ciphertext, aad)
+}
+
+// LoadGroupKey fetches the user's copy of the group's current key and unwraps it. Ordinary
+// groups just come back marked as unencrypted.
+// It returns an error.
+func LoadGroupKey(c pb.ChatClient, u string, g string, id *ecdh.PrivateKey, keys *GroupKeys) error {
+
+	k, err := c.GetGroupKey(context.Background(), &pb.GroupInfo{Client: u, GroupName: g})
+	if err != nil {
+		return err
+	}
+
+	keys.mu.Lock()
+	keys.encrypted = k.Encrypted
+	keys.mu.Unlock()
+	if len(k.Keys) == 0 {
+		return nil
+	}
+
+	key, err := UnwrapKey(id, k.Keys[0], WrapAAD(g, k.Epoch))
+	if err != nil {
+		return err
+	}
+
+	keys.add(k.Epoch, key)
+
+	return nil
+}
+
+// ShareGroupKey generates a new group key for the epoch after current and shares it with every
+// member of the group who has published a public key.
+// It returns an error.
+func ShareGroupKey(c pb.ChatClient, u string, g string, keys *GroupKeys, current uint64) error {
+
+	m, err := c.GetGroupClientList(context.Background(), &pb.GroupInfo{Client: u, GroupName: g})
+	if err != nil {
+		return err
+	}
+
+	pubs, err := c.GetPublicKeys(context.Background(), m)
+	if err != nil {
+		return err
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+
+	epoch := current + 1
+	gk := &pb.GroupKey{Client: u, GroupName: g, Epoch: epoch}
+	for _, p := range pubs.Keys {
+		w, err := WrapKey(p.Client, p.Key, key, WrapAAD(g, epoch))
+		if err != nil {
+			log.Println("[ShareGroupKey]: Skipping " + p.Client + ": " + err.Error())
+			continue
+		}
+		gk.Keys = append(gk.Keys, w)
+	}
+
+	if _, err := c.ShareGroupKey(context.Background(), gk); err != nil {
+		return err
+	}
+
+	keys.add(epoch, key)
+	return nil
+}
+
+// HandleKeyEvent deals with the key management events of encrypted groups: sharing a new key
+// when the server asks us to and fetching one when another member has shared it.
+// It returns true if m was a key management event.
+func HandleKeyEvent(c pb.ChatClient, u string, g string, id *ecdh.PrivateKey, keys *GroupKeys, m pb.ChatMessage) bool {
+
+	switch m.Kind {
+	case pb.Kind_REKEY:
+		if m.Message == u {
+			if err := ShareGroupKey(c, u, g, keys, m.KeyEpoch); err != nil {
+				log.Println("[HandleKeyEvent]: Couldn't share a new group key: " + err.Error())
+			}
+		}
+	case pb.Kind_KEY:
+		if err := LoadGroupKey(c, u, g, id, keys); err != nil {
+			log.Println("[HandleKeyEvent]: Couldn't load the new group key: " + err.Error())
+		}
+	default:
+		return false
+	}
+
+	return true
+}
+
+// Encrypt replaces the text of an outgoing message with ciphertext under the newest group key.
+// It returns an error.
+func (k *GroupKeys) Encrypt(m *pb.ChatMessage) error {
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	key, ok := k.keys[k.epoch]
+	if !ok {
+		return errors.New("the group key hasn't arrived yet")
+	}
+
+	gcm, err := NewGCM(key)
+	if err != nil {
+		return err
+	}
+
+	m.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(m.Nonce); err != nil {
+		return err
+	}
+
+	m.KeyEpoch = k.epoch
+	m.Ciphertext = gcm.Seal(nil, m.Nonce, []byte(m.Message), MessageAAD(m))
+	m.Message = ""
+
+	return nil
+}
+
+// Decrypt recovers the text of an encrypted message. Messages that can't be decrypted, for
+// example because they were sent before the user joined, are marked as unreadable.
+// It returns the decrypted message.
+func (k *GroupKeys) Decrypt(m pb.ChatMessage) pb.ChatMessage {
+
+	if len(m.Ciphertext) == 0 {
+		return m
+	}
+
+	k.mu.Lock()
+	key, ok := k.keys[m.KeyEpoch]
+	k.mu.Unlock()
+	if !ok {
+		m.Message = "(encrypted message you don't have the key for)\n"
+		return m
+	}
+
+	gcm, err := NewGCM(key)
+	if err == nil {
+		var text []byte
+		if text, err = gcm.Open(nil, m.Nonce, m.Ciphertext, MessageAAD(&m)); err == nil {
+			m.Message = string(text)
+			return m
+		}
+	}
+
+	m.Message = "(encrypted message that couldn't be decrypted)\n"
+	return m
+}
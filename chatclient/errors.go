@@ -0,0 +1,95 @@
+package chatclient
+
+import (
+	"strings"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorMessage describes an error from the server in a sentence fit to show a user, using
+// the status code and details the server sent with it. Errors that didn't come from the
+// server are described by their own text.
+// It returns the message.
+func ErrorMessage(err error) string {
+
+	st := status.Convert(err)
+
+	var res *errdetails.ResourceInfo
+	var retry *errdetails.RetryInfo
+	var bad *errdetails.BadRequest
+	var quota *errdetails.QuotaFailure
+	for _, d := range st.Details() {
+		switch d := d.(type) {
+		case *errdetails.ResourceInfo:
+			res = d
+		case *errdetails.RetryInfo:
+			retry = d
+		case *errdetails.BadRequest:
+			bad = d
+		case *errdetails.QuotaFailure:
+			quota = d
+		}
+	}
+
+	switch st.Code() {
+	case codes.NotFound:
+		if res != nil && res.ResourceName != "" && res.Owner == "" {
+			return "The " + ResourceNoun(res.ResourceType) + " \"" + res.ResourceName + "\" doesn't exist."
+		}
+	case codes.AlreadyExists:
+		if res != nil && res.ResourceName != "" {
+			return "The " + ResourceNoun(res.ResourceType) + " name \"" + res.ResourceName + "\" is already taken."
+		}
+	case codes.InvalidArgument:
+		if bad != nil && len(bad.FieldViolations) > 0 {
+			return Sentence(bad.FieldViolations[0].Description)
+		}
+	case codes.ResourceExhausted:
+		if retry != nil && retry.RetryDelay != nil {
+			d := retry.RetryDelay.AsDuration().Round(time.Second)
+			if d < time.Second {
+				d = time.Second
+			}
+			return "You're doing that too often. Try again in " + d.String() + "."
+		} else if quota == nil {
+			return "You're doing that too often. Try again later."
+		}
+	case codes.Unavailable:
+		return "The server can't be reached right now."
+	case codes.DeadlineExceeded:
+		return "The server took too long to answer."
+	}
+
+	return Sentence(st.Message())
+}
+
+// ResourceNoun names the kind of thing the server calls t the way users know it.
+// It returns the noun.
+func ResourceNoun(t string) string {
+
+	if t == "client" {
+		return "user"
+	}
+
+	return t
+}
+
+// Sentence capitalizes s and ends it with a full stop, unless it already ends in punctuation.
+// It returns the sentence.
+func Sentence(s string) string {
+
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return s
+	}
+
+	s = strings.ToUpper(s[:1]) + s[1:]
+	if !strings.ContainsAny(s[len(s)-1:], ".!?)") {
+		s += "."
+	}
+
+	return s
+}
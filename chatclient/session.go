@@ -0,0 +1,482 @@
+// Package chatclient lets Go programs such as bots and integrations take part in go-chat
+// groups without dealing with the details of the RouteChat stream.
+//
+// A Session is created with Connect and logged in as a user with Login. From then on
+// everything the server sends the user arrives on the session's Events channel, and Join,
+// Send and Leave move the user between groups and talk in them:
+//
+//	s, err := chatclient.Connect("localhost:12021")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer s.Close()
+//
+//	s.Login("echo-bot")
+//	s.Join("general")
+//	for m := range s.Events {
+//		if m.Sender != "echo-bot" {
+//			s.Send(m.Message)
+//		}
+//	}
+//
+// End-to-end encrypted groups are handled by the session: messages are encrypted before
+// they are sent and decrypted before they are delivered on Events.
+package chatclient
+
+import (
+	"crypto/ecdh"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+
+	pb "github.com/taylorflatt/go-chat"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// TokenHeader is the metadata key the server gives a session its token under when it logs in.
+// The session sends the token with every call after that so the server knows the calls are its.
+const TokenHeader = "session-token"
+
+// eventBuffer is how many events are held for a session before new ones are dropped.
+const eventBuffer = 100
+
+// Session is a user's connection to a chat server.
+type Session struct {
+	// Events receives every message and event the server sends the user, in the order they
+	// were sent. Events that arrive while it is full are dropped, so it should be read
+	// promptly. It is closed when the connection to the server is lost or closed.
+	Events <-chan pb.ChatMessage
+
+	conn   *grpc.ClientConn
+	client pb.ChatClient
+	events chan pb.ChatMessage
+	id     *ecdh.PrivateKey // Used to receive the keys of encrypted groups.
+	sendMu sync.Mutex       // Stops messages being sent on the stream concurrently.
+
+	mu      sync.Mutex // Guards the fields below.
+	stream  pb.Chat_RouteChatClient
+	user    string
+	token   string // Authenticates the session's calls.
+	group   string
+	keys    *GroupKeys
+	leaving map[string]int // Leave notices still to come for groups the session left itself.
+}
+
+// Connect opens a connection to the chat server at address (host:port). Any options are added
+// to the defaults, e.g. to dial an in-memory listener in tests.
+// It returns the session and an error.
+func Connect(address string, opts ...grpc.DialOption) (*Session, error) {
+
+	events := make(chan pb.ChatMessage, eventBuffer)
+	s := &Session{
+		Events:  events,
+		events:  events,
+		keys:    NewGroupKeys(),
+		leaving: make(map[string]int),
+	}
+
+	defaults := []grpc.DialOption{
+		grpc.WithInsecure(),
+		grpc.WithChainUnaryInterceptor(s.authenticateUnary),
+		grpc.WithChainStreamInterceptor(s.authenticateStream),
+	}
+	conn, err := grpc.Dial(address, append(defaults, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	s.conn = conn
+	s.client = pb.NewChatClient(conn)
+
+	return s, nil
+}
+
+// Client gives access to the generated client for the RPCs the session doesn't wrap, such as
+// listing groups and members or editing messages.
+// It returns the chat client.
+func (s *Session) Client() pb.ChatClient {
+
+	return s.client
+}
+
+// User returns the name the session is logged in as.
+func (s *Session) User() string {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.user
+}
+
+// Group returns the group the session is chatting in, or an empty string if it isn't in one.
+func (s *Session) Group() string {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.group
+}
+
+// Encrypted reports whether the group the session is chatting in is end-to-end encrypted.
+func (s *Session) Encrypted() bool {
+
+	s.mu.Lock()
+	keys := s.keys
+	s.mu.Unlock()
+
+	return keys.Encrypted()
+}
+
+// Login registers the user u with the server and opens the stream events are delivered on.
+// The server uses the first message on a stream to learn who it belongs to, so an empty one
+// is sent straight away.
+// It returns an error.
+func (s *Session) Login(u string) error {
+
+	return s.login(u, false)
+}
+
+// LoginBot logs in like Login, but marks the user as a bot in member lists.
+// It returns an error.
+func (s *Session) LoginBot(u string) error {
+
+	return s.login(u, true)
+}
+
+// login registers the user u, as a bot if bot is set, and opens the session's stream.
+// It returns an error.
+func (s *Session) login(u string, bot bool) error {
+
+	if s.chatStream() != nil {
+		return errors.New("the session is already logged in as " + s.User())
+	}
+
+	var header metadata.MD
+	if _, err := s.client.Register(context.Background(), &pb.ClientInfo{Sender: u, Bot: bot}, grpc.Header(&header)); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	if t := header.Get(TokenHeader); len(t) > 0 {
+		s.token = t[0]
+	}
+	s.mu.Unlock()
+
+	id, err := CreateIdentity(s.client, u)
+	if err != nil {
+		log.Print("[login]: Couldn't publish a public key, encrypted groups won't work: ", err)
+	}
+
+	stream, err := s.client.RouteChat(context.Background())
+	if err != nil {
+		s.client.UnRegister(context.Background(), &pb.ClientInfo{Sender: u})
+		return err
+	}
+
+	if err := stream.Send(&pb.ChatMessage{Sender: u, Message: ""}); err != nil {
+		s.client.UnRegister(context.Background(), &pb.ClientInfo{Sender: u})
+		return err
+	}
+
+	s.mu.Lock()
+	s.user = u
+	s.id = id
+	s.stream = stream
+	s.mu.Unlock()
+
+	go s.receive()
+	return nil
+}
+
+// Create creates the group g, optionally end-to-end encrypted. The session doesn't join it.
+// It returns an error.
+func (s *Session) Create(g string, encrypted bool) error {
+
+	_, err := s.client.CreateGroup(context.Background(), &pb.GroupInfo{Client: s.User(), GroupName: g, Encrypted: encrypted})
+	return err
+}
+
+// Join joins the group g and announces the user to its members. A session chats in one
+// group at a time, so it leaves the group it is in first.
+// It returns an error.
+func (s *Session) Join(g string) error {
+
+	if s.chatStream() == nil {
+		return errors.New("the session isn't logged in")
+	}
+
+	if cur := s.Group(); cur != "" {
+		if err := s.Leave(); err != nil {
+			return err
+		}
+	}
+
+	u := s.User()
+	if _, err := s.client.JoinGroup(context.Background(), &pb.GroupInfo{Client: u, GroupName: g}); err != nil {
+		return err
+	}
+
+	keys := NewGroupKeys()
+	s.mu.Lock()
+	s.group = g
+	s.keys = keys
+	s.mu.Unlock()
+
+	if err := LoadGroupKey(s.client, u, g, s.id, keys); err != nil {
+		log.Println("[Join]: Couldn't load the group key: " + err.Error())
+	}
+
+	return s.send(&pb.ChatMessage{Sender: u, Receiver: g, Message: "joined chat!\n"})
+}
+
+// Send sends text to the group the session is in.
+// It returns an error.
+func (s *Session) Send(text string) error {
+
+	return s.Reply(0, text)
+}
+
+// Reply sends text to the group the session is in as a reply in the thread of message parent.
+// A parent of 0 sends it to the main chat. Like messages typed into the CLI, text is sent with
+// a trailing newline.
+// It returns an error.
+func (s *Session) Reply(parent uint64, text string) error {
+
+	return s.sendText(pb.Kind_MESSAGE, parent, text)
+}
+
+// Act sends an action to the group the session is in, e.g. Act("waves") for "/me waves".
+// It returns an error.
+func (s *Session) Act(text string) error {
+
+	return s.sendText(pb.Kind_ACTION, 0, text)
+}
+
+// sendText sends text to the group the session is in as a message of kind k, encrypting it
+// if the group is end-to-end encrypted.
+// It returns an error.
+func (s *Session) sendText(k pb.Kind, parent uint64, text string) error {
+
+	s.mu.Lock()
+	if s.group == "" {
+		s.mu.Unlock()
+		return errors.New("the session isn't in a group")
+	}
+
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+
+	msg := pb.ChatMessage{Sender: s.user, Receiver: s.group, Message: text, ParentId: parent, Kind: k}
+	if s.keys.Encrypted() {
+		if err := s.keys.Encrypt(&msg); err != nil {
+			s.mu.Unlock()
+			return err
+		}
+	}
+	s.mu.Unlock()
+
+	return s.send(&msg)
+}
+
+// SendDirect sends text privately to the user to. Private messages aren't end-to-end
+// encrypted and aren't stored by the server. They arrive on Events with the recipient as
+// the receiver, and the server echoes them back to the sender.
+// It returns an error.
+func (s *Session) SendDirect(to string, text string) error {
+
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+
+	return s.send(&pb.ChatMessage{Sender: s.User(), Receiver: to, Message: text, Kind: pb.Kind_DIRECT})
+}
+
+// Command asks the server to run a slash command, e.g. Command("/stats"). The server's
+// response arrives on Events as a COMMAND_RESULT event.
+// It returns an error.
+func (s *Session) Command(line string) error {
+
+	return s.send(&pb.ChatMessage{Sender: s.User(), Receiver: s.Group(), Message: line, Kind: pb.Kind_COMMAND})
+}
+
+// Rename changes the name the session is logged in as to n.
+// It returns an error.
+func (s *Session) Rename(n string) error {
+
+	if _, err := s.client.Rename(context.Background(), &pb.NameChange{Client: s.User(), Name: n}); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.user = n
+	s.mu.Unlock()
+
+	return nil
+}
+
+// authenticate adds the session's token, once it has one, to the metadata of a call.
+// It returns the context to make the call with.
+func (s *Session) authenticate(ctx context.Context) context.Context {
+
+	s.mu.Lock()
+	token := s.token
+	s.mu.Unlock()
+
+	if token == "" {
+		return ctx
+	}
+
+	return metadata.AppendToOutgoingContext(ctx, TokenHeader, token)
+}
+
+// authenticateUnary sends the session's token with each call it makes.
+// It returns an error.
+func (s *Session) authenticateUnary(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+
+	return invoker(s.authenticate(ctx), method, req, reply, cc, opts...)
+}
+
+// authenticateStream sends the session's token with each stream it opens.
+// It returns the stream and an error.
+func (s *Session) authenticateStream(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+
+	return streamer(s.authenticate(ctx), desc, cc, method, opts...)
+}
+
+// send sends msg on the session's stream.
+// It returns an error.
+func (s *Session) send(msg *pb.ChatMessage) error {
+
+	stream := s.chatStream()
+	if stream == nil {
+		return errors.New("the session isn't logged in")
+	}
+
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+
+	return stream.Send(msg)
+}
+
+// chatStream gets the session's stream, which is set once it logs in.
+// It returns the stream, or nil if the session hasn't logged in.
+func (s *Session) chatStream() pb.Chat_RouteChatClient {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.stream
+}
+
+// Leave leaves the group the session is chatting in.
+// It returns an error.
+func (s *Session) Leave() error {
+
+	s.mu.Lock()
+	u, g := s.user, s.group
+	s.group = ""
+	s.keys = NewGroupKeys()
+	if g != "" {
+		s.leaving[g]++
+	}
+	s.mu.Unlock()
+
+	if g == "" {
+		return errors.New("the session isn't in a group")
+	}
+
+	_, err := s.client.LeaveRoom(context.Background(), &pb.GroupInfo{Client: u, GroupName: g})
+	if err != nil {
+		// No notice comes if the server didn't take the user out of the group.
+		s.mu.Lock()
+		s.leaving[g]--
+		s.mu.Unlock()
+	}
+
+	return err
+}
+
+// Decrypt decrypts a message from the group the session is in, such as one returned by
+// GetThread. Messages on Events have already been decrypted.
+// It returns the decrypted message.
+func (s *Session) Decrypt(m pb.ChatMessage) pb.ChatMessage {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if m.Receiver != s.group {
+		return m
+	}
+
+	return s.keys.Decrypt(m)
+}
+
+// Close unregisters the user, if the session logged in, and closes the connection. Events is
+// closed once the stream has shut down.
+// It returns an error.
+func (s *Session) Close() error {
+
+	if u := s.User(); u != "" {
+		s.client.UnRegister(context.Background(), &pb.ClientInfo{Sender: u})
+	}
+
+	return s.conn.Close()
+}
+
+// receive reads the session's stream until it fails. Key management events for the group the
+// session is in are handled here, and those for groups it has left are dropped. Everything else
+// is decrypted and passed on to Events, apart from the notice that the user left a group, which
+// the server only sends back to the user to mark the end of a chat. That notice also arrives
+// when someone else removes the user from a group, so the session leaves the group too.
+// It doesn't return anything.
+func (s *Session) receive() {
+
+	defer close(s.events)
+
+	for {
+		msg, err := s.stream.Recv()
+		if err != nil {
+			log.Println("[receive]: The stream closed: " + err.Error())
+			return
+		}
+
+		if msg.Sender == s.User() && msg.Message == msg.Sender+" left chat!\n" {
+			// Unless the session left the group itself, the user was taken out of it, e.g. by
+			// being kicked.
+			s.mu.Lock()
+			if s.leaving[msg.Receiver] > 0 {
+				s.leaving[msg.Receiver]--
+			} else if msg.Receiver == s.group {
+				s.group = ""
+				s.keys = NewGroupKeys()
+			}
+			s.mu.Unlock()
+			continue
+		}
+
+		// Handling key events calls the server, so it is done without holding the lock. If the
+		// session leaves the group meanwhile, the keys are simply thrown away.
+		s.mu.Lock()
+		u, g, keys := s.user, s.group, s.keys
+		s.mu.Unlock()
+
+		if msg.Receiver != g {
+			if msg.Kind == pb.Kind_REKEY || msg.Kind == pb.Kind_KEY {
+				continue
+			}
+		} else if HandleKeyEvent(s.client, u, g, s.id, keys, *msg) {
+			continue
+		} else {
+			*msg = keys.Decrypt(*msg)
+		}
+
+		select {
+		case s.events <- *msg:
+		default:
+			log.Println("[receive]: Events is full, dropping a message from " + msg.Sender)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+// Chat-openapi writes an OpenAPI (Swagger 2.0) spec for the REST API the server serves, built
+// from the google.api.http options on the RPCs in services.proto. Regenerate the spec after
+// changing them with go generate in the repository root.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"strings"
+
+	_ "github.com/taylorflatt/go-chat"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// Object is a JSON object in the spec.
+type Object map[string]interface{}
+
+func main() {
+
+	out := flag.String("o", "", "The `file` to write the spec to, rather than standard output.")
+	flag.Parse()
+
+	fd, err := protoregistry.GlobalFiles.FindFileByPath("services.proto")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	b, err := json.MarshalIndent(Spec(fd), "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	b = append(b, '\n')
+
+	if *out == "" {
+		os.Stdout.Write(b)
+	} else if err := os.WriteFile(*out, b, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// Spec builds the spec for the RPCs in fd that have an HTTP rule.
+// It returns the spec.
+func Spec(fd protoreflect.FileDescriptor) Object {
+
+	paths := Object{}
+	defs := Object{
+		"rpcStatus": Object{
+			"type": "object",
+			"properties": Object{
+				"code":    Object{"type": "integer", "format": "int32"},
+				"message": Object{"type": "string"},
+			},
+		},
+	}
+	var tags []Object
+
+	for i := 0; i < fd.Services().Len(); i++ {
+		sd := fd.Services().Get(i)
+		tag := string(sd.Name())
+		routed := false
+
+		for j := 0; j < sd.Methods().Len(); j++ {
+			md := sd.Methods().Get(j)
+			rule, ok := proto.GetExtension(md.Options(), annotations.E_Http).(*annotations.HttpRule)
+			if !ok || rule == nil {
+				continue
+			}
+
+			verb, path := Pattern(rule)
+			if verb == "" {
+				log.Fatal(string(md.Name()) + " has an HTTP rule that isn't supported")
+			}
+
+			op := Object{
+				"operationId": tag + "_" + string(md.Name()),
+				"tags":        []string{tag},
+				"responses": Object{
+					"200":     Object{"description": "A successful response.", "schema": Ref(md.Output(), defs)},
+					"default": Object{"description": "An unexpected error response.", "schema": Object{"$ref": "#/definitions/rpcStatus"}},
+				},
+			}
+			if params := Parameters(md.Input(), path, rule.Body == "*", defs); len(params) > 0 {
+				op["parameters"] = params
+			}
+
+			if _, ok := paths[path]; !ok {
+				paths[path] = Object{}
+			}
+			paths[path].(Object)[verb] = op
+			routed = true
+		}
+
+		// Services that aren't served over HTTP, such as Admin, are left out.
+		if routed {
+			tags = append(tags, Object{"name": tag})
+		}
+	}
+
+	return Object{
+		"swagger":     "2.0",
+		"info":        Object{"title": fd.Path(), "version": "version not set"},
+		"tags":        tags,
+		"consumes":    []string{"application/json"},
+		"produces":    []string{"application/json"},
+		"paths":       paths,
+		"definitions": defs,
+	}
+}
+
+// Pattern reads the HTTP method and path template of rule.
+// It returns the method in lower case, or "" if it isn't supported, and the path.
+func Pattern(rule *annotations.HttpRule) (string, string) {
+
+	switch p := rule.Pattern.(type) {
+	case *annotations.HttpRule_Get:
+		return "get", p.Get
+	case *annotations.HttpRule_Post:
+		return "post", p.Post
+	case *annotations.HttpRule_Put:
+		return "put", p.Put
+	case *annotations.HttpRule_Patch:
+		return "patch", p.Patch
+	case *annotations.HttpRule_Delete:
+		return "delete", p.Delete
+	}
+
+	return "", ""
+}
+
+// Parameters describes where the fields of the request message m come from: the path, then
+// either the body or the query.
+// It returns the parameters.
+func Parameters(m protoreflect.MessageDescriptor, path string, body bool, defs Object) []Object {
+
+	var params []Object
+	inPath := map[string]bool{}
+	for _, seg := range strings.Split(path, "/") {
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			n := strings.Trim(seg, "{}")
+			inPath[n] = true
+
+			p := Object{"name": n, "in": "path", "required": true}
+			for k, v := range Schema(m.Fields().ByName(protoreflect.Name(n)), defs) {
+				p[k] = v
+			}
+			params = append(params, p)
+		}
+	}
+
+	if body {
+		return append(params, Object{"name": "body", "in": "body", "required": true, "schema": Ref(m, defs)})
+	}
+
+	for i := 0; i < m.Fields().Len(); i++ {
+		f := m.Fields().Get(i)
+		if inPath[string(f.Name())] {
+			continue
+		}
+
+		p := Object{"name": string(f.Name()), "in": "query", "required": false}
+		for k, v := range Schema(f, defs) {
+			p[k] = v
+		}
+		if f.IsList() {
+			p["collectionFormat"] = "multi"
+		}
+		params = append(params, p)
+	}
+
+	return params
+}
+
+// Ref adds a definition of message m to defs if it isn't there already.
+// It returns a reference to the definition.
+func Ref(m protoreflect.MessageDescriptor, defs Object) Object {
+
+	n := strings.ReplaceAll(string(m.ParentFile().Package()), ".", "") + string(m.Name())
+	if _, ok := defs[n]; !ok {
+		props := Object{}
+		defs[n] = Object{"type": "object", "properties": props}
+		for i := 0; i < m.Fields().Len(); i++ {
+			f := m.Fields().Get(i)
+			props[string(f.Name())] = Schema(f, defs)
+		}
+	}
+
+	return Object{"$ref": "#/definitions/" + n}
+}
+
+// Schema describes the JSON form of field f. 64 bit numbers are strings in JSON.
+// It returns the schema.
+func Schema(f protoreflect.FieldDescriptor, defs Object) Object {
+
+	var s Object
+	switch f.Kind() {
+	case protoreflect.StringKind:
+		s = Object{"type": "string"}
+	case protoreflect.BoolKind:
+		s = Object{"type": "boolean"}
+	case protoreflect.BytesKind:
+		s = Object{"type": "string", "format": "byte"}
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		s = Object{"type": "string", "format": "uint64"}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		s = Object{"type": "string", "format": "int64"}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		s = Object{"type": "integer", "format": "int64"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		s = Object{"type": "integer", "format": "int32"}
+	case protoreflect.EnumKind:
+		var vs []string
+		for i := 0; i < f.Enum().Values().Len(); i++ {
+			vs = append(vs, string(f.Enum().Values().Get(i).Name()))
+		}
+		s = Object{"type": "string", "enum": vs}
+	case protoreflect.MessageKind:
+		s = Ref(f.Message(), defs)
+	default:
+		s = Object{"type": "number"}
+	}
+
+	if f.IsList() {
+		return Object{"type": "array", "items": s}
+	}
+
+	return s
+}
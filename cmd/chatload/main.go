@@ -0,0 +1,416 @@
+// Chatload load tests a chat server. It logs in simulated clients spread over groups, has each
+// send messages to its group at a steady rate, and reports how long messages took to be
+// delivered, how many were delivered per second and how many never arrived.
+//
+// For example, 200 clients in 10 groups each sending 2 messages a second for a minute:
+//
+//	chatload -server localhost:12021 -clients 200 -groups 10 -rate 2 -duration 1m
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/taylorflatt/go-chat/chatclient"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// payloadTag starts every message chatload sends, so they can be told apart from anything else.
+const payloadTag = "chatload"
+
+// progress reports how a run is going. The standard logger is left to the client library, which
+// is quiet unless -v is given.
+var progress = log.New(os.Stderr, "", log.LstdFlags)
+
+// Config is what a run does.
+type Config struct {
+	Server   string        `json:"server"`
+	Clients  int           `json:"clients"`
+	Groups   int           `json:"groups"`
+	Rate     float64       `json:"rate"` // Messages each client sends per second.
+	Duration time.Duration `json:"-"`
+	Size     int           `json:"size"` // The length of each message, in bytes.
+	Settle   time.Duration `json:"-"`    // How long to wait for messages still on their way once sending stops.
+}
+
+// Report is what a run measured.
+type Report struct {
+	Config     Config  `json:"config"`
+	Seconds    float64 `json:"seconds"` // How long messages were sent for.
+	Sent       int64   `json:"sent"`
+	SendErrors int64   `json:"send_errors"`
+	Expected   int64   `json:"expected"` // Deliveries there should have been: every message to every member of its group.
+	Delivered  int64   `json:"delivered"`
+	Dropped    int64   `json:"dropped"`
+	DropRate   float64 `json:"drop_rate"`
+	SendRate   float64 `json:"send_rate"`  // Messages sent per second.
+	Throughput float64 `json:"throughput"` // Messages delivered per second.
+	P50        float64 `json:"p50_ms"`     // Delivery latency percentiles, in milliseconds.
+	P90        float64 `json:"p90_ms"`
+	P99        float64 `json:"p99_ms"`
+	Max        float64 `json:"max_ms"`
+}
+
+// Client is one simulated client.
+type Client struct {
+	id      int
+	group   string
+	s       *chatclient.Session
+	mu      sync.Mutex
+	latency []time.Duration // How long each message this client received took to arrive.
+	done    chan struct{}   // Closed once the client has stopped receiving.
+}
+
+// closeTimeout is how long logging out the clients may take before the report is printed anyway.
+// A saturated server can take a long while to let go of them.
+const closeTimeout = 10 * time.Second
+
+func main() {
+
+	var c Config
+	flag.StringVar(&c.Server, "server", "localhost:12021", "The `host:port` of the chat server.")
+	flag.IntVar(&c.Clients, "clients", 50, "The `number` of clients to simulate.")
+	flag.IntVar(&c.Groups, "groups", 5, "The `number` of groups to spread the clients over.")
+	flag.Float64Var(&c.Rate, "rate", 1, "The `number` of messages each client sends per second.")
+	flag.DurationVar(&c.Duration, "duration", 30*time.Second, "How `long` to send messages for.")
+	flag.IntVar(&c.Size, "size", 64, "The length of each message in `bytes`.")
+	flag.DurationVar(&c.Settle, "settle", 5*time.Second, "How `long` to wait for late messages once sending stops.")
+	asJSON := flag.Bool("json", false, "Print the report as JSON.")
+	verbose := flag.Bool("v", false, "Show what the clients log.")
+	flag.Parse()
+
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+
+	if c.Clients < 1 || c.Groups < 1 || c.Groups > c.Clients || c.Rate <= 0 {
+		progress.Fatal("chatload needs at least one client per group and a rate above 0")
+	}
+
+	r, err := Run(c)
+	if err != nil {
+		progress.Fatal(err)
+	}
+
+	if *asJSON {
+		b, _ := json.MarshalIndent(r, "", "  ")
+		fmt.Println(string(b))
+	} else {
+		Print(r)
+	}
+}
+
+// Run logs in the clients, has them send messages for the configured time, then logs them out.
+// It returns what was measured and an error if the clients couldn't be set up.
+func Run(c Config) (Report, error) {
+
+	run := RunId()
+	progress.Printf("Logging in %d clients...", c.Clients)
+
+	clients, err := Setup(c, run)
+	if err != nil {
+		return Report{}, err
+	}
+
+	members := make(map[string]int64)
+	for _, cl := range clients {
+		members[cl.group]++
+	}
+
+	var sent, sendErrors, expected int64
+
+	progress.Printf("Sending for %s...", c.Duration)
+	start := time.Now()
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for _, cl := range clients {
+		wg.Add(1)
+		go func(cl *Client) {
+			defer wg.Done()
+			n, errs := cl.Send(c, stop)
+			atomic.AddInt64(&sent, n)
+			atomic.AddInt64(&sendErrors, errs)
+			atomic.AddInt64(&expected, (n-errs)*members[cl.group])
+		}(cl)
+	}
+
+	time.Sleep(c.Duration)
+	close(stop)
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	progress.Printf("Waiting %s for messages still on their way...", c.Settle)
+	time.Sleep(c.Settle)
+
+	// Anything that hasn't arrived by now counts as dropped.
+	var latency []time.Duration
+	for _, cl := range clients {
+		cl.mu.Lock()
+		latency = append(latency, cl.latency...)
+		cl.mu.Unlock()
+	}
+
+	progress.Print("Logging out...")
+	Logout(clients)
+
+	return Measure(c, sent, sendErrors, expected, latency, elapsed), nil
+}
+
+// RunId picks a random id for a run, so the names of its clients and groups don't clash with
+// anyone else's.
+// It returns the id.
+func RunId() string {
+
+	b := make([]byte, 3)
+	rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+// Setup logs in the clients and puts client i in group i mod the number of groups.
+// It returns the clients and an error.
+func Setup(c Config, run string) ([]*Client, error) {
+
+	clients := make([]*Client, c.Clients)
+	errs := make([]error, c.Clients)
+
+	// The first member of each group creates it, before anyone tries to join.
+	sem := make(chan struct{}, 32)
+	var wg sync.WaitGroup
+	for _, first := range []bool{true, false} {
+		for i := range clients {
+			if (i < c.Groups) != first {
+				continue
+			}
+
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(i int) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				clients[i], errs[i] = Login(c.Server, run, i, "load-"+run+"-g"+strconv.Itoa(i%c.Groups), first)
+			}(i)
+		}
+		wg.Wait()
+	}
+
+	for i, err := range errs {
+		if err != nil {
+			Teardown(clients)
+			if status.Code(err) == codes.ResourceExhausted {
+				return nil, fmt.Errorf("client %d: %v (every client logs in from this address, so start the server with -call-rate 0)", i, status.Convert(err).Message())
+			}
+			return nil, fmt.Errorf("client %d: %v", i, err)
+		}
+	}
+
+	return clients, nil
+}
+
+// Login logs in client i of the run and joins group g, creating it first if create is set.
+// It returns the client and an error.
+func Login(server string, run string, i int, g string, create bool) (*Client, error) {
+
+	s, err := chatclient.Connect(server)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Login("load-" + run + "-" + strconv.Itoa(i)); err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	if create {
+		if err := s.Create(g, false); err != nil {
+			s.Close()
+			return nil, err
+		}
+	}
+
+	// Start receiving before joining: a client that lets its messages pile up holds up the whole
+	// group.
+	cl := &Client{id: i, group: g, s: s, done: make(chan struct{})}
+	go cl.Receive()
+
+	if err := s.Join(g); err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	return cl, nil
+}
+
+// Logout leaves the clients' groups and logs them out, all at once, giving up after closeTimeout.
+// It doesn't return anything.
+func Logout(clients []*Client) {
+
+	var wg sync.WaitGroup
+	for _, cl := range clients {
+		wg.Add(1)
+		go func(cl *Client) {
+			defer wg.Done()
+			cl.s.Leave()
+			cl.s.Close()
+			<-cl.done
+		}(cl)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(closeTimeout):
+		progress.Print("Gave up waiting for the clients to log out")
+	}
+}
+
+// Teardown logs out the clients that logged in before setting up failed.
+// It doesn't return anything.
+func Teardown(clients []*Client) {
+
+	for _, cl := range clients {
+		if cl != nil {
+			cl.s.Close()
+		}
+	}
+}
+
+// Send sends a message at the configured rate until stop is closed. Each message holds the time
+// it was sent, padded to the configured size.
+// It returns how many messages were sent and how many of those failed.
+func (cl *Client) Send(c Config, stop <-chan struct{}) (int64, int64) {
+
+	interval := time.Duration(float64(time.Second) / c.Rate)
+
+	// Spread the clients out over the first interval rather than sending all at once.
+	select {
+	case <-time.After(time.Duration(cl.id) * interval / time.Duration(c.Clients)):
+	case <-stop:
+		return 0, 0
+	}
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	var sent, errs int64
+	for seq := 0; ; seq++ {
+		msg := payloadTag + " " + strconv.FormatInt(time.Now().UnixNano(), 10) + " " + strconv.Itoa(seq) + " "
+		if len(msg) < c.Size {
+			msg += strings.Repeat("x", c.Size-len(msg))
+		}
+
+		sent++
+		if err := cl.s.Send(msg); err != nil {
+			errs++
+		}
+
+		select {
+		case <-t.C:
+		case <-stop:
+			return sent, errs
+		}
+	}
+}
+
+// Receive records how long each load message the client receives took to arrive, until its
+// session closes.
+// It doesn't return anything.
+func (cl *Client) Receive() {
+
+	defer close(cl.done)
+
+	for m := range cl.s.Events {
+		f := strings.Fields(m.Message)
+		if len(f) < 3 || f[0] != payloadTag {
+			continue
+		}
+
+		ns, err := strconv.ParseInt(f[1], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		d := time.Since(time.Unix(0, ns))
+		cl.mu.Lock()
+		cl.latency = append(cl.latency, d)
+		cl.mu.Unlock()
+	}
+}
+
+// Measure works out the figures for a run.
+// It returns the report.
+func Measure(c Config, sent int64, sendErrors int64, expected int64, latency []time.Duration, elapsed time.Duration) Report {
+
+	r := Report{
+		Config:     c,
+		Seconds:    elapsed.Seconds(),
+		Sent:       sent,
+		SendErrors: sendErrors,
+		Expected:   expected,
+		Delivered:  int64(len(latency)),
+		SendRate:   float64(sent) / elapsed.Seconds(),
+		Throughput: float64(len(latency)) / elapsed.Seconds(),
+	}
+
+	if r.Dropped = r.Expected - r.Delivered; r.Dropped < 0 {
+		r.Dropped = 0
+	}
+	if r.Expected > 0 {
+		r.DropRate = float64(r.Dropped) / float64(r.Expected)
+	}
+
+	sort.Slice(latency, func(i, j int) bool { return latency[i] < latency[j] })
+	r.P50 = Percentile(latency, 50)
+	r.P90 = Percentile(latency, 90)
+	r.P99 = Percentile(latency, 99)
+	r.Max = Percentile(latency, 100)
+
+	return r
+}
+
+// Percentile finds the pth percentile of the sorted latencies l.
+// It returns the latency in milliseconds, or 0 if there are none.
+func Percentile(l []time.Duration, p float64) float64 {
+
+	if len(l) == 0 {
+		return 0
+	}
+
+	i := int(math.Ceil(p/100*float64(len(l)))) - 1
+	if i < 0 {
+		i = 0
+	}
+
+	return float64(l[i]) / float64(time.Millisecond)
+}
+
+// Print writes the report as text.
+// It doesn't return anything.
+func Print(r Report) {
+
+	c := r.Config
+	fmt.Printf("%d clients in %d groups, %g messages/s each for %s against %s\n\n", c.Clients, c.Groups, c.Rate, c.Duration, c.Server)
+	fmt.Printf("sent        %d (%.1f/s), %d failed\n", r.Sent, r.SendRate, r.SendErrors)
+	fmt.Printf("delivered   %d of %d (%.1f/s)\n", r.Delivered, r.Expected, r.Throughput)
+	fmt.Printf("dropped     %d (%.2f%%)\n", r.Dropped, r.DropRate*100)
+	fmt.Printf("latency     p50 %.2fms  p90 %.2fms  p99 %.2fms  max %.2fms\n", r.P50, r.P90, r.P99, r.Max)
+}
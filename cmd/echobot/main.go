@@ -0,0 +1,44 @@
+// Echobot is a bot for trying out a server: it greets people who join its group, repeats what
+// they say and answers !ping.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	pb "github.com/taylorflatt/go-chat"
+	"github.com/taylorflatt/go-chat/bot"
+)
+
+func main() {
+
+	server := flag.String("server", "localhost:12021", "The `host:port` of the chat server.")
+	name := flag.String("name", "echo-bot", "The `name` the bot logs in with.")
+	group := flag.String("group", "general", "The `group` the bot chats in.")
+	create := flag.Bool("create", true, "Create the group if it doesn't exist.")
+	flag.Parse()
+
+	b := bot.New(*server, *name, *group)
+	b.Create = *create
+
+	b.OnJoin(func(b *bot.Bot, u string) {
+		b.Send("Welcome, " + u + "! Type !help to see what I can do.")
+	})
+	b.OnMessage(func(b *bot.Bot, m pb.ChatMessage) {
+		b.Respond(m, m.Sender+" said: "+m.Message)
+	})
+	b.Command("ping", "Checks that the bot is alive.", func(b *bot.Bot, m pb.ChatMessage, args string) {
+		b.Respond(m, "pong")
+	})
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := b.Run(ctx); err != nil {
+		log.Fatal(err)
+	}
+}
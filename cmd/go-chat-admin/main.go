@@ -0,0 +1,312 @@
+// Go-chat-admin manages a running chat server through its Admin service, which the server
+// serves when it is started with -admin.
+//
+//	go-chat-admin sessions
+//	go-chat-admin disconnect --reason "Spamming" mallory
+//	go-chat-admin delete-group spam
+//	go-chat-admin announce "Restarting in 5 minutes"
+//	go-chat-admin stats
+//	go-chat-admin reload
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	pb "github.com/taylorflatt/go-chat"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// Exit codes of the commands.
+const (
+	exitOK    = 0 // The command succeeded.
+	exitError = 1 // The command failed, e.g. the server couldn't be reached.
+	exitUsage = 2 // The command line was invalid.
+)
+
+// defaultServer is where the Admin service is unless told otherwise.
+const defaultServer = "localhost:12023"
+
+// callTimeout is how long the server has to answer.
+const callTimeout = 10 * time.Second
+
+// commands holds the commands by name. Each one is given the arguments after its name and
+// returns the exit code.
+var commands = map[string]func(args []string) int{
+	"sessions":     SessionsCommand,
+	"disconnect":   DisconnectCommand,
+	"delete-group": DeleteGroupCommand,
+	"announce":     AnnounceCommand,
+	"stats":        StatsCommand,
+	"reload":       ReloadCommand,
+}
+
+func main() {
+
+	if len(os.Args) < 2 {
+		Usage()
+		os.Exit(exitUsage)
+	}
+
+	run, ok := commands[os.Args[1]]
+	if !ok {
+		Usage()
+		os.Exit(exitUsage)
+	}
+
+	os.Exit(run(os.Args[2:]))
+}
+
+// Usage lists the commands on stderr.
+// It doesn't return anything.
+func Usage() {
+
+	fmt.Fprintln(os.Stderr, "Usage: go-chat-admin sessions|disconnect|delete-group|announce|stats|reload [flags] (run one with -h for its flags)")
+}
+
+// Fail prints an error for the command cmd to stderr, without the gRPC details of errors from
+// the server.
+// It returns the exit code code.
+func Fail(cmd string, code int, err error) int {
+
+	fmt.Fprintln(os.Stderr, "go-chat-admin "+cmd+": "+status.Convert(err).Message())
+	return code
+}
+
+// NewFlagSet creates the flags for a command along with the --server flag every command has.
+// It returns the flag set and the server flag.
+func NewFlagSet(cmd string, usage string) (*flag.FlagSet, *string) {
+
+	fs := flag.NewFlagSet(cmd, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: go-chat-admin "+cmd+" "+usage)
+		fs.PrintDefaults()
+	}
+
+	return fs, fs.String("server", defaultServer, "The `host:port` of the server's Admin service.")
+}
+
+// Call connects to the Admin service at server and runs call against it.
+// It returns an error.
+func Call(server string, call func(ctx context.Context, a pb.AdminClient) error) error {
+
+	conn, err := grpc.Dial(server, grpc.WithInsecure())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+
+	return call(ctx, pb.NewAdminClient(conn))
+}
+
+// SessionsCommand runs `go-chat-admin sessions`, which lists everyone connected.
+// It returns the exit code.
+func SessionsCommand(args []string) int {
+
+	fs, server := NewFlagSet("sessions", "[--json]")
+	asJSON := fs.Bool("json", false, "Write one JSON object per line instead of a table.")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	var l *pb.SessionList
+	err := Call(*server, func(ctx context.Context, a pb.AdminClient) (err error) {
+		l, err = a.ListSessions(ctx, &pb.Empty{})
+		return err
+	})
+	if err != nil {
+		return Fail("sessions", exitError, err)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		for _, s := range l.Sessions {
+			enc.Encode(struct {
+				Client    string   `json:"client"`
+				Bot       bool     `json:"bot"`
+				Connected string   `json:"connected"`
+				Address   string   `json:"address"`
+				Groups    []string `json:"groups"`
+			}{s.Client, s.Bot, time.Unix(s.Connected, 0).UTC().Format(time.RFC3339), s.Address, s.Groups})
+		}
+		return exitOK
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "CLIENT\tCONNECTED\tADDRESS\tGROUPS")
+	for _, s := range l.Sessions {
+		name := s.Client
+		if s.Bot {
+			name += " (bot)"
+		}
+		since := time.Since(time.Unix(s.Connected, 0)).Round(time.Second)
+		fmt.Fprintf(w, "%s\t%s ago\t%s\t%s\n", name, since, s.Address, strings.Join(s.Groups, ", "))
+	}
+	w.Flush()
+
+	return exitOK
+}
+
+// DisconnectCommand runs `go-chat-admin disconnect`, which logs a user out.
+// It returns the exit code.
+func DisconnectCommand(args []string) int {
+
+	fs, server := NewFlagSet("disconnect", "[--reason <reason>] <user>")
+	reason := fs.String("reason", "", "Why the user is being disconnected, which they are told.")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	u := fs.Arg(0)
+	err := Call(*server, func(ctx context.Context, a pb.AdminClient) error {
+		_, err := a.DisconnectUser(ctx, &pb.Disconnect{Client: u, Reason: *reason})
+		return err
+	})
+	if err != nil {
+		return Fail("disconnect", exitError, err)
+	}
+
+	fmt.Println("Disconnected " + u + ".")
+	return exitOK
+}
+
+// DeleteGroupCommand runs `go-chat-admin delete-group`, which removes a group and takes its
+// members out of it.
+// It returns the exit code.
+func DeleteGroupCommand(args []string) int {
+
+	fs, server := NewFlagSet("delete-group", "<group>")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	g := fs.Arg(0)
+	err := Call(*server, func(ctx context.Context, a pb.AdminClient) error {
+		_, err := a.DeleteGroup(ctx, &pb.GroupInfo{GroupName: g})
+		return err
+	})
+	if err != nil {
+		return Fail("delete-group", exitError, err)
+	}
+
+	fmt.Println("Deleted " + g + ".")
+	return exitOK
+}
+
+// AnnounceCommand runs `go-chat-admin announce`, which sends a notice to everyone connected.
+// The notice is the rest of the command line.
+// It returns the exit code.
+func AnnounceCommand(args []string) int {
+
+	fs, server := NewFlagSet("announce", "<message>")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	text := strings.Join(fs.Args(), " ")
+	if strings.TrimSpace(text) == "" {
+		fs.Usage()
+		return exitUsage
+	}
+
+	err := Call(*server, func(ctx context.Context, a pb.AdminClient) error {
+		_, err := a.BroadcastAnnouncement(ctx, &pb.Announcement{Message: text})
+		return err
+	})
+	if err != nil {
+		return Fail("announce", exitError, err)
+	}
+
+	return exitOK
+}
+
+// StatsCommand runs `go-chat-admin stats`, which shows how busy the server is.
+// It returns the exit code.
+func StatsCommand(args []string) int {
+
+	fs, server := NewFlagSet("stats", "[--json]")
+	asJSON := fs.Bool("json", false, "Write the stats as JSON.")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	var st *pb.ServerStats
+	err := Call(*server, func(ctx context.Context, a pb.AdminClient) (err error) {
+		st, err = a.GetServerStats(ctx, &pb.Empty{})
+		return err
+	})
+	if err != nil {
+		return Fail("stats", exitError, err)
+	}
+
+	if *asJSON {
+		json.NewEncoder(os.Stdout).Encode(st)
+		return exitOK
+	}
+
+	fmt.Printf("uptime           %s\n", time.Duration(st.UptimeSeconds)*time.Second)
+	fmt.Printf("clients          %d (%d bots)\n", st.Clients, st.Bots)
+	fmt.Printf("groups           %d\n", st.Groups)
+	fmt.Printf("stored messages  %d\n", st.StoredMessages)
+	fmt.Printf("webhooks         %d\n", st.Webhooks)
+	fmt.Printf("goroutines       %d\n", st.Goroutines)
+
+	return exitOK
+}
+
+// ReloadCommand runs `go-chat-admin reload`, which has the server read its webhooks file again.
+// It returns the exit code.
+func ReloadCommand(args []string) int {
+
+	fs, server := NewFlagSet("reload", "")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	var ci *pb.ConfigInfo
+	err := Call(*server, func(ctx context.Context, a pb.AdminClient) (err error) {
+		ci, err = a.ReloadConfig(ctx, &pb.Empty{})
+		return err
+	})
+	if err != nil {
+		return Fail("reload", exitError, err)
+	}
+
+	fmt.Printf("Loaded %d webhook(s) from %s.\n", ci.Webhooks, ci.WebhooksFile)
+	return exitOK
+}
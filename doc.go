@@ -1,19 +1,21 @@
 package goChat
 
+//go:generate go run ./cmd/chat-openapi -o services.swagger.json
+
 /*
 Go-Chat is a straight forward client-server chat app implemented using gRPC with protocol buffers. The code is well documented to help individuals learn as well as contribute.package go-chat
 
 
 To begin, start the server:
 	cd [PATH_TO_SRC]/Server
-	go run server.go
+	go run .
 
 	// Alternatively:
 	go build
 
 Now, you can start spinning up as many clients as you wish:
 	cd [PATH_TO_SRC]/client
-	go run client.go menu.go
+	go run .
 
 	// Alternatively:
 	go build
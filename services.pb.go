@@ -15,6 +15,30 @@ It has these top-level messages:
 	GroupInfo
 	GroupList
 	ClientList
+	ReadMarker
+	SeenInfo
+	MessageEdit
+	ReactionInfo
+	Reaction
+	MessageRef
+	MessageList
+	FileInfo
+	FileChunk
+	FileRequest
+	PublicKey
+	PublicKeyList
+	WrappedKey
+	GroupKey
+	Topic
+	NameChange
+	IncomingHook
+	IncomingHookList
+	Session
+	SessionList
+	Disconnect
+	Announcement
+	ServerStats
+	ConfigInfo
 */
 package goChat
 
@@ -27,6 +51,8 @@ import (
 
 	context "golang.org/x/net/context"
 
+	_ "google.golang.org/genproto/googleapis/api/annotations"
+
 	grpc "google.golang.org/grpc"
 )
 
@@ -41,6 +67,99 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 
+// Distinguishes regular chat messages from events about earlier messages.
+type Kind int32
+
+const (
+	// A regular chat message.
+	Kind_MESSAGE Kind = 0
+	// The message with the given id was edited to the new text.
+	Kind_EDIT Kind = 1
+	// The message with the given id was deleted.
+	Kind_DELETE Kind = 2
+	// The sender reacted to the message with the given id using the emoji in message.
+	Kind_REACT Kind = 3
+	// The sender took back their reaction to the message with the given id.
+	Kind_UNREACT Kind = 4
+	// The message with the given id mentions the receiving client. Receiver holds the group
+	// the message was sent to, which may not be the one the client is chatting in.
+	Kind_MENTION Kind = 5
+	// A file was shared with the group. The file field describes it.
+	Kind_ATTACHMENT Kind = 6
+	// The membership of an encrypted group changed. The client named in message should share a
+	// new group key for the epoch after key_epoch.
+	Kind_REKEY Kind = 7
+	// A new group key was shared for key_epoch and can be fetched with GetGroupKey.
+	Kind_KEY Kind = 8
+	// A private message. Receiver holds the client it was sent to rather than a group.
+	Kind_DIRECT Kind = 9
+	// The sender changed the group's topic to the text in message.
+	Kind_TOPIC Kind = 10
+	// The sender describes an action, e.g. "/me waves" is sent as "waves".
+	Kind_ACTION Kind = 11
+	// The sender is now known by the name in message.
+	Kind_NICK Kind = 12
+	// A slash command for the server to run, e.g. "/stats". Receiver holds the group it was
+	// run in, if any.
+	Kind_COMMAND Kind = 13
+	// The server's response to a command, sent only to the client that ran it.
+	Kind_COMMAND_RESULT Kind = 14
+	// A notice from the server's operators in message. Receiver holds the client it was sent to.
+	Kind_ANNOUNCEMENT Kind = 15
+	// A message the client sent was dropped for going over a rate limit. Message says why and how
+	// long the client is muted for, and receiver holds where the message was going.
+	Kind_RATE_LIMITED Kind = 16
+	// A message the client sent was turned away as invalid, e.g. for being too long. Message says
+	// why, and receiver holds where the message was going.
+	Kind_REJECTED Kind = 17
+)
+
+var Kind_name = map[int32]string{
+	0:  "MESSAGE",
+	1:  "EDIT",
+	2:  "DELETE",
+	3:  "REACT",
+	4:  "UNREACT",
+	5:  "MENTION",
+	6:  "ATTACHMENT",
+	7:  "REKEY",
+	8:  "KEY",
+	9:  "DIRECT",
+	10: "TOPIC",
+	11: "ACTION",
+	12: "NICK",
+	13: "COMMAND",
+	14: "COMMAND_RESULT",
+	15: "ANNOUNCEMENT",
+	16: "RATE_LIMITED",
+	17: "REJECTED",
+}
+var Kind_value = map[string]int32{
+	"MESSAGE":        0,
+	"EDIT":           1,
+	"DELETE":         2,
+	"REACT":          3,
+	"UNREACT":        4,
+	"MENTION":        5,
+	"ATTACHMENT":     6,
+	"REKEY":          7,
+	"KEY":            8,
+	"DIRECT":         9,
+	"TOPIC":          10,
+	"ACTION":         11,
+	"NICK":           12,
+	"COMMAND":        13,
+	"COMMAND_RESULT": 14,
+	"ANNOUNCEMENT":   15,
+	"RATE_LIMITED":   16,
+	"REJECTED":       17,
+}
+
+func (x Kind) String() string {
+	return proto.EnumName(Kind_name, int32(x))
+}
+func (Kind) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
+
 type Empty struct {
 }
 
@@ -53,6 +172,22 @@ type ChatMessage struct {
 	Sender   string `protobuf:"bytes,1,opt,name=sender" json:"sender,omitempty"`
 	Receiver string `protobuf:"bytes,2,opt,name=receiver" json:"receiver,omitempty"`
 	Message  string `protobuf:"bytes,3,opt,name=message" json:"message,omitempty"`
+	// Sequence number assigned by the server, unique within a group.
+	Id        uint64      `protobuf:"varint,4,opt,name=id" json:"id,omitempty"`
+	Kind      Kind        `protobuf:"varint,5,opt,name=kind,enum=goChat.Kind" json:"kind,omitempty"`
+	Edited    bool        `protobuf:"varint,6,opt,name=edited" json:"edited,omitempty"`
+	Deleted   bool        `protobuf:"varint,7,opt,name=deleted" json:"deleted,omitempty"`
+	Reactions []*Reaction `protobuf:"bytes,8,rep,name=reactions" json:"reactions,omitempty"`
+	// Id of the message this one replies to, or 0 if it isn't part of a thread.
+	ParentId uint64 `protobuf:"varint,9,opt,name=parent_id,json=parentId" json:"parent_id,omitempty"`
+	// Number of replies in the thread started by this message.
+	Replies uint64    `protobuf:"varint,10,opt,name=replies" json:"replies,omitempty"`
+	File    *FileInfo `protobuf:"bytes,11,opt,name=file" json:"file,omitempty"`
+	// Set instead of message in end-to-end encrypted groups.
+	Ciphertext []byte `protobuf:"bytes,12,opt,name=ciphertext" json:"ciphertext,omitempty"`
+	Nonce      []byte `protobuf:"bytes,13,opt,name=nonce" json:"nonce,omitempty"`
+	// The epoch of the group key the ciphertext was encrypted with.
+	KeyEpoch uint64 `protobuf:"varint,14,opt,name=key_epoch,json=keyEpoch" json:"key_epoch,omitempty"`
 }
 
 func (m *ChatMessage) Reset()                    { *m = ChatMessage{} }
@@ -81,8 +216,87 @@ func (m *ChatMessage) GetMessage() string {
 	return ""
 }
 
+func (m *ChatMessage) GetId() uint64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *ChatMessage) GetKind() Kind {
+	if m != nil {
+		return m.Kind
+	}
+	return Kind_MESSAGE
+}
+
+func (m *ChatMessage) GetEdited() bool {
+	if m != nil {
+		return m.Edited
+	}
+	return false
+}
+
+func (m *ChatMessage) GetDeleted() bool {
+	if m != nil {
+		return m.Deleted
+	}
+	return false
+}
+
+func (m *ChatMessage) GetReactions() []*Reaction {
+	if m != nil {
+		return m.Reactions
+	}
+	return nil
+}
+
+func (m *ChatMessage) GetParentId() uint64 {
+	if m != nil {
+		return m.ParentId
+	}
+	return 0
+}
+
+func (m *ChatMessage) GetReplies() uint64 {
+	if m != nil {
+		return m.Replies
+	}
+	return 0
+}
+
+func (m *ChatMessage) GetFile() *FileInfo {
+	if m != nil {
+		return m.File
+	}
+	return nil
+}
+
+func (m *ChatMessage) GetCiphertext() []byte {
+	if m != nil {
+		return m.Ciphertext
+	}
+	return nil
+}
+
+func (m *ChatMessage) GetNonce() []byte {
+	if m != nil {
+		return m.Nonce
+	}
+	return nil
+}
+
+func (m *ChatMessage) GetKeyEpoch() uint64 {
+	if m != nil {
+		return m.KeyEpoch
+	}
+	return 0
+}
+
 type ClientInfo struct {
 	Sender string `protobuf:"bytes,1,opt,name=sender" json:"sender,omitempty"`
+	// Whether a client registering is a bot rather than a person.
+	Bot bool `protobuf:"varint,2,opt,name=bot" json:"bot,omitempty"`
 }
 
 func (m *ClientInfo) Reset()                    { *m = ClientInfo{} }
@@ -97,9 +311,18 @@ func (m *ClientInfo) GetSender() string {
 	return ""
 }
 
+func (m *ClientInfo) GetBot() bool {
+	if m != nil {
+		return m.Bot
+	}
+	return false
+}
+
 type GroupInfo struct {
 	Client    string `protobuf:"bytes,1,opt,name=client" json:"client,omitempty"`
 	GroupName string `protobuf:"bytes,2,opt,name=groupName" json:"groupName,omitempty"`
+	// Whether a group being created should be end-to-end encrypted.
+	Encrypted bool `protobuf:"varint,3,opt,name=encrypted" json:"encrypted,omitempty"`
 }
 
 func (m *GroupInfo) Reset()                    { *m = GroupInfo{} }
@@ -121,8 +344,19 @@ func (m *GroupInfo) GetGroupName() string {
 	return ""
 }
 
+func (m *GroupInfo) GetEncrypted() bool {
+	if m != nil {
+		return m.Encrypted
+	}
+	return false
+}
+
 type GroupList struct {
 	Groups []string `protobuf:"bytes,1,rep,name=groups" json:"groups,omitempty"`
+	// Unread message count for the requesting client, matched by index to groups.
+	Unread []uint64 `protobuf:"varint,2,rep,packed,name=unread" json:"unread,omitempty"`
+	// Whether each group is end-to-end encrypted, matched by index to groups.
+	Encrypted []bool `protobuf:"varint,3,rep,packed,name=encrypted" json:"encrypted,omitempty"`
 }
 
 func (m *GroupList) Reset()                    { *m = GroupList{} }
@@ -137,8 +371,24 @@ func (m *GroupList) GetGroups() []string {
 	return nil
 }
 
+func (m *GroupList) GetUnread() []uint64 {
+	if m != nil {
+		return m.Unread
+	}
+	return nil
+}
+
+func (m *GroupList) GetEncrypted() []bool {
+	if m != nil {
+		return m.Encrypted
+	}
+	return nil
+}
+
 type ClientList struct {
 	Clients []string `protobuf:"bytes,1,rep,name=clients" json:"clients,omitempty"`
+	// Whether each client is a bot, matched by index to clients.
+	Bots []bool `protobuf:"varint,2,rep,packed,name=bots" json:"bots,omitempty"`
 }
 
 func (m *ClientList) Reset()                    { *m = ClientList{} }
@@ -153,332 +403,1778 @@ func (m *ClientList) GetClients() []string {
 	return nil
 }
 
-func init() {
-	proto.RegisterType((*Empty)(nil), "goChat.Empty")
-	proto.RegisterType((*ChatMessage)(nil), "goChat.ChatMessage")
-	proto.RegisterType((*ClientInfo)(nil), "goChat.ClientInfo")
-	proto.RegisterType((*GroupInfo)(nil), "goChat.GroupInfo")
-	proto.RegisterType((*GroupList)(nil), "goChat.GroupList")
-	proto.RegisterType((*ClientList)(nil), "goChat.ClientList")
+func (m *ClientList) GetBots() []bool {
+	if m != nil {
+		return m.Bots
+	}
+	return nil
 }
 
-// Reference imports to suppress errors if they are not otherwise used.
-var _ context.Context
-var _ grpc.ClientConn
+// Marks every message in a group up to and including id as read by client.
+type ReadMarker struct {
+	Client    string `protobuf:"bytes,1,opt,name=client" json:"client,omitempty"`
+	GroupName string `protobuf:"bytes,2,opt,name=groupName" json:"groupName,omitempty"`
+	Id        uint64 `protobuf:"varint,3,opt,name=id" json:"id,omitempty"`
+}
 
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the grpc package it is being compiled against.
-const _ = grpc.SupportPackageIsVersion4
+func (m *ReadMarker) Reset()                    { *m = ReadMarker{} }
+func (m *ReadMarker) String() string            { return proto.CompactTextString(m) }
+func (*ReadMarker) ProtoMessage()               {}
+func (*ReadMarker) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
 
-// Client API for Chat service
+func (m *ReadMarker) GetClient() string {
+	if m != nil {
+		return m.Client
+	}
+	return ""
+}
 
-type ChatClient interface {
-	RouteChat(ctx context.Context, opts ...grpc.CallOption) (Chat_RouteChatClient, error)
-	UnRegister(ctx context.Context, in *ClientInfo, opts ...grpc.CallOption) (*Empty, error)
-	Register(ctx context.Context, in *ClientInfo, opts ...grpc.CallOption) (*Empty, error)
-	CreateGroup(ctx context.Context, in *GroupInfo, opts ...grpc.CallOption) (*Empty, error)
-	JoinGroup(ctx context.Context, in *GroupInfo, opts ...grpc.CallOption) (*Empty, error)
-	GetGroupList(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*GroupList, error)
-	GetGroupClientList(ctx context.Context, in *GroupInfo, opts ...grpc.CallOption) (*ClientList, error)
-	GetClientList(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*ClientList, error)
-	LeaveRoom(ctx context.Context, in *GroupInfo, opts ...grpc.CallOption) (*Empty, error)
+func (m *ReadMarker) GetGroupName() string {
+	if m != nil {
+		return m.GroupName
+	}
+	return ""
 }
 
-type chatClient struct {
-	cc *grpc.ClientConn
+func (m *ReadMarker) GetId() uint64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
 }
 
-func NewChatClient(cc *grpc.ClientConn) ChatClient {
-	return &chatClient{cc}
+// Lists the members who have read the latest message in a group.
+type SeenInfo struct {
+	Id      uint64   `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
+	Clients []string `protobuf:"bytes,2,rep,name=clients" json:"clients,omitempty"`
 }
 
-func (c *chatClient) RouteChat(ctx context.Context, opts ...grpc.CallOption) (Chat_RouteChatClient, error) {
-	stream, err := grpc.NewClientStream(ctx, &_Chat_serviceDesc.Streams[0], c.cc, "/goChat.Chat/RouteChat", opts...)
-	if err != nil {
-		return nil, err
+func (m *SeenInfo) Reset()                    { *m = SeenInfo{} }
+func (m *SeenInfo) String() string            { return proto.CompactTextString(m) }
+func (*SeenInfo) ProtoMessage()               {}
+func (*SeenInfo) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+
+func (m *SeenInfo) GetId() uint64 {
+	if m != nil {
+		return m.Id
 	}
-	x := &chatRouteChatClient{stream}
-	return x, nil
+	return 0
 }
 
-type Chat_RouteChatClient interface {
-	Send(*ChatMessage) error
-	Recv() (*ChatMessage, error)
-	grpc.ClientStream
+func (m *SeenInfo) GetClients() []string {
+	if m != nil {
+		return m.Clients
+	}
+	return nil
 }
 
-type chatRouteChatClient struct {
-	grpc.ClientStream
+// Identifies a stored message to edit or delete. The message field is ignored on delete.
+type MessageEdit struct {
+	Client    string `protobuf:"bytes,1,opt,name=client" json:"client,omitempty"`
+	GroupName string `protobuf:"bytes,2,opt,name=groupName" json:"groupName,omitempty"`
+	Id        uint64 `protobuf:"varint,3,opt,name=id" json:"id,omitempty"`
+	Message   string `protobuf:"bytes,4,opt,name=message" json:"message,omitempty"`
 }
 
-func (x *chatRouteChatClient) Send(m *ChatMessage) error {
-	return x.ClientStream.SendMsg(m)
-}
+func (m *MessageEdit) Reset()                    { *m = MessageEdit{} }
+func (m *MessageEdit) String() string            { return proto.CompactTextString(m) }
+func (*MessageEdit) ProtoMessage()               {}
+func (*MessageEdit) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
 
-func (x *chatRouteChatClient) Recv() (*ChatMessage, error) {
-	m := new(ChatMessage)
-	if err := x.ClientStream.RecvMsg(m); err != nil {
-		return nil, err
+func (m *MessageEdit) GetClient() string {
+	if m != nil {
+		return m.Client
 	}
-	return m, nil
+	return ""
 }
 
-func (c *chatClient) UnRegister(ctx context.Context, in *ClientInfo, opts ...grpc.CallOption) (*Empty, error) {
-	out := new(Empty)
-	err := grpc.Invoke(ctx, "/goChat.Chat/UnRegister", in, out, c.cc, opts...)
-	if err != nil {
-		return nil, err
+func (m *MessageEdit) GetGroupName() string {
+	if m != nil {
+		return m.GroupName
 	}
-	return out, nil
+	return ""
 }
 
-func (c *chatClient) Register(ctx context.Context, in *ClientInfo, opts ...grpc.CallOption) (*Empty, error) {
-	out := new(Empty)
-	err := grpc.Invoke(ctx, "/goChat.Chat/Register", in, out, c.cc, opts...)
-	if err != nil {
-		return nil, err
+func (m *MessageEdit) GetId() uint64 {
+	if m != nil {
+		return m.Id
 	}
-	return out, nil
+	return 0
 }
 
-func (c *chatClient) CreateGroup(ctx context.Context, in *GroupInfo, opts ...grpc.CallOption) (*Empty, error) {
-	out := new(Empty)
-	err := grpc.Invoke(ctx, "/goChat.Chat/CreateGroup", in, out, c.cc, opts...)
-	if err != nil {
-		return nil, err
+func (m *MessageEdit) GetMessage() string {
+	if m != nil {
+		return m.Message
 	}
-	return out, nil
+	return ""
 }
 
-func (c *chatClient) JoinGroup(ctx context.Context, in *GroupInfo, opts ...grpc.CallOption) (*Empty, error) {
-	out := new(Empty)
-	err := grpc.Invoke(ctx, "/goChat.Chat/JoinGroup", in, out, c.cc, opts...)
-	if err != nil {
-		return nil, err
-	}
-	return out, nil
+// Toggles a client's emoji reaction on a stored message.
+type ReactionInfo struct {
+	Client    string `protobuf:"bytes,1,opt,name=client" json:"client,omitempty"`
+	GroupName string `protobuf:"bytes,2,opt,name=groupName" json:"groupName,omitempty"`
+	Id        uint64 `protobuf:"varint,3,opt,name=id" json:"id,omitempty"`
+	Emoji     string `protobuf:"bytes,4,opt,name=emoji" json:"emoji,omitempty"`
 }
 
-func (c *chatClient) GetGroupList(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*GroupList, error) {
-	out := new(GroupList)
-	err := grpc.Invoke(ctx, "/goChat.Chat/GetGroupList", in, out, c.cc, opts...)
-	if err != nil {
-		return nil, err
+func (m *ReactionInfo) Reset()                    { *m = ReactionInfo{} }
+func (m *ReactionInfo) String() string            { return proto.CompactTextString(m) }
+func (*ReactionInfo) ProtoMessage()               {}
+func (*ReactionInfo) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
+
+func (m *ReactionInfo) GetClient() string {
+	if m != nil {
+		return m.Client
 	}
-	return out, nil
+	return ""
 }
 
-func (c *chatClient) GetGroupClientList(ctx context.Context, in *GroupInfo, opts ...grpc.CallOption) (*ClientList, error) {
-	out := new(ClientList)
-	err := grpc.Invoke(ctx, "/goChat.Chat/GetGroupClientList", in, out, c.cc, opts...)
-	if err != nil {
-		return nil, err
+func (m *ReactionInfo) GetGroupName() string {
+	if m != nil {
+		return m.GroupName
 	}
-	return out, nil
+	return ""
 }
 
-func (c *chatClient) GetClientList(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*ClientList, error) {
-	out := new(ClientList)
-	err := grpc.Invoke(ctx, "/goChat.Chat/GetClientList", in, out, c.cc, opts...)
-	if err != nil {
-		return nil, err
+func (m *ReactionInfo) GetId() uint64 {
+	if m != nil {
+		return m.Id
 	}
-	return out, nil
+	return 0
 }
 
-func (c *chatClient) LeaveRoom(ctx context.Context, in *GroupInfo, opts ...grpc.CallOption) (*Empty, error) {
-	out := new(Empty)
-	err := grpc.Invoke(ctx, "/goChat.Chat/LeaveRoom", in, out, c.cc, opts...)
-	if err != nil {
-		return nil, err
+func (m *ReactionInfo) GetEmoji() string {
+	if m != nil {
+		return m.Emoji
 	}
-	return out, nil
+	return ""
 }
 
-// Server API for Chat service
-
-type ChatServer interface {
-	RouteChat(Chat_RouteChatServer) error
-	UnRegister(context.Context, *ClientInfo) (*Empty, error)
-	Register(context.Context, *ClientInfo) (*Empty, error)
-	CreateGroup(context.Context, *GroupInfo) (*Empty, error)
-	JoinGroup(context.Context, *GroupInfo) (*Empty, error)
-	GetGroupList(context.Context, *Empty) (*GroupList, error)
-	GetGroupClientList(context.Context, *GroupInfo) (*ClientList, error)
-	GetClientList(context.Context, *Empty) (*ClientList, error)
-	LeaveRoom(context.Context, *GroupInfo) (*Empty, error)
+// Aggregates everyone who reacted to a message with the same emoji.
+type Reaction struct {
+	Emoji   string   `protobuf:"bytes,1,opt,name=emoji" json:"emoji,omitempty"`
+	Clients []string `protobuf:"bytes,2,rep,name=clients" json:"clients,omitempty"`
 }
 
-func RegisterChatServer(s *grpc.Server, srv ChatServer) {
-	s.RegisterService(&_Chat_serviceDesc, srv)
-}
+func (m *Reaction) Reset()                    { *m = Reaction{} }
+func (m *Reaction) String() string            { return proto.CompactTextString(m) }
+func (*Reaction) ProtoMessage()               {}
+func (*Reaction) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
 
-func _Chat_RouteChat_Handler(srv interface{}, stream grpc.ServerStream) error {
-	return srv.(ChatServer).RouteChat(&chatRouteChatServer{stream})
+func (m *Reaction) GetEmoji() string {
+	if m != nil {
+		return m.Emoji
+	}
+	return ""
 }
 
-type Chat_RouteChatServer interface {
-	Send(*ChatMessage) error
-	Recv() (*ChatMessage, error)
-	grpc.ServerStream
+func (m *Reaction) GetClients() []string {
+	if m != nil {
+		return m.Clients
+	}
+	return nil
 }
 
-type chatRouteChatServer struct {
-	grpc.ServerStream
+// Refers to a stored message within a group.
+type MessageRef struct {
+	Client    string `protobuf:"bytes,1,opt,name=client" json:"client,omitempty"`
+	GroupName string `protobuf:"bytes,2,opt,name=groupName" json:"groupName,omitempty"`
+	Id        uint64 `protobuf:"varint,3,opt,name=id" json:"id,omitempty"`
 }
 
-func (x *chatRouteChatServer) Send(m *ChatMessage) error {
-	return x.ServerStream.SendMsg(m)
-}
+func (m *MessageRef) Reset()                    { *m = MessageRef{} }
+func (m *MessageRef) String() string            { return proto.CompactTextString(m) }
+func (*MessageRef) ProtoMessage()               {}
+func (*MessageRef) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
 
-func (x *chatRouteChatServer) Recv() (*ChatMessage, error) {
-	m := new(ChatMessage)
-	if err := x.ServerStream.RecvMsg(m); err != nil {
-		return nil, err
+func (m *MessageRef) GetClient() string {
+	if m != nil {
+		return m.Client
+	}
+	return ""
+}
+
+func (m *MessageRef) GetGroupName() string {
+	if m != nil {
+		return m.GroupName
+	}
+	return ""
+}
+
+func (m *MessageRef) GetId() uint64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+type MessageList struct {
+	Messages []*ChatMessage `protobuf:"bytes,1,rep,name=messages" json:"messages,omitempty"`
+}
+
+func (m *MessageList) Reset()                    { *m = MessageList{} }
+func (m *MessageList) String() string            { return proto.CompactTextString(m) }
+func (*MessageList) ProtoMessage()               {}
+func (*MessageList) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
+
+func (m *MessageList) GetMessages() []*ChatMessage {
+	if m != nil {
+		return m.Messages
+	}
+	return nil
+}
+
+// Describes a file shared with a group.
+type FileInfo struct {
+	Id   string `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+	Name string `protobuf:"bytes,2,opt,name=name" json:"name,omitempty"`
+	Size uint64 `protobuf:"varint,3,opt,name=size" json:"size,omitempty"`
+	// Hex encoded SHA-256 checksum of the file's contents.
+	Sha256    string `protobuf:"bytes,4,opt,name=sha256" json:"sha256,omitempty"`
+	Client    string `protobuf:"bytes,5,opt,name=client" json:"client,omitempty"`
+	GroupName string `protobuf:"bytes,6,opt,name=groupName" json:"groupName,omitempty"`
+}
+
+func (m *FileInfo) Reset()                    { *m = FileInfo{} }
+func (m *FileInfo) String() string            { return proto.CompactTextString(m) }
+func (*FileInfo) ProtoMessage()               {}
+func (*FileInfo) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
+
+func (m *FileInfo) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *FileInfo) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *FileInfo) GetSize() uint64 {
+	if m != nil {
+		return m.Size
+	}
+	return 0
+}
+
+func (m *FileInfo) GetSha256() string {
+	if m != nil {
+		return m.Sha256
+	}
+	return ""
+}
+
+func (m *FileInfo) GetClient() string {
+	if m != nil {
+		return m.Client
+	}
+	return ""
+}
+
+func (m *FileInfo) GetGroupName() string {
+	if m != nil {
+		return m.GroupName
+	}
+	return ""
+}
+
+// A piece of a file being uploaded or downloaded. The first chunk of every transfer carries
+// the file's info.
+type FileChunk struct {
+	Info *FileInfo `protobuf:"bytes,1,opt,name=info" json:"info,omitempty"`
+	Data []byte    `protobuf:"bytes,2,opt,name=data" json:"data,omitempty"`
+}
+
+func (m *FileChunk) Reset()                    { *m = FileChunk{} }
+func (m *FileChunk) String() string            { return proto.CompactTextString(m) }
+func (*FileChunk) ProtoMessage()               {}
+func (*FileChunk) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
+
+func (m *FileChunk) GetInfo() *FileInfo {
+	if m != nil {
+		return m.Info
+	}
+	return nil
+}
+
+func (m *FileChunk) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+type FileRequest struct {
+	Client string `protobuf:"bytes,1,opt,name=client" json:"client,omitempty"`
+	Id     string `protobuf:"bytes,2,opt,name=id" json:"id,omitempty"`
+}
+
+func (m *FileRequest) Reset()                    { *m = FileRequest{} }
+func (m *FileRequest) String() string            { return proto.CompactTextString(m) }
+func (*FileRequest) ProtoMessage()               {}
+func (*FileRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
+
+func (m *FileRequest) GetClient() string {
+	if m != nil {
+		return m.Client
+	}
+	return ""
+}
+
+func (m *FileRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+// A client's X25519 public key.
+type PublicKey struct {
+	Client string `protobuf:"bytes,1,opt,name=client" json:"client,omitempty"`
+	Key    []byte `protobuf:"bytes,2,opt,name=key" json:"key,omitempty"`
+}
+
+func (m *PublicKey) Reset()                    { *m = PublicKey{} }
+func (m *PublicKey) String() string            { return proto.CompactTextString(m) }
+func (*PublicKey) ProtoMessage()               {}
+func (*PublicKey) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
+
+func (m *PublicKey) GetClient() string {
+	if m != nil {
+		return m.Client
+	}
+	return ""
+}
+
+func (m *PublicKey) GetKey() []byte {
+	if m != nil {
+		return m.Key
+	}
+	return nil
+}
+
+type PublicKeyList struct {
+	Keys []*PublicKey `protobuf:"bytes,1,rep,name=keys" json:"keys,omitempty"`
+}
+
+func (m *PublicKeyList) Reset()                    { *m = PublicKeyList{} }
+func (m *PublicKeyList) String() string            { return proto.CompactTextString(m) }
+func (*PublicKeyList) ProtoMessage()               {}
+func (*PublicKeyList) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{17} }
+
+func (m *PublicKeyList) GetKeys() []*PublicKey {
+	if m != nil {
+		return m.Keys
+	}
+	return nil
+}
+
+// A group key encrypted for a single member with a key derived from an ephemeral X25519 key
+// and the member's public key.
+type WrappedKey struct {
+	Client     string `protobuf:"bytes,1,opt,name=client" json:"client,omitempty"`
+	Ephemeral  []byte `protobuf:"bytes,2,opt,name=ephemeral" json:"ephemeral,omitempty"`
+	Nonce      []byte `protobuf:"bytes,3,opt,name=nonce" json:"nonce,omitempty"`
+	Ciphertext []byte `protobuf:"bytes,4,opt,name=ciphertext" json:"ciphertext,omitempty"`
+}
+
+func (m *WrappedKey) Reset()                    { *m = WrappedKey{} }
+func (m *WrappedKey) String() string            { return proto.CompactTextString(m) }
+func (*WrappedKey) ProtoMessage()               {}
+func (*WrappedKey) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{18} }
+
+func (m *WrappedKey) GetClient() string {
+	if m != nil {
+		return m.Client
+	}
+	return ""
+}
+
+func (m *WrappedKey) GetEphemeral() []byte {
+	if m != nil {
+		return m.Ephemeral
+	}
+	return nil
+}
+
+func (m *WrappedKey) GetNonce() []byte {
+	if m != nil {
+		return m.Nonce
+	}
+	return nil
+}
+
+func (m *WrappedKey) GetCiphertext() []byte {
+	if m != nil {
+		return m.Ciphertext
+	}
+	return nil
+}
+
+// A group key for one epoch, wrapped for each member of the group. When fetched, keys only
+// holds the copy wrapped for the requesting client.
+type GroupKey struct {
+	Client    string        `protobuf:"bytes,1,opt,name=client" json:"client,omitempty"`
+	GroupName string        `protobuf:"bytes,2,opt,name=groupName" json:"groupName,omitempty"`
+	Epoch     uint64        `protobuf:"varint,3,opt,name=epoch" json:"epoch,omitempty"`
+	Keys      []*WrappedKey `protobuf:"bytes,4,rep,name=keys" json:"keys,omitempty"`
+	Encrypted bool          `protobuf:"varint,5,opt,name=encrypted" json:"encrypted,omitempty"`
+}
+
+func (m *GroupKey) Reset()                    { *m = GroupKey{} }
+func (m *GroupKey) String() string            { return proto.CompactTextString(m) }
+func (*GroupKey) ProtoMessage()               {}
+func (*GroupKey) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{19} }
+
+func (m *GroupKey) GetClient() string {
+	if m != nil {
+		return m.Client
+	}
+	return ""
+}
+
+func (m *GroupKey) GetGroupName() string {
+	if m != nil {
+		return m.GroupName
+	}
+	return ""
+}
+
+func (m *GroupKey) GetEpoch() uint64 {
+	if m != nil {
+		return m.Epoch
+	}
+	return 0
+}
+
+func (m *GroupKey) GetKeys() []*WrappedKey {
+	if m != nil {
+		return m.Keys
+	}
+	return nil
+}
+
+func (m *GroupKey) GetEncrypted() bool {
+	if m != nil {
+		return m.Encrypted
+	}
+	return false
+}
+
+// The topic of a group. Setting an empty topic clears it.
+type Topic struct {
+	Client    string `protobuf:"bytes,1,opt,name=client" json:"client,omitempty"`
+	GroupName string `protobuf:"bytes,2,opt,name=groupName" json:"groupName,omitempty"`
+	Topic     string `protobuf:"bytes,3,opt,name=topic" json:"topic,omitempty"`
+}
+
+func (m *Topic) Reset()                    { *m = Topic{} }
+func (m *Topic) String() string            { return proto.CompactTextString(m) }
+func (*Topic) ProtoMessage()               {}
+func (*Topic) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{20} }
+
+func (m *Topic) GetClient() string {
+	if m != nil {
+		return m.Client
+	}
+	return ""
+}
+
+func (m *Topic) GetGroupName() string {
+	if m != nil {
+		return m.GroupName
+	}
+	return ""
+}
+
+func (m *Topic) GetTopic() string {
+	if m != nil {
+		return m.Topic
+	}
+	return ""
+}
+
+// Asks for client to be known by a new name from now on.
+type NameChange struct {
+	Client string `protobuf:"bytes,1,opt,name=client" json:"client,omitempty"`
+	Name   string `protobuf:"bytes,2,opt,name=name" json:"name,omitempty"`
+}
+
+func (m *NameChange) Reset()                    { *m = NameChange{} }
+func (m *NameChange) String() string            { return proto.CompactTextString(m) }
+func (*NameChange) ProtoMessage()               {}
+func (*NameChange) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{21} }
+
+func (m *NameChange) GetClient() string {
+	if m != nil {
+		return m.Client
+	}
+	return ""
+}
+
+func (m *NameChange) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+// An incoming webhook, which lets HTTP clients post messages into a group.
+type IncomingHook struct {
+	// The client managing the hook, who must moderate the group.
+	Client    string `protobuf:"bytes,1,opt,name=client" json:"client,omitempty"`
+	GroupName string `protobuf:"bytes,2,opt,name=groupName" json:"groupName,omitempty"`
+	// The name messages posted through the hook are shown as.
+	Name string `protobuf:"bytes,3,opt,name=name" json:"name,omitempty"`
+	// The secret that goes in the hook's URL. The server picks it when the hook is created.
+	Token string `protobuf:"bytes,4,opt,name=token" json:"token,omitempty"`
+}
+
+func (m *IncomingHook) Reset()                    { *m = IncomingHook{} }
+func (m *IncomingHook) String() string            { return proto.CompactTextString(m) }
+func (*IncomingHook) ProtoMessage()               {}
+func (*IncomingHook) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{22} }
+
+func (m *IncomingHook) GetClient() string {
+	if m != nil {
+		return m.Client
+	}
+	return ""
+}
+
+func (m *IncomingHook) GetGroupName() string {
+	if m != nil {
+		return m.GroupName
+	}
+	return ""
+}
+
+func (m *IncomingHook) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *IncomingHook) GetToken() string {
+	if m != nil {
+		return m.Token
+	}
+	return ""
+}
+
+type IncomingHookList struct {
+	Hooks []*IncomingHook `protobuf:"bytes,1,rep,name=hooks" json:"hooks,omitempty"`
+}
+
+func (m *IncomingHookList) Reset()                    { *m = IncomingHookList{} }
+func (m *IncomingHookList) String() string            { return proto.CompactTextString(m) }
+func (*IncomingHookList) ProtoMessage()               {}
+func (*IncomingHookList) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{23} }
+
+func (m *IncomingHookList) GetHooks() []*IncomingHook {
+	if m != nil {
+		return m.Hooks
+	}
+	return nil
+}
+
+// A client connected to the server, as operators see it.
+type Session struct {
+	Client string `protobuf:"bytes,1,opt,name=client" json:"client,omitempty"`
+	Bot    bool   `protobuf:"varint,2,opt,name=bot" json:"bot,omitempty"`
+	// When the client registered, in seconds since the Unix epoch.
+	Connected int64 `protobuf:"varint,3,opt,name=connected" json:"connected,omitempty"`
+	// The address the client registered from. Clients of the gateways show up as the server's
+	// own address.
+	Address string   `protobuf:"bytes,4,opt,name=address" json:"address,omitempty"`
+	Groups  []string `protobuf:"bytes,5,rep,name=groups" json:"groups,omitempty"`
+}
+
+func (m *Session) Reset()                    { *m = Session{} }
+func (m *Session) String() string            { return proto.CompactTextString(m) }
+func (*Session) ProtoMessage()               {}
+func (*Session) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{24} }
+
+func (m *Session) GetClient() string {
+	if m != nil {
+		return m.Client
+	}
+	return ""
+}
+
+func (m *Session) GetBot() bool {
+	if m != nil {
+		return m.Bot
+	}
+	return false
+}
+
+func (m *Session) GetConnected() int64 {
+	if m != nil {
+		return m.Connected
+	}
+	return 0
+}
+
+func (m *Session) GetAddress() string {
+	if m != nil {
+		return m.Address
+	}
+	return ""
+}
+
+func (m *Session) GetGroups() []string {
+	if m != nil {
+		return m.Groups
+	}
+	return nil
+}
+
+type SessionList struct {
+	Sessions []*Session `protobuf:"bytes,1,rep,name=sessions" json:"sessions,omitempty"`
+}
+
+func (m *SessionList) Reset()                    { *m = SessionList{} }
+func (m *SessionList) String() string            { return proto.CompactTextString(m) }
+func (*SessionList) ProtoMessage()               {}
+func (*SessionList) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{25} }
+
+func (m *SessionList) GetSessions() []*Session {
+	if m != nil {
+		return m.Sessions
+	}
+	return nil
+}
+
+// Asks for client to be disconnected, telling them the reason if there is one.
+type Disconnect struct {
+	Client string `protobuf:"bytes,1,opt,name=client" json:"client,omitempty"`
+	Reason string `protobuf:"bytes,2,opt,name=reason" json:"reason,omitempty"`
+}
+
+func (m *Disconnect) Reset()                    { *m = Disconnect{} }
+func (m *Disconnect) String() string            { return proto.CompactTextString(m) }
+func (*Disconnect) ProtoMessage()               {}
+func (*Disconnect) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{26} }
+
+func (m *Disconnect) GetClient() string {
+	if m != nil {
+		return m.Client
+	}
+	return ""
+}
+
+func (m *Disconnect) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+// A notice for every connected client.
+type Announcement struct {
+	Message string `protobuf:"bytes,1,opt,name=message" json:"message,omitempty"`
+}
+
+func (m *Announcement) Reset()                    { *m = Announcement{} }
+func (m *Announcement) String() string            { return proto.CompactTextString(m) }
+func (*Announcement) ProtoMessage()               {}
+func (*Announcement) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{27} }
+
+func (m *Announcement) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+type ServerStats struct {
+	UptimeSeconds int64 `protobuf:"varint,1,opt,name=uptime_seconds,json=uptimeSeconds" json:"uptime_seconds,omitempty"`
+	Clients       int32 `protobuf:"varint,2,opt,name=clients" json:"clients,omitempty"`
+	// How many of the clients are bots.
+	Bots           int32  `protobuf:"varint,3,opt,name=bots" json:"bots,omitempty"`
+	Groups         int32  `protobuf:"varint,4,opt,name=groups" json:"groups,omitempty"`
+	StoredMessages uint64 `protobuf:"varint,5,opt,name=stored_messages,json=storedMessages" json:"stored_messages,omitempty"`
+	// Outgoing webhooks the server posts events to.
+	Webhooks   int32 `protobuf:"varint,6,opt,name=webhooks" json:"webhooks,omitempty"`
+	Goroutines int32 `protobuf:"varint,7,opt,name=goroutines" json:"goroutines,omitempty"`
+}
+
+func (m *ServerStats) Reset()                    { *m = ServerStats{} }
+func (m *ServerStats) String() string            { return proto.CompactTextString(m) }
+func (*ServerStats) ProtoMessage()               {}
+func (*ServerStats) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{28} }
+
+func (m *ServerStats) GetUptimeSeconds() int64 {
+	if m != nil {
+		return m.UptimeSeconds
+	}
+	return 0
+}
+
+func (m *ServerStats) GetClients() int32 {
+	if m != nil {
+		return m.Clients
+	}
+	return 0
+}
+
+func (m *ServerStats) GetBots() int32 {
+	if m != nil {
+		return m.Bots
+	}
+	return 0
+}
+
+func (m *ServerStats) GetGroups() int32 {
+	if m != nil {
+		return m.Groups
+	}
+	return 0
+}
+
+func (m *ServerStats) GetStoredMessages() uint64 {
+	if m != nil {
+		return m.StoredMessages
+	}
+	return 0
+}
+
+func (m *ServerStats) GetWebhooks() int32 {
+	if m != nil {
+		return m.Webhooks
+	}
+	return 0
+}
+
+func (m *ServerStats) GetGoroutines() int32 {
+	if m != nil {
+		return m.Goroutines
+	}
+	return 0
+}
+
+// What the server read when it last loaded its configuration.
+type ConfigInfo struct {
+	// The file the outgoing webhooks were read from, if any.
+	WebhooksFile string `protobuf:"bytes,1,opt,name=webhooks_file,json=webhooksFile" json:"webhooks_file,omitempty"`
+	Webhooks     int32  `protobuf:"varint,2,opt,name=webhooks" json:"webhooks,omitempty"`
+}
+
+func (m *ConfigInfo) Reset()                    { *m = ConfigInfo{} }
+func (m *ConfigInfo) String() string            { return proto.CompactTextString(m) }
+func (*ConfigInfo) ProtoMessage()               {}
+func (*ConfigInfo) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{29} }
+
+func (m *ConfigInfo) GetWebhooksFile() string {
+	if m != nil {
+		return m.WebhooksFile
+	}
+	return ""
+}
+
+func (m *ConfigInfo) GetWebhooks() int32 {
+	if m != nil {
+		return m.Webhooks
+	}
+	return 0
+}
+
+func init() {
+	proto.RegisterType((*Empty)(nil), "goChat.Empty")
+	proto.RegisterType((*ChatMessage)(nil), "goChat.ChatMessage")
+	proto.RegisterType((*ClientInfo)(nil), "goChat.ClientInfo")
+	proto.RegisterType((*GroupInfo)(nil), "goChat.GroupInfo")
+	proto.RegisterType((*GroupList)(nil), "goChat.GroupList")
+	proto.RegisterType((*ClientList)(nil), "goChat.ClientList")
+	proto.RegisterType((*ReadMarker)(nil), "goChat.ReadMarker")
+	proto.RegisterType((*SeenInfo)(nil), "goChat.SeenInfo")
+	proto.RegisterType((*MessageEdit)(nil), "goChat.MessageEdit")
+	proto.RegisterType((*ReactionInfo)(nil), "goChat.ReactionInfo")
+	proto.RegisterType((*Reaction)(nil), "goChat.Reaction")
+	proto.RegisterType((*MessageRef)(nil), "goChat.MessageRef")
+	proto.RegisterType((*MessageList)(nil), "goChat.MessageList")
+	proto.RegisterType((*FileInfo)(nil), "goChat.FileInfo")
+	proto.RegisterType((*FileChunk)(nil), "goChat.FileChunk")
+	proto.RegisterType((*FileRequest)(nil), "goChat.FileRequest")
+	proto.RegisterType((*PublicKey)(nil), "goChat.PublicKey")
+	proto.RegisterType((*PublicKeyList)(nil), "goChat.PublicKeyList")
+	proto.RegisterType((*WrappedKey)(nil), "goChat.WrappedKey")
+	proto.RegisterType((*GroupKey)(nil), "goChat.GroupKey")
+	proto.RegisterType((*Topic)(nil), "goChat.Topic")
+	proto.RegisterType((*NameChange)(nil), "goChat.NameChange")
+	proto.RegisterType((*IncomingHook)(nil), "goChat.IncomingHook")
+	proto.RegisterType((*IncomingHookList)(nil), "goChat.IncomingHookList")
+	proto.RegisterType((*Session)(nil), "goChat.Session")
+	proto.RegisterType((*SessionList)(nil), "goChat.SessionList")
+	proto.RegisterType((*Disconnect)(nil), "goChat.Disconnect")
+	proto.RegisterType((*Announcement)(nil), "goChat.Announcement")
+	proto.RegisterType((*ServerStats)(nil), "goChat.ServerStats")
+	proto.RegisterType((*ConfigInfo)(nil), "goChat.ConfigInfo")
+	proto.RegisterEnum("goChat.Kind", Kind_name, Kind_value)
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// Client API for Chat service
+
+type ChatClient interface {
+	RouteChat(ctx context.Context, opts ...grpc.CallOption) (Chat_RouteChatClient, error)
+	UnRegister(ctx context.Context, in *ClientInfo, opts ...grpc.CallOption) (*Empty, error)
+	Register(ctx context.Context, in *ClientInfo, opts ...grpc.CallOption) (*Empty, error)
+	CreateGroup(ctx context.Context, in *GroupInfo, opts ...grpc.CallOption) (*Empty, error)
+	JoinGroup(ctx context.Context, in *GroupInfo, opts ...grpc.CallOption) (*Empty, error)
+	GetGroupList(ctx context.Context, in *ClientInfo, opts ...grpc.CallOption) (*GroupList, error)
+	GetGroupClientList(ctx context.Context, in *GroupInfo, opts ...grpc.CallOption) (*ClientList, error)
+	GetClientList(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*ClientList, error)
+	LeaveRoom(ctx context.Context, in *GroupInfo, opts ...grpc.CallOption) (*Empty, error)
+	MarkRead(ctx context.Context, in *ReadMarker, opts ...grpc.CallOption) (*Empty, error)
+	GetSeenBy(ctx context.Context, in *GroupInfo, opts ...grpc.CallOption) (*SeenInfo, error)
+	EditMessage(ctx context.Context, in *MessageEdit, opts ...grpc.CallOption) (*Empty, error)
+	DeleteMessage(ctx context.Context, in *MessageEdit, opts ...grpc.CallOption) (*Empty, error)
+	React(ctx context.Context, in *ReactionInfo, opts ...grpc.CallOption) (*ChatMessage, error)
+	GetThread(ctx context.Context, in *MessageRef, opts ...grpc.CallOption) (*MessageList, error)
+	GetMentions(ctx context.Context, in *ClientInfo, opts ...grpc.CallOption) (*MessageList, error)
+	UploadFile(ctx context.Context, opts ...grpc.CallOption) (Chat_UploadFileClient, error)
+	DownloadFile(ctx context.Context, in *FileRequest, opts ...grpc.CallOption) (Chat_DownloadFileClient, error)
+	PublishKey(ctx context.Context, in *PublicKey, opts ...grpc.CallOption) (*Empty, error)
+	GetPublicKeys(ctx context.Context, in *ClientList, opts ...grpc.CallOption) (*PublicKeyList, error)
+	ShareGroupKey(ctx context.Context, in *GroupKey, opts ...grpc.CallOption) (*Empty, error)
+	GetGroupKey(ctx context.Context, in *GroupInfo, opts ...grpc.CallOption) (*GroupKey, error)
+	SetTopic(ctx context.Context, in *Topic, opts ...grpc.CallOption) (*Empty, error)
+	GetTopic(ctx context.Context, in *GroupInfo, opts ...grpc.CallOption) (*Topic, error)
+	Rename(ctx context.Context, in *NameChange, opts ...grpc.CallOption) (*Empty, error)
+	RunCommand(ctx context.Context, in *ChatMessage, opts ...grpc.CallOption) (*ChatMessage, error)
+	CreateIncomingHook(ctx context.Context, in *IncomingHook, opts ...grpc.CallOption) (*IncomingHook, error)
+	ListIncomingHooks(ctx context.Context, in *GroupInfo, opts ...grpc.CallOption) (*IncomingHookList, error)
+	DeleteIncomingHook(ctx context.Context, in *IncomingHook, opts ...grpc.CallOption) (*Empty, error)
+}
+
+type chatClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewChatClient(cc *grpc.ClientConn) ChatClient {
+	return &chatClient{cc}
+}
+
+func (c *chatClient) RouteChat(ctx context.Context, opts ...grpc.CallOption) (Chat_RouteChatClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Chat_serviceDesc.Streams[0], c.cc, "/goChat.Chat/RouteChat", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &chatRouteChatClient{stream}
+	return x, nil
+}
+
+type Chat_RouteChatClient interface {
+	Send(*ChatMessage) error
+	Recv() (*ChatMessage, error)
+	grpc.ClientStream
+}
+
+type chatRouteChatClient struct {
+	grpc.ClientStream
+}
+
+func (x *chatRouteChatClient) Send(m *ChatMessage) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *chatRouteChatClient) Recv() (*ChatMessage, error) {
+	m := new(ChatMessage)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *chatClient) UnRegister(ctx context.Context, in *ClientInfo, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := grpc.Invoke(ctx, "/goChat.Chat/UnRegister", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatClient) Register(ctx context.Context, in *ClientInfo, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := grpc.Invoke(ctx, "/goChat.Chat/Register", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatClient) CreateGroup(ctx context.Context, in *GroupInfo, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := grpc.Invoke(ctx, "/goChat.Chat/CreateGroup", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatClient) JoinGroup(ctx context.Context, in *GroupInfo, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := grpc.Invoke(ctx, "/goChat.Chat/JoinGroup", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatClient) GetGroupList(ctx context.Context, in *ClientInfo, opts ...grpc.CallOption) (*GroupList, error) {
+	out := new(GroupList)
+	err := grpc.Invoke(ctx, "/goChat.Chat/GetGroupList", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatClient) GetGroupClientList(ctx context.Context, in *GroupInfo, opts ...grpc.CallOption) (*ClientList, error) {
+	out := new(ClientList)
+	err := grpc.Invoke(ctx, "/goChat.Chat/GetGroupClientList", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatClient) GetClientList(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*ClientList, error) {
+	out := new(ClientList)
+	err := grpc.Invoke(ctx, "/goChat.Chat/GetClientList", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatClient) LeaveRoom(ctx context.Context, in *GroupInfo, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := grpc.Invoke(ctx, "/goChat.Chat/LeaveRoom", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatClient) MarkRead(ctx context.Context, in *ReadMarker, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := grpc.Invoke(ctx, "/goChat.Chat/MarkRead", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatClient) GetSeenBy(ctx context.Context, in *GroupInfo, opts ...grpc.CallOption) (*SeenInfo, error) {
+	out := new(SeenInfo)
+	err := grpc.Invoke(ctx, "/goChat.Chat/GetSeenBy", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatClient) EditMessage(ctx context.Context, in *MessageEdit, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := grpc.Invoke(ctx, "/goChat.Chat/EditMessage", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatClient) DeleteMessage(ctx context.Context, in *MessageEdit, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := grpc.Invoke(ctx, "/goChat.Chat/DeleteMessage", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatClient) React(ctx context.Context, in *ReactionInfo, opts ...grpc.CallOption) (*ChatMessage, error) {
+	out := new(ChatMessage)
+	err := grpc.Invoke(ctx, "/goChat.Chat/React", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatClient) GetThread(ctx context.Context, in *MessageRef, opts ...grpc.CallOption) (*MessageList, error) {
+	out := new(MessageList)
+	err := grpc.Invoke(ctx, "/goChat.Chat/GetThread", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatClient) GetMentions(ctx context.Context, in *ClientInfo, opts ...grpc.CallOption) (*MessageList, error) {
+	out := new(MessageList)
+	err := grpc.Invoke(ctx, "/goChat.Chat/GetMentions", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatClient) UploadFile(ctx context.Context, opts ...grpc.CallOption) (Chat_UploadFileClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Chat_serviceDesc.Streams[1], c.cc, "/goChat.Chat/UploadFile", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &chatUploadFileClient{stream}
+	return x, nil
+}
+
+type Chat_UploadFileClient interface {
+	Send(*FileChunk) error
+	CloseAndRecv() (*FileInfo, error)
+	grpc.ClientStream
+}
+
+type chatUploadFileClient struct {
+	grpc.ClientStream
+}
+
+func (x *chatUploadFileClient) Send(m *FileChunk) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *chatUploadFileClient) CloseAndRecv() (*FileInfo, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(FileInfo)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *chatClient) DownloadFile(ctx context.Context, in *FileRequest, opts ...grpc.CallOption) (Chat_DownloadFileClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Chat_serviceDesc.Streams[2], c.cc, "/goChat.Chat/DownloadFile", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &chatDownloadFileClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Chat_DownloadFileClient interface {
+	Recv() (*FileChunk, error)
+	grpc.ClientStream
+}
+
+type chatDownloadFileClient struct {
+	grpc.ClientStream
+}
+
+func (x *chatDownloadFileClient) Recv() (*FileChunk, error) {
+	m := new(FileChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *chatClient) PublishKey(ctx context.Context, in *PublicKey, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := grpc.Invoke(ctx, "/goChat.Chat/PublishKey", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatClient) GetPublicKeys(ctx context.Context, in *ClientList, opts ...grpc.CallOption) (*PublicKeyList, error) {
+	out := new(PublicKeyList)
+	err := grpc.Invoke(ctx, "/goChat.Chat/GetPublicKeys", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatClient) ShareGroupKey(ctx context.Context, in *GroupKey, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := grpc.Invoke(ctx, "/goChat.Chat/ShareGroupKey", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatClient) GetGroupKey(ctx context.Context, in *GroupInfo, opts ...grpc.CallOption) (*GroupKey, error) {
+	out := new(GroupKey)
+	err := grpc.Invoke(ctx, "/goChat.Chat/GetGroupKey", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatClient) SetTopic(ctx context.Context, in *Topic, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := grpc.Invoke(ctx, "/goChat.Chat/SetTopic", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatClient) GetTopic(ctx context.Context, in *GroupInfo, opts ...grpc.CallOption) (*Topic, error) {
+	out := new(Topic)
+	err := grpc.Invoke(ctx, "/goChat.Chat/GetTopic", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatClient) Rename(ctx context.Context, in *NameChange, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := grpc.Invoke(ctx, "/goChat.Chat/Rename", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatClient) RunCommand(ctx context.Context, in *ChatMessage, opts ...grpc.CallOption) (*ChatMessage, error) {
+	out := new(ChatMessage)
+	err := grpc.Invoke(ctx, "/goChat.Chat/RunCommand", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatClient) CreateIncomingHook(ctx context.Context, in *IncomingHook, opts ...grpc.CallOption) (*IncomingHook, error) {
+	out := new(IncomingHook)
+	err := grpc.Invoke(ctx, "/goChat.Chat/CreateIncomingHook", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatClient) ListIncomingHooks(ctx context.Context, in *GroupInfo, opts ...grpc.CallOption) (*IncomingHookList, error) {
+	out := new(IncomingHookList)
+	err := grpc.Invoke(ctx, "/goChat.Chat/ListIncomingHooks", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatClient) DeleteIncomingHook(ctx context.Context, in *IncomingHook, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := grpc.Invoke(ctx, "/goChat.Chat/DeleteIncomingHook", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for Chat service
+
+type ChatServer interface {
+	RouteChat(Chat_RouteChatServer) error
+	UnRegister(context.Context, *ClientInfo) (*Empty, error)
+	Register(context.Context, *ClientInfo) (*Empty, error)
+	CreateGroup(context.Context, *GroupInfo) (*Empty, error)
+	JoinGroup(context.Context, *GroupInfo) (*Empty, error)
+	GetGroupList(context.Context, *ClientInfo) (*GroupList, error)
+	GetGroupClientList(context.Context, *GroupInfo) (*ClientList, error)
+	GetClientList(context.Context, *Empty) (*ClientList, error)
+	LeaveRoom(context.Context, *GroupInfo) (*Empty, error)
+	MarkRead(context.Context, *ReadMarker) (*Empty, error)
+	GetSeenBy(context.Context, *GroupInfo) (*SeenInfo, error)
+	EditMessage(context.Context, *MessageEdit) (*Empty, error)
+	DeleteMessage(context.Context, *MessageEdit) (*Empty, error)
+	React(context.Context, *ReactionInfo) (*ChatMessage, error)
+	GetThread(context.Context, *MessageRef) (*MessageList, error)
+	GetMentions(context.Context, *ClientInfo) (*MessageList, error)
+	UploadFile(Chat_UploadFileServer) error
+	DownloadFile(*FileRequest, Chat_DownloadFileServer) error
+	PublishKey(context.Context, *PublicKey) (*Empty, error)
+	GetPublicKeys(context.Context, *ClientList) (*PublicKeyList, error)
+	ShareGroupKey(context.Context, *GroupKey) (*Empty, error)
+	GetGroupKey(context.Context, *GroupInfo) (*GroupKey, error)
+	SetTopic(context.Context, *Topic) (*Empty, error)
+	GetTopic(context.Context, *GroupInfo) (*Topic, error)
+	Rename(context.Context, *NameChange) (*Empty, error)
+	RunCommand(context.Context, *ChatMessage) (*ChatMessage, error)
+	CreateIncomingHook(context.Context, *IncomingHook) (*IncomingHook, error)
+	ListIncomingHooks(context.Context, *GroupInfo) (*IncomingHookList, error)
+	DeleteIncomingHook(context.Context, *IncomingHook) (*Empty, error)
+}
+
+func RegisterChatServer(s *grpc.Server, srv ChatServer) {
+	s.RegisterService(&_Chat_serviceDesc, srv)
+}
+
+func _Chat_RouteChat_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ChatServer).RouteChat(&chatRouteChatServer{stream})
+}
+
+type Chat_RouteChatServer interface {
+	Send(*ChatMessage) error
+	Recv() (*ChatMessage, error)
+	grpc.ServerStream
+}
+
+type chatRouteChatServer struct {
+	grpc.ServerStream
+}
+
+func (x *chatRouteChatServer) Send(m *ChatMessage) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *chatRouteChatServer) Recv() (*ChatMessage, error) {
+	m := new(ChatMessage)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Chat_UnRegister_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClientInfo)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServer).UnRegister(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goChat.Chat/UnRegister",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServer).UnRegister(ctx, req.(*ClientInfo))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chat_Register_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClientInfo)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServer).Register(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goChat.Chat/Register",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServer).Register(ctx, req.(*ClientInfo))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chat_CreateGroup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GroupInfo)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServer).CreateGroup(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goChat.Chat/CreateGroup",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServer).CreateGroup(ctx, req.(*GroupInfo))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chat_JoinGroup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GroupInfo)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServer).JoinGroup(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goChat.Chat/JoinGroup",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServer).JoinGroup(ctx, req.(*GroupInfo))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chat_GetGroupList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClientInfo)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServer).GetGroupList(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goChat.Chat/GetGroupList",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServer).GetGroupList(ctx, req.(*ClientInfo))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chat_GetGroupClientList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GroupInfo)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServer).GetGroupClientList(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goChat.Chat/GetGroupClientList",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServer).GetGroupClientList(ctx, req.(*GroupInfo))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chat_GetClientList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServer).GetClientList(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goChat.Chat/GetClientList",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServer).GetClientList(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chat_LeaveRoom_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GroupInfo)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServer).LeaveRoom(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goChat.Chat/LeaveRoom",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServer).LeaveRoom(ctx, req.(*GroupInfo))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chat_MarkRead_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReadMarker)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServer).MarkRead(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goChat.Chat/MarkRead",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServer).MarkRead(ctx, req.(*ReadMarker))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chat_GetSeenBy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GroupInfo)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServer).GetSeenBy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goChat.Chat/GetSeenBy",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServer).GetSeenBy(ctx, req.(*GroupInfo))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chat_EditMessage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MessageEdit)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServer).EditMessage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goChat.Chat/EditMessage",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServer).EditMessage(ctx, req.(*MessageEdit))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chat_DeleteMessage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MessageEdit)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServer).DeleteMessage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goChat.Chat/DeleteMessage",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServer).DeleteMessage(ctx, req.(*MessageEdit))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chat_React_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReactionInfo)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServer).React(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goChat.Chat/React",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServer).React(ctx, req.(*ReactionInfo))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chat_GetThread_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MessageRef)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServer).GetThread(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goChat.Chat/GetThread",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServer).GetThread(ctx, req.(*MessageRef))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chat_GetMentions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClientInfo)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServer).GetMentions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goChat.Chat/GetMentions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServer).GetMentions(ctx, req.(*ClientInfo))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chat_UploadFile_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ChatServer).UploadFile(&chatUploadFileServer{stream})
+}
+
+type Chat_UploadFileServer interface {
+	SendAndClose(*FileInfo) error
+	Recv() (*FileChunk, error)
+	grpc.ServerStream
+}
+
+type chatUploadFileServer struct {
+	grpc.ServerStream
+}
+
+func (x *chatUploadFileServer) SendAndClose(m *FileInfo) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *chatUploadFileServer) Recv() (*FileChunk, error) {
+	m := new(FileChunk)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
 	}
 	return m, nil
 }
 
-func _Chat_UnRegister_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(ClientInfo)
+func _Chat_DownloadFile_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(FileRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ChatServer).DownloadFile(m, &chatDownloadFileServer{stream})
+}
+
+type Chat_DownloadFileServer interface {
+	Send(*FileChunk) error
+	grpc.ServerStream
+}
+
+type chatDownloadFileServer struct {
+	grpc.ServerStream
+}
+
+func (x *chatDownloadFileServer) Send(m *FileChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Chat_PublishKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PublicKey)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServer).PublishKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goChat.Chat/PublishKey",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServer).PublishKey(ctx, req.(*PublicKey))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chat_GetPublicKeys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClientList)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ChatServer).UnRegister(ctx, in)
+		return srv.(ChatServer).GetPublicKeys(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/goChat.Chat/UnRegister",
+		FullMethod: "/goChat.Chat/GetPublicKeys",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ChatServer).UnRegister(ctx, req.(*ClientInfo))
+		return srv.(ChatServer).GetPublicKeys(ctx, req.(*ClientList))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Chat_Register_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(ClientInfo)
+func _Chat_ShareGroupKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GroupKey)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ChatServer).Register(ctx, in)
+		return srv.(ChatServer).ShareGroupKey(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/goChat.Chat/Register",
+		FullMethod: "/goChat.Chat/ShareGroupKey",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ChatServer).Register(ctx, req.(*ClientInfo))
+		return srv.(ChatServer).ShareGroupKey(ctx, req.(*GroupKey))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Chat_CreateGroup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+func _Chat_GetGroupKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GroupInfo)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ChatServer).CreateGroup(ctx, in)
+		return srv.(ChatServer).GetGroupKey(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/goChat.Chat/CreateGroup",
+		FullMethod: "/goChat.Chat/GetGroupKey",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ChatServer).CreateGroup(ctx, req.(*GroupInfo))
+		return srv.(ChatServer).GetGroupKey(ctx, req.(*GroupInfo))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Chat_JoinGroup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+func _Chat_SetTopic_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Topic)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServer).SetTopic(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goChat.Chat/SetTopic",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServer).SetTopic(ctx, req.(*Topic))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chat_GetTopic_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GroupInfo)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ChatServer).JoinGroup(ctx, in)
+		return srv.(ChatServer).GetTopic(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/goChat.Chat/JoinGroup",
+		FullMethod: "/goChat.Chat/GetTopic",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ChatServer).JoinGroup(ctx, req.(*GroupInfo))
+		return srv.(ChatServer).GetTopic(ctx, req.(*GroupInfo))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Chat_GetGroupList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(Empty)
+func _Chat_Rename_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NameChange)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ChatServer).GetGroupList(ctx, in)
+		return srv.(ChatServer).Rename(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/goChat.Chat/GetGroupList",
+		FullMethod: "/goChat.Chat/Rename",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ChatServer).GetGroupList(ctx, req.(*Empty))
+		return srv.(ChatServer).Rename(ctx, req.(*NameChange))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Chat_GetGroupClientList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(GroupInfo)
+func _Chat_RunCommand_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChatMessage)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ChatServer).GetGroupClientList(ctx, in)
+		return srv.(ChatServer).RunCommand(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/goChat.Chat/GetGroupClientList",
+		FullMethod: "/goChat.Chat/RunCommand",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ChatServer).GetGroupClientList(ctx, req.(*GroupInfo))
+		return srv.(ChatServer).RunCommand(ctx, req.(*ChatMessage))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Chat_GetClientList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(Empty)
+func _Chat_CreateIncomingHook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IncomingHook)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ChatServer).GetClientList(ctx, in)
+		return srv.(ChatServer).CreateIncomingHook(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/goChat.Chat/GetClientList",
+		FullMethod: "/goChat.Chat/CreateIncomingHook",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ChatServer).GetClientList(ctx, req.(*Empty))
+		return srv.(ChatServer).CreateIncomingHook(ctx, req.(*IncomingHook))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Chat_LeaveRoom_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+func _Chat_ListIncomingHooks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GroupInfo)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ChatServer).LeaveRoom(ctx, in)
+		return srv.(ChatServer).ListIncomingHooks(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/goChat.Chat/LeaveRoom",
+		FullMethod: "/goChat.Chat/ListIncomingHooks",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ChatServer).LeaveRoom(ctx, req.(*GroupInfo))
+		return srv.(ChatServer).ListIncomingHooks(ctx, req.(*GroupInfo))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chat_DeleteIncomingHook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IncomingHook)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServer).DeleteIncomingHook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goChat.Chat/DeleteIncomingHook",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServer).DeleteIncomingHook(ctx, req.(*IncomingHook))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -519,6 +2215,78 @@ var _Chat_serviceDesc = grpc.ServiceDesc{
 			MethodName: "LeaveRoom",
 			Handler:    _Chat_LeaveRoom_Handler,
 		},
+		{
+			MethodName: "MarkRead",
+			Handler:    _Chat_MarkRead_Handler,
+		},
+		{
+			MethodName: "GetSeenBy",
+			Handler:    _Chat_GetSeenBy_Handler,
+		},
+		{
+			MethodName: "EditMessage",
+			Handler:    _Chat_EditMessage_Handler,
+		},
+		{
+			MethodName: "DeleteMessage",
+			Handler:    _Chat_DeleteMessage_Handler,
+		},
+		{
+			MethodName: "React",
+			Handler:    _Chat_React_Handler,
+		},
+		{
+			MethodName: "GetThread",
+			Handler:    _Chat_GetThread_Handler,
+		},
+		{
+			MethodName: "GetMentions",
+			Handler:    _Chat_GetMentions_Handler,
+		},
+		{
+			MethodName: "PublishKey",
+			Handler:    _Chat_PublishKey_Handler,
+		},
+		{
+			MethodName: "GetPublicKeys",
+			Handler:    _Chat_GetPublicKeys_Handler,
+		},
+		{
+			MethodName: "ShareGroupKey",
+			Handler:    _Chat_ShareGroupKey_Handler,
+		},
+		{
+			MethodName: "GetGroupKey",
+			Handler:    _Chat_GetGroupKey_Handler,
+		},
+		{
+			MethodName: "SetTopic",
+			Handler:    _Chat_SetTopic_Handler,
+		},
+		{
+			MethodName: "GetTopic",
+			Handler:    _Chat_GetTopic_Handler,
+		},
+		{
+			MethodName: "Rename",
+			Handler:    _Chat_Rename_Handler,
+		},
+		{
+			MethodName: "RunCommand",
+			Handler:    _Chat_RunCommand_Handler,
+		},
+		{
+			MethodName: "CreateIncomingHook",
+			Handler:    _Chat_CreateIncomingHook_Handler,
+		},
+		{
+			MethodName: "ListIncomingHooks",
+			Handler:    _Chat_ListIncomingHooks_Handler,
+		},
+		{
+			MethodName: "DeleteIncomingHook",
+			Handler:    _Chat_DeleteIncomingHook_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -527,34 +2295,369 @@ var _Chat_serviceDesc = grpc.ServiceDesc{
 			ServerStreams: true,
 			ClientStreams: true,
 		},
+		{
+			StreamName:    "UploadFile",
+			Handler:       _Chat_UploadFile_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "DownloadFile",
+			Handler:       _Chat_DownloadFile_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "services.proto",
+}
+
+// Client API for Admin service
+
+type AdminClient interface {
+	ListSessions(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*SessionList, error)
+	DisconnectUser(ctx context.Context, in *Disconnect, opts ...grpc.CallOption) (*Empty, error)
+	DeleteGroup(ctx context.Context, in *GroupInfo, opts ...grpc.CallOption) (*Empty, error)
+	BroadcastAnnouncement(ctx context.Context, in *Announcement, opts ...grpc.CallOption) (*Empty, error)
+	GetServerStats(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*ServerStats, error)
+	ReloadConfig(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*ConfigInfo, error)
+}
+
+type adminClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewAdminClient(cc *grpc.ClientConn) AdminClient {
+	return &adminClient{cc}
+}
+
+func (c *adminClient) ListSessions(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*SessionList, error) {
+	out := new(SessionList)
+	err := grpc.Invoke(ctx, "/goChat.Admin/ListSessions", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) DisconnectUser(ctx context.Context, in *Disconnect, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := grpc.Invoke(ctx, "/goChat.Admin/DisconnectUser", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) DeleteGroup(ctx context.Context, in *GroupInfo, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := grpc.Invoke(ctx, "/goChat.Admin/DeleteGroup", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) BroadcastAnnouncement(ctx context.Context, in *Announcement, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := grpc.Invoke(ctx, "/goChat.Admin/BroadcastAnnouncement", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) GetServerStats(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*ServerStats, error) {
+	out := new(ServerStats)
+	err := grpc.Invoke(ctx, "/goChat.Admin/GetServerStats", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) ReloadConfig(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*ConfigInfo, error) {
+	out := new(ConfigInfo)
+	err := grpc.Invoke(ctx, "/goChat.Admin/ReloadConfig", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for Admin service
+
+type AdminServer interface {
+	ListSessions(context.Context, *Empty) (*SessionList, error)
+	DisconnectUser(context.Context, *Disconnect) (*Empty, error)
+	DeleteGroup(context.Context, *GroupInfo) (*Empty, error)
+	BroadcastAnnouncement(context.Context, *Announcement) (*Empty, error)
+	GetServerStats(context.Context, *Empty) (*ServerStats, error)
+	ReloadConfig(context.Context, *Empty) (*ConfigInfo, error)
+}
+
+func RegisterAdminServer(s *grpc.Server, srv AdminServer) {
+	s.RegisterService(&_Admin_serviceDesc, srv)
+}
+
+func _Admin_ListSessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).ListSessions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goChat.Admin/ListSessions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).ListSessions(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_DisconnectUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Disconnect)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).DisconnectUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goChat.Admin/DisconnectUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).DisconnectUser(ctx, req.(*Disconnect))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_DeleteGroup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GroupInfo)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).DeleteGroup(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goChat.Admin/DeleteGroup",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).DeleteGroup(ctx, req.(*GroupInfo))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_BroadcastAnnouncement_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Announcement)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).BroadcastAnnouncement(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goChat.Admin/BroadcastAnnouncement",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).BroadcastAnnouncement(ctx, req.(*Announcement))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_GetServerStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).GetServerStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goChat.Admin/GetServerStats",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).GetServerStats(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_ReloadConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).ReloadConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goChat.Admin/ReloadConfig",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).ReloadConfig(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Admin_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "goChat.Admin",
+	HandlerType: (*AdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListSessions",
+			Handler:    _Admin_ListSessions_Handler,
+		},
+		{
+			MethodName: "DisconnectUser",
+			Handler:    _Admin_DisconnectUser_Handler,
+		},
+		{
+			MethodName: "DeleteGroup",
+			Handler:    _Admin_DeleteGroup_Handler,
+		},
+		{
+			MethodName: "BroadcastAnnouncement",
+			Handler:    _Admin_BroadcastAnnouncement_Handler,
+		},
+		{
+			MethodName: "GetServerStats",
+			Handler:    _Admin_GetServerStats_Handler,
+		},
+		{
+			MethodName: "ReloadConfig",
+			Handler:    _Admin_ReloadConfig_Handler,
+		},
 	},
+	Streams:  []grpc.StreamDesc{},
 	Metadata: "services.proto",
 }
 
 func init() { proto.RegisterFile("services.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 346 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x94, 0x53, 0x4d, 0x4b, 0xf3, 0x40,
-	0x10, 0x6e, 0xde, 0xbe, 0xb6, 0xcd, 0xd4, 0x0a, 0x8e, 0x20, 0x21, 0x78, 0x28, 0xab, 0x48, 0x4f,
-	0xad, 0xad, 0xde, 0xc4, 0x83, 0x14, 0x29, 0x4a, 0xf5, 0x10, 0xf0, 0xe4, 0x29, 0xd6, 0x31, 0x06,
-	0x4c, 0xb6, 0xec, 0x6e, 0x0b, 0xfe, 0x60, 0xff, 0x87, 0xec, 0x6e, 0x36, 0xfd, 0x20, 0x42, 0x3d,
-	0x3e, 0xf3, 0x7c, 0xcc, 0xce, 0x13, 0x02, 0x07, 0x92, 0xc4, 0x32, 0x9d, 0x91, 0xec, 0xcf, 0x05,
-	0x57, 0x1c, 0x1b, 0x09, 0x1f, 0x7f, 0xc4, 0x8a, 0x35, 0x61, 0xef, 0x2e, 0x9b, 0xab, 0x2f, 0xf6,
-	0x02, 0x6d, 0x3d, 0x78, 0x24, 0x29, 0xe3, 0x84, 0xf0, 0x18, 0x1a, 0x92, 0xf2, 0x37, 0x12, 0x81,
-	0xd7, 0xf5, 0x7a, 0x7e, 0x54, 0x20, 0x0c, 0xa1, 0x25, 0x68, 0x46, 0xe9, 0x92, 0x44, 0xf0, 0xcf,
-	0x30, 0x25, 0xc6, 0x00, 0x9a, 0x99, 0xb5, 0x07, 0x75, 0x43, 0x39, 0xc8, 0xce, 0x00, 0xc6, 0x9f,
-	0x29, 0xe5, 0xea, 0x3e, 0x7f, 0xe7, 0xbf, 0x65, 0xb3, 0x5b, 0xf0, 0x27, 0x82, 0x2f, 0xe6, 0x4e,
-	0x34, 0x33, 0x16, 0x27, 0xb2, 0x08, 0x4f, 0xc0, 0x4f, 0xb4, 0xe8, 0x29, 0xce, 0xa8, 0x78, 0xc1,
-	0x6a, 0xc0, 0x4e, 0x8b, 0x88, 0x69, 0x2a, 0x95, 0x8e, 0x30, 0x8c, 0x0c, 0xbc, 0x6e, 0x5d, 0x47,
-	0x58, 0xc4, 0xce, 0xdd, 0x6b, 0x8c, 0x2a, 0x80, 0xa6, 0x8d, 0x76, 0x32, 0x07, 0x47, 0xdf, 0x75,
-	0xf8, 0xaf, 0x3b, 0xc1, 0x6b, 0xf0, 0x23, 0xbe, 0x50, 0x64, 0xc0, 0x51, 0xdf, 0x56, 0xd7, 0x5f,
-	0xab, 0x2b, 0xac, 0x1a, 0xb2, 0x5a, 0xcf, 0xbb, 0xf0, 0x70, 0x08, 0xf0, 0x9c, 0x47, 0x94, 0xa4,
-	0x52, 0x91, 0x40, 0x2c, 0x85, 0x65, 0x1f, 0x61, 0xc7, 0xcd, 0xec, 0x97, 0xa8, 0xe1, 0x00, 0x5a,
-	0x7f, 0x33, 0x0c, 0xa1, 0x3d, 0x16, 0x14, 0x2b, 0x32, 0xc7, 0xe3, 0xa1, 0xe3, 0xcb, 0x3a, 0xab,
-	0x76, 0xf8, 0x0f, 0x3c, 0xcd, 0x77, 0x37, 0x8c, 0x60, 0x7f, 0x42, 0x6a, 0xd5, 0xee, 0xa6, 0x20,
-	0xdc, 0x8c, 0xd0, 0x0a, 0x56, 0xc3, 0x1b, 0x40, 0xe7, 0x59, 0x6b, 0xbc, 0x62, 0xdb, 0xd6, 0x95,
-	0x85, 0xfd, 0x0a, 0x3a, 0x13, 0x52, 0x6b, 0xce, 0xad, 0x9d, 0xd5, 0xae, 0x01, 0xf8, 0x53, 0x8a,
-	0x97, 0x14, 0x71, 0x9e, 0xed, 0x72, 0xd9, 0x6b, 0xc3, 0xfc, 0x12, 0x97, 0x3f, 0x01, 0x00, 0x00,
-	0xff, 0xff, 0x15, 0x0b, 0xaa, 0x80, 0x24, 0x03, 0x00, 0x00,
+	// 1880 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x58, 0x5b, 0x6f, 0x1b, 0xc7,
+	0x15, 0xe6, 0xf2, 0x26, 0xf2, 0xf0, 0xe2, 0xf5, 0xd8, 0x0e, 0x16, 0x8a, 0x11, 0xb0, 0xdb, 0x26,
+	0x21, 0x5c, 0x54, 0xb2, 0x15, 0x4b, 0x30, 0x94, 0xc0, 0x00, 0x4d, 0x6d, 0x14, 0xc6, 0x92, 0x1c,
+	0x8c, 0xa8, 0x16, 0x79, 0x12, 0x56, 0xdc, 0x23, 0x72, 0x4d, 0x72, 0x86, 0xdd, 0x5d, 0x2a, 0x51,
+	0x8b, 0xbe, 0xb4, 0x7f, 0xa0, 0x40, 0x5f, 0x0b, 0xf4, 0x47, 0xb5, 0xef, 0x7d, 0x29, 0xd0, 0xf7,
+	0xfe, 0x82, 0x62, 0x66, 0x76, 0x76, 0x97, 0x37, 0xd5, 0x76, 0xf3, 0xc6, 0x73, 0xbf, 0xcc, 0x99,
+	0x33, 0xdf, 0x12, 0x9a, 0x21, 0x06, 0x37, 0xfe, 0x00, 0xc3, 0x9d, 0x59, 0xc0, 0x23, 0x4e, 0xca,
+	0x43, 0xde, 0x1d, 0xb9, 0xd1, 0xf6, 0xe3, 0x21, 0xe7, 0xc3, 0x09, 0xee, 0xba, 0x33, 0x7f, 0xd7,
+	0x65, 0x8c, 0x47, 0x6e, 0xe4, 0x73, 0x16, 0x6b, 0xd9, 0x5b, 0x50, 0x72, 0xa6, 0xb3, 0xe8, 0xd6,
+	0xfe, 0x5b, 0x01, 0x6a, 0x42, 0xff, 0x14, 0xc3, 0xd0, 0x1d, 0x22, 0xf9, 0x08, 0xca, 0x21, 0x32,
+	0x0f, 0x03, 0xcb, 0x68, 0x19, 0xed, 0x2a, 0x8d, 0x29, 0xb2, 0x0d, 0x95, 0x00, 0x07, 0xe8, 0xdf,
+	0x60, 0x60, 0xe5, 0xa5, 0x24, 0xa1, 0x89, 0x05, 0x5b, 0x53, 0x65, 0x6e, 0x15, 0xa4, 0x48, 0x93,
+	0xa4, 0x09, 0x79, 0xdf, 0xb3, 0x8a, 0x2d, 0xa3, 0x5d, 0xa4, 0x79, 0xdf, 0x23, 0x2d, 0x28, 0x8e,
+	0x7d, 0xe6, 0x59, 0xa5, 0x96, 0xd1, 0x6e, 0xee, 0xd5, 0x77, 0x54, 0xae, 0x3b, 0xaf, 0x7d, 0xe6,
+	0x51, 0x29, 0x11, 0xf1, 0xd1, 0xf3, 0x23, 0xf4, 0xac, 0x72, 0xcb, 0x68, 0x57, 0x68, 0x4c, 0x89,
+	0x18, 0x1e, 0x4e, 0x50, 0x08, 0xb6, 0xa4, 0x40, 0x93, 0x64, 0x07, 0xaa, 0x01, 0xba, 0x03, 0x59,
+	0x9d, 0x55, 0x69, 0x15, 0xda, 0xb5, 0x3d, 0x53, 0x3b, 0xa6, 0xb1, 0x80, 0xa6, 0x2a, 0xe4, 0x63,
+	0xa8, 0xce, 0xdc, 0x00, 0x59, 0x74, 0xe9, 0x7b, 0x56, 0x55, 0xa6, 0x56, 0x51, 0x8c, 0x9e, 0x0c,
+	0x13, 0xe0, 0x6c, 0xe2, 0x63, 0x68, 0x81, 0x14, 0x69, 0x92, 0xfc, 0x02, 0x8a, 0xd7, 0xfe, 0x04,
+	0xad, 0x5a, 0xcb, 0xc8, 0x46, 0xf8, 0xda, 0x9f, 0x60, 0x8f, 0x5d, 0x73, 0x2a, 0xa5, 0xe4, 0x13,
+	0x80, 0x81, 0x3f, 0x1b, 0x61, 0x10, 0xe1, 0x8f, 0x91, 0x55, 0x6f, 0x19, 0xed, 0x3a, 0xcd, 0x70,
+	0xc8, 0x43, 0x28, 0x31, 0xce, 0x06, 0x68, 0x35, 0xa4, 0x48, 0x11, 0x22, 0xa5, 0x31, 0xde, 0x5e,
+	0xe2, 0x8c, 0x0f, 0x46, 0x56, 0x53, 0xa5, 0x34, 0xc6, 0x5b, 0x47, 0xd0, 0xf6, 0x01, 0x40, 0x77,
+	0xe2, 0x8b, 0xf4, 0xd8, 0x35, 0xdf, 0x78, 0x3e, 0x26, 0x14, 0xae, 0x78, 0x24, 0x8f, 0xa6, 0x42,
+	0xc5, 0x4f, 0xfb, 0x12, 0xaa, 0xc7, 0x01, 0x9f, 0xcf, 0xb4, 0xd9, 0x40, 0x3a, 0xd1, 0x66, 0x8a,
+	0x22, 0x8f, 0xa1, 0x3a, 0x14, 0x4a, 0x67, 0xee, 0x14, 0xe3, 0x73, 0x4d, 0x19, 0x42, 0x8a, 0x6c,
+	0x10, 0xdc, 0xce, 0x44, 0xdb, 0x0b, 0xd2, 0x75, 0xca, 0xb0, 0xbf, 0x8f, 0x03, 0x9c, 0xf8, 0x61,
+	0x24, 0x02, 0x48, 0xbb, 0xd0, 0x32, 0x5a, 0x05, 0x11, 0x40, 0x51, 0x82, 0x3f, 0x67, 0x01, 0xba,
+	0x9e, 0x95, 0x6f, 0x15, 0xda, 0x45, 0x1a, 0x53, 0xcb, 0xae, 0x0b, 0x8b, 0xae, 0x0f, 0x75, 0xcd,
+	0xd2, 0xb7, 0x05, 0x5b, 0x2a, 0x5d, 0xed, 0x5c, 0x93, 0x84, 0x40, 0xf1, 0x8a, 0x47, 0xa1, 0xf4,
+	0x5d, 0xa1, 0xf2, 0xb7, 0x4d, 0x01, 0x28, 0xba, 0xde, 0xa9, 0x1b, 0x8c, 0x31, 0xf8, 0xc0, 0xc2,
+	0xd5, 0xdc, 0x16, 0xf4, 0xdc, 0xda, 0xcf, 0xa1, 0x72, 0x8e, 0xc8, 0x64, 0x2b, 0x95, 0xcc, 0xd0,
+	0xb2, 0x6c, 0x76, 0xf9, 0x85, 0xec, 0xec, 0x29, 0xd4, 0xe2, 0x6b, 0xe5, 0x78, 0x7e, 0xf4, 0xd3,
+	0xa4, 0x92, 0xbd, 0x6c, 0xc5, 0x85, 0xcb, 0x66, 0xbf, 0x85, 0xba, 0x9e, 0xf7, 0xff, 0xe3, 0xcc,
+	0x97, 0xe3, 0x3d, 0x84, 0x12, 0x4e, 0xf9, 0x5b, 0x3f, 0x8e, 0xa6, 0x08, 0xfb, 0x10, 0x2a, 0x3a,
+	0x56, 0xaa, 0x61, 0x64, 0x34, 0xee, 0x68, 0x0b, 0x05, 0x88, 0xdb, 0x42, 0xf1, 0xfa, 0x27, 0x3a,
+	0xa0, 0x97, 0x49, 0xab, 0xe5, 0xc4, 0xec, 0x42, 0x25, 0xee, 0x8a, 0x1a, 0x99, 0xda, 0xde, 0x03,
+	0x7d, 0x61, 0x33, 0xcb, 0x8e, 0x26, 0x4a, 0xf6, 0x9f, 0x0d, 0xa8, 0xe8, 0xab, 0x9c, 0x39, 0xe1,
+	0xaa, 0x6c, 0x01, 0x81, 0x22, 0x4b, 0xb3, 0x90, 0xbf, 0x05, 0x2f, 0xf4, 0x7f, 0x87, 0x71, 0x0a,
+	0xf2, 0xb7, 0xbc, 0x9b, 0x23, 0x77, 0x6f, 0xff, 0x20, 0xee, 0x55, 0x4c, 0x65, 0x4a, 0x2c, 0x6d,
+	0x2e, 0xb1, 0xbc, 0x54, 0xa2, 0xed, 0x40, 0x55, 0x64, 0xd4, 0x1d, 0xcd, 0xd9, 0x58, 0x6c, 0x1f,
+	0x9f, 0x5d, 0x73, 0xcb, 0xd8, 0xb4, 0x7d, 0x84, 0x54, 0x24, 0xe5, 0xb9, 0x91, 0x2b, 0x13, 0xad,
+	0x53, 0xf9, 0xdb, 0xde, 0x87, 0x9a, 0xd0, 0xa2, 0xf8, 0xdb, 0x39, 0x86, 0x9b, 0x87, 0x50, 0xd5,
+	0x9c, 0xd7, 0x35, 0xdb, 0xfb, 0x50, 0xfd, 0x6e, 0x7e, 0x35, 0xf1, 0x07, 0xaf, 0xf1, 0x76, 0xa3,
+	0x91, 0x09, 0x85, 0x31, 0xde, 0xc6, 0xe1, 0xc4, 0x4f, 0xfb, 0x00, 0x1a, 0x89, 0x99, 0x3c, 0x89,
+	0x4f, 0xa1, 0x38, 0xc6, 0x5b, 0x7d, 0x0a, 0xf7, 0x75, 0xe2, 0x89, 0x12, 0x95, 0x62, 0xfb, 0x47,
+	0x80, 0xdf, 0x04, 0xee, 0x6c, 0x86, 0xde, 0x5d, 0xf1, 0xc4, 0xd2, 0x98, 0x8d, 0x70, 0x8a, 0x81,
+	0x3b, 0x89, 0xa3, 0xa6, 0x8c, 0x74, 0xb7, 0x16, 0xb2, 0xbb, 0x75, 0x71, 0x23, 0x17, 0x97, 0x37,
+	0xb2, 0xfd, 0x57, 0x03, 0x2a, 0x72, 0x8d, 0xfd, 0x8f, 0xc0, 0x77, 0x0c, 0xa3, 0xb8, 0x00, 0x72,
+	0x75, 0xab, 0x61, 0x50, 0x04, 0xf9, 0x2c, 0xae, 0xbc, 0x28, 0x2b, 0x27, 0xba, 0xf2, 0xb4, 0x4c,
+	0x55, 0xfa, 0xe2, 0x26, 0x2c, 0x2d, 0x2f, 0xd9, 0x73, 0x28, 0xf5, 0xf9, 0xcc, 0x1f, 0x7c, 0x78,
+	0x6a, 0x91, 0x30, 0x8f, 0x1f, 0x66, 0x45, 0xd8, 0x2f, 0x00, 0x84, 0xb4, 0x3b, 0x72, 0x99, 0x7a,
+	0xf2, 0xd7, 0x7a, 0x5e, 0x33, 0xf6, 0x36, 0x83, 0x7a, 0x8f, 0x0d, 0xf8, 0xd4, 0x67, 0xc3, 0x6f,
+	0x38, 0x1f, 0x7f, 0x60, 0x56, 0xda, 0x73, 0x21, 0x73, 0xa1, 0x64, 0xa6, 0x63, 0x64, 0x7a, 0xcf,
+	0x48, 0xc2, 0x7e, 0x09, 0x66, 0x36, 0x9e, 0x1c, 0xa9, 0x27, 0x50, 0x1a, 0x71, 0x3e, 0xd6, 0x33,
+	0xf5, 0x50, 0x77, 0x36, 0xab, 0x48, 0x95, 0x8a, 0xfd, 0x27, 0x03, 0xb6, 0xce, 0x31, 0x0c, 0xc5,
+	0x9e, 0xba, 0x63, 0x8a, 0x17, 0x9f, 0x4e, 0x91, 0xfd, 0x80, 0x33, 0x86, 0x03, 0xfd, 0xee, 0x15,
+	0x68, 0xca, 0x10, 0x9b, 0xcd, 0xf5, 0xbc, 0x00, 0xc3, 0x50, 0x6f, 0xe0, 0x98, 0xcc, 0x3c, 0x82,
+	0xa5, 0xec, 0x23, 0x68, 0x1f, 0x42, 0x2d, 0x4e, 0x42, 0x16, 0xf0, 0x4b, 0xa8, 0x84, 0x8a, 0xd4,
+	0x35, 0xdc, 0xd3, 0x35, 0xc4, 0x6a, 0x34, 0x51, 0xb0, 0xbf, 0x02, 0x38, 0xf2, 0xc3, 0x38, 0xfa,
+	0xc6, 0x1a, 0x3e, 0x82, 0x72, 0x80, 0x6e, 0xc8, 0x59, 0xdc, 0xec, 0x98, 0xb2, 0xdb, 0x50, 0xef,
+	0x30, 0xc6, 0xe7, 0x6c, 0x80, 0x53, 0xa1, 0x97, 0x79, 0x3d, 0x8c, 0xc5, 0xd7, 0xe3, 0x9f, 0x86,
+	0x48, 0x32, 0xb8, 0xc1, 0xe0, 0x3c, 0x72, 0xa3, 0x90, 0x7c, 0x0a, 0xcd, 0xf9, 0x2c, 0xf2, 0xa7,
+	0x78, 0x19, 0xe2, 0x80, 0x33, 0x2f, 0x94, 0x06, 0x05, 0xda, 0x50, 0xdc, 0x73, 0xc5, 0x5c, 0x5c,
+	0xf3, 0x46, 0xbb, 0xb4, 0xfa, 0x36, 0x17, 0x24, 0x5b, 0xfe, 0xce, 0x34, 0xa8, 0x28, 0xb9, 0x31,
+	0x45, 0x3e, 0x87, 0x7b, 0x61, 0xc4, 0x03, 0xf4, 0x2e, 0x93, 0xb5, 0x5d, 0x92, 0x77, 0xa9, 0xa9,
+	0xd8, 0xf1, 0xc2, 0x0e, 0x05, 0x0c, 0xfd, 0x01, 0xaf, 0xd4, 0xf1, 0x97, 0xa5, 0x8b, 0x84, 0x16,
+	0x37, 0x7d, 0xc8, 0x03, 0x3e, 0x8f, 0x7c, 0x86, 0xa1, 0x44, 0x89, 0x25, 0x9a, 0xe1, 0xd8, 0xa7,
+	0x00, 0x5d, 0xce, 0xae, 0xfd, 0xa1, 0x5c, 0xf2, 0x3f, 0x87, 0x86, 0xb6, 0xbc, 0x94, 0xc0, 0x4e,
+	0xf5, 0xa3, 0xae, 0x99, 0x62, 0x69, 0x2e, 0x84, 0xcb, 0x2f, 0x86, 0x7b, 0xf2, 0x1f, 0x03, 0x8a,
+	0x02, 0xb8, 0x92, 0x1a, 0x6c, 0x9d, 0x3a, 0xe7, 0xe7, 0x9d, 0x63, 0xc7, 0xcc, 0x91, 0x0a, 0x14,
+	0x9d, 0xa3, 0x5e, 0xdf, 0x34, 0x08, 0x40, 0xf9, 0xc8, 0x39, 0x71, 0xfa, 0x8e, 0x99, 0x27, 0x55,
+	0x28, 0x51, 0xa7, 0xd3, 0xed, 0x9b, 0x05, 0xa1, 0x7d, 0x71, 0xa6, 0x88, 0xa2, 0x32, 0x3d, 0xeb,
+	0xf7, 0xde, 0x9c, 0x99, 0x25, 0xd2, 0x04, 0xe8, 0xf4, 0xfb, 0x9d, 0xee, 0x37, 0x82, 0x65, 0x96,
+	0x95, 0xd1, 0x6b, 0xe7, 0x7b, 0x73, 0x8b, 0x6c, 0x41, 0x41, 0xfc, 0xa8, 0x48, 0xa7, 0x3d, 0xea,
+	0x74, 0xfb, 0x66, 0x55, 0xc8, 0xfb, 0x6f, 0xbe, 0xeb, 0x75, 0x4d, 0x10, 0xec, 0x4e, 0x57, 0xba,
+	0xa9, 0x89, 0x0c, 0xce, 0x7a, 0xdd, 0xd7, 0x66, 0x5d, 0x78, 0xef, 0xbe, 0x39, 0x3d, 0xed, 0x9c,
+	0x1d, 0x99, 0x0d, 0x42, 0xa0, 0x19, 0x13, 0x97, 0xd4, 0x39, 0xbf, 0x38, 0xe9, 0x9b, 0x4d, 0x62,
+	0x42, 0xbd, 0x73, 0x76, 0xf6, 0xe6, 0xe2, 0xac, 0xeb, 0xc8, 0x98, 0xf7, 0x04, 0x87, 0x76, 0xfa,
+	0xce, 0xe5, 0x49, 0xef, 0xb4, 0xd7, 0x77, 0x8e, 0x4c, 0x93, 0xd4, 0xa1, 0x42, 0x9d, 0x6f, 0x9d,
+	0xae, 0xa0, 0xee, 0xef, 0xfd, 0xbb, 0x01, 0x45, 0x31, 0xa8, 0xe4, 0x4b, 0xa8, 0x52, 0x3e, 0x8f,
+	0x50, 0x12, 0xeb, 0x1e, 0xd7, 0xed, 0x75, 0x4c, 0x3b, 0xd7, 0x36, 0x9e, 0x1a, 0xe4, 0x19, 0xc0,
+	0x05, 0xa3, 0x38, 0xf4, 0xc3, 0x08, 0x03, 0x92, 0xac, 0xc6, 0x14, 0xe6, 0x6e, 0x37, 0x34, 0x4f,
+	0x7d, 0xa5, 0xe4, 0xc4, 0x8b, 0xfe, 0x7e, 0x06, 0x5f, 0x43, 0xad, 0x1b, 0xa0, 0x1b, 0xa1, 0x5c,
+	0xee, 0x24, 0x79, 0x79, 0x12, 0x4c, 0xbc, 0x6c, 0xf2, 0xe8, 0x8f, 0x7f, 0xff, 0xd7, 0x5f, 0xf2,
+	0xf7, 0x6c, 0xd8, 0xbd, 0x79, 0xb6, 0xab, 0xe6, 0xf2, 0xd0, 0x78, 0x42, 0x2e, 0xa0, 0xfa, 0x2d,
+	0xf7, 0xd9, 0xbb, 0x7a, 0xf9, 0x4c, 0x7a, 0x69, 0xd9, 0x1f, 0xa7, 0x5e, 0x76, 0x7f, 0x9f, 0x6c,
+	0xbe, 0x3f, 0xec, 0xbe, 0xe5, 0x3e, 0x13, 0x6e, 0x7b, 0x50, 0x3f, 0xc6, 0x28, 0xc5, 0xcf, 0xeb,
+	0x6a, 0x5a, 0x8c, 0x26, 0xd4, 0x6c, 0x22, 0xdd, 0xd7, 0x49, 0x26, 0x49, 0x32, 0x00, 0xa2, 0x5d,
+	0x65, 0x40, 0xf3, 0x9a, 0x54, 0x97, 0x62, 0x48, 0x87, 0x71, 0xbe, 0xe4, 0x93, 0x0d, 0xf9, 0xea,
+	0xdb, 0x7c, 0x0c, 0x8d, 0x63, 0x8c, 0x32, 0xfe, 0x17, 0xeb, 0x5e, 0xeb, 0xfb, 0x81, 0xf4, 0xdd,
+	0x20, 0x35, 0xe1, 0x5b, 0x3b, 0xfa, 0x35, 0x54, 0x4f, 0xd0, 0xbd, 0x41, 0xca, 0xf9, 0xf4, 0x1d,
+	0xfa, 0xf9, 0xb9, 0xf4, 0xf1, 0x33, 0xfb, 0xf1, 0x86, 0xfc, 0x26, 0xc2, 0x97, 0x68, 0xe8, 0x2e,
+	0x54, 0x04, 0xe4, 0x17, 0xd0, 0x3f, 0x6d, 0x66, 0xfa, 0x21, 0xb0, 0x3a, 0x20, 0x7b, 0x50, 0x3d,
+	0xc6, 0x48, 0xc0, 0xfa, 0x57, 0xb7, 0xeb, 0x12, 0x31, 0xd3, 0x9d, 0xac, 0x90, 0xbf, 0x9d, 0x23,
+	0x5f, 0x40, 0x4d, 0x40, 0x79, 0xfd, 0xb1, 0x9c, 0x8c, 0x78, 0x06, 0xe6, 0xaf, 0x06, 0xda, 0x87,
+	0xc6, 0x91, 0xfc, 0x56, 0x7d, 0x3f, 0xb3, 0xe7, 0x50, 0x92, 0x10, 0x9b, 0x3c, 0x5c, 0xfe, 0x9a,
+	0x95, 0xe9, 0xad, 0xbf, 0x5e, 0xe4, 0x40, 0x56, 0xd5, 0x1f, 0x05, 0x0b, 0x7d, 0x48, 0xf1, 0xf6,
+	0xf6, 0x72, 0x70, 0x79, 0x52, 0x39, 0xf2, 0x02, 0x6a, 0xc7, 0x18, 0x9d, 0x22, 0x53, 0x1f, 0xc9,
+	0xeb, 0xc6, 0x71, 0x83, 0xe5, 0x3e, 0xc0, 0xc5, 0x6c, 0xc2, 0x5d, 0x4f, 0x6e, 0xcc, 0xfb, 0x59,
+	0x68, 0x2a, 0xb1, 0xeb, 0xf6, 0x0a, 0x5a, 0x15, 0x5b, 0x80, 0x1c, 0x42, 0xfd, 0x88, 0xff, 0xc0,
+	0x12, 0xc3, 0x07, 0x59, 0xad, 0x18, 0xad, 0x6e, 0xaf, 0x7a, 0xb3, 0x73, 0x4f, 0x0d, 0xf2, 0x14,
+	0x40, 0x02, 0xc8, 0x70, 0x24, 0x40, 0xdb, 0x2a, 0xa8, 0x5c, 0x6d, 0xe6, 0x57, 0x72, 0x7c, 0x13,
+	0x85, 0x95, 0x02, 0x45, 0x2d, 0xdb, 0x8f, 0x56, 0x1c, 0xc5, 0x25, 0xee, 0x41, 0xe3, 0x7c, 0xe4,
+	0x06, 0x98, 0xe0, 0x44, 0x73, 0x61, 0x5c, 0xd6, 0x46, 0x7c, 0x2e, 0x1b, 0x9a, 0x58, 0xdc, 0x35,
+	0x60, 0x5a, 0xc9, 0xce, 0x91, 0x27, 0xe2, 0x43, 0x33, 0x52, 0x88, 0x2f, 0x71, 0x29, 0xc9, 0xd5,
+	0x08, 0x3b, 0x50, 0x39, 0xd6, 0xba, 0x77, 0x5d, 0x24, 0xa9, 0x61, 0xe7, 0xc8, 0xaf, 0xa0, 0x4c,
+	0x51, 0x7d, 0xbc, 0x68, 0x51, 0x8a, 0x02, 0x57, 0xdd, 0xbf, 0x00, 0xa0, 0x73, 0xd6, 0xe5, 0xd3,
+	0xa9, 0xcb, 0xbc, 0xf7, 0x59, 0xf1, 0xe4, 0x15, 0x10, 0xb5, 0x7a, 0x17, 0xa0, 0xe2, 0x5a, 0x9c,
+	0xb6, 0xbd, 0x96, 0x2b, 0x7d, 0xdc, 0x17, 0xcd, 0xcf, 0x72, 0xc3, 0x75, 0x55, 0x5a, 0xeb, 0xec,
+	0xe3, 0x63, 0xfb, 0x12, 0x88, 0xba, 0x78, 0xef, 0x90, 0xc7, 0x72, 0xf9, 0x7b, 0xff, 0xc8, 0x43,
+	0xa9, 0xe3, 0x4d, 0x7d, 0x46, 0x9e, 0x43, 0x5d, 0x38, 0x8c, 0xa1, 0x59, 0xb8, 0xbc, 0xf9, 0x1e,
+	0x2c, 0x61, 0xb7, 0xe4, 0x5a, 0x34, 0x53, 0xdc, 0x76, 0x11, 0x66, 0x9f, 0xad, 0x94, 0xbf, 0xda,
+	0xf5, 0x67, 0x50, 0x53, 0x39, 0xbf, 0xeb, 0x83, 0x93, 0x23, 0x2f, 0xe1, 0xd1, 0xab, 0x80, 0xbb,
+	0xde, 0xc0, 0x0d, 0xa3, 0x05, 0xb0, 0x97, 0x54, 0x9a, 0xe5, 0xae, 0xda, 0x1f, 0x40, 0x53, 0x2e,
+	0xc2, 0x14, 0xfb, 0x6d, 0xae, 0x30, 0xd1, 0x91, 0xcb, 0xb0, 0x4e, 0x51, 0xdc, 0x5f, 0x85, 0xaa,
+	0x36, 0xbf, 0x08, 0x09, 0xe8, 0xb2, 0x73, 0x57, 0x65, 0xf9, 0xff, 0xe3, 0x17, 0xff, 0x1d, 0x00,
+	0x15, 0xbb, 0x96, 0x89, 0xb7, 0x14, 0x00, 0x00,
 }